@@ -0,0 +1,74 @@
+package jsonvalue
+
+import (
+	"testing"
+)
+
+type benchImportItem struct {
+	ID    int               `json:"id"`
+	Name  string            `json:"name"`
+	Score *float64          `json:"score"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+	Next  *benchImportItem  `json:"next,omitempty"`
+}
+
+func newBenchImportItems() []*benchImportItem {
+	items := make([]*benchImportItem, 100)
+	for i := range items {
+		score := float64(i) / 3
+		items[i] = &benchImportItem{
+			ID:    i,
+			Name:  "item",
+			Score: &score,
+			Tags:  []string{"a", "b", "c"},
+			Attrs: map[string]string{"k1": "v1", "k2": "v2"},
+			Next:  &benchImportItem{ID: -i, Tags: []string{"d"}},
+		}
+	}
+	return items
+}
+
+func BenchmarkImport(b *testing.B) {
+	items := newBenchImportItems()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Import(items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func newBenchUnmarshalData() []byte {
+	v, _ := Import(newBenchImportItems())
+	return v.MustMarshal()
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	data := newBenchUnmarshalData()
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Unmarshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshalBinary(b *testing.B) {
+	data, err := MustUnmarshal(newBenchUnmarshalData()).MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v := &V{}
+		if err := v.UnmarshalBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -9,6 +9,12 @@ import (
 //
 // Contains 表示是否包含某个子集。只对 object 和 array 类型有效, 其他类型则需完全相等时,
 // 才返回 true
+//
+// Deprecated: please use jsonvalue.Contains instead, which also checks elements of arrays
+// recursively, rather than requiring them to be equal. This function keeps its original
+// behavior.
+//
+// Deprecated: 请改用 jsonvalue.Contains, 其对数组成员也会递归检查，而非要求相等。本函数保持原有行为。
 func Contains(v *jsonvalue.V, sub interface{}, inPath ...interface{}) bool {
 	if v == nil {
 		return false
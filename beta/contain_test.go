@@ -57,4 +57,14 @@ func testContains(t *testing.T) {
 		f(true, `{"a":[{}, {"b":22,"c":222}, 23, "hello"]}`, P{"a", 1}, `{"c":222}`)
 		// f(false, testjson, `$.store.book[*].category`, `{}`)
 	})
+
+	cv("array elements should equal", func() {
+		v := jsonvalue.MustUnmarshalString(`{"a":[{"b":1,"c":2},{"d":3}]}`)
+		sub := jsonvalue.MustUnmarshalString(`{"a":[{"b":1}]}`)
+		so(Contains(v, sub), isFalse)
+		so(jsonvalue.Contains(v, sub), isTrue)
+
+		sub = jsonvalue.MustUnmarshalString(`{"a":[{"b":1,"c":2}]}`)
+		so(Contains(v, sub), isTrue)
+	})
 }
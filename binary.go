@@ -0,0 +1,419 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*V)(nil)
+	_ encoding.BinaryUnmarshaler = (*V)(nil)
+)
+
+// binary format:
+//
+//	header: "JVB" | version (1 byte) | node count (uint32, little endian)
+//	value:  type (1 byte) | payload
+//
+// payloads by types:
+//
+//	null, false, true: none
+//	number: flags (1 byte) | f64 bits (8 bytes) if floated, else u64 (uvarint) and optional f64 bits (8 bytes) |
+//	        source text length (uvarint) | source text, which are omitted if the source text is the default formatting
+//	string: length (uvarint) | bytes
+//	array:  count (uvarint) | values
+//	object: count (uvarint) | (key length (uvarint) | key | value) in set sequence
+const (
+	binaryMagic      = "JVB"
+	binaryVersion    = 1
+	binaryHeaderSize = len(binaryMagic) + 1 + 4
+)
+
+const (
+	binaryNull   byte = 0
+	binaryFalse  byte = 1
+	binaryTrue   byte = 2
+	binaryNumber byte = 3
+	binaryString byte = 4
+	binaryArray  byte = 5
+	binaryObject byte = 6
+)
+
+// flags of numbers
+const (
+	binaryNumNegative byte = 1 << iota
+	binaryNumFloated
+	binaryNumF64       // f64 of an integer is given explicitly as it is not derived from u64
+	binaryNumCanonical // source text is omitted as it is the default formatting of the number
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler, encoding *V into a versioned compact binary format. Number
+// source texts, set sequence of objects and string contents are preserved exactly. As numbers and strings are stored
+// in decoded forms, UnmarshalBinary() skips parsing and escaping. In BenchmarkUnmarshalBinary, it takes about half the
+// time of Unmarshal() on the same value.
+//
+// MarshalBinary 实现 encoding.BinaryMarshaler, 将 *V 编码为带版本号的紧凑二进制格式。数字的原始文本、object 的 set 顺序以及字符串内容
+// 均会被精确保留。由于数字和字符串以解码后的形式保存，UnmarshalBinary() 无需进行解析和转义。在 BenchmarkUnmarshalBinary 中，其耗时约为
+// 对同一个值使用 Unmarshal() 的一半。
+func (v *V) MarshalBinary() ([]byte, error) {
+	if v == nil || v.valueType == NotExist {
+		return nil, ErrValueUninitialized
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString(binaryMagic)
+	buf.WriteByte(binaryVersion)
+	buf.Write([]byte{0, 0, 0, 0})
+
+	cnt := 0
+	if err := v.marshalBinary(&buf, &cnt); err != nil {
+		return nil, err
+	}
+	b := buf.Bytes()
+	binary.LittleEndian.PutUint32(b[len(binaryMagic)+1:], uint32(cnt))
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding data generated by MarshalBinary() into *V.
+//
+// UnmarshalBinary 实现 encoding.BinaryUnmarshaler, 将 MarshalBinary() 生成的数据解码到 *V 中。
+func (v *V) UnmarshalBinary(data []byte) error {
+	if v == nil {
+		return ErrNilParameter
+	}
+	if len(data) < binaryHeaderSize || string(data[:len(binaryMagic)]) != binaryMagic {
+		return fmt.Errorf("%w: binary: invalid header", ErrRawBytesUnrecignized)
+	}
+	if ver := data[len(binaryMagic)]; ver != binaryVersion {
+		return fmt.Errorf("%w: binary: unsupported version %d", ErrRawBytesUnrecignized, ver)
+	}
+
+	// every value takes at least one byte
+	cnt := binary.LittleEndian.Uint32(data[len(binaryMagic)+1:])
+	if cnt == 0 || uint64(cnt) > uint64(len(data)-binaryHeaderSize) {
+		return fmt.Errorf("%w: binary: invalid value count %d", ErrRawBytesUnrecignized, cnt)
+	}
+
+	// strings and source texts of numbers refer to one private copy of data
+	b := append([]byte{}, data...)
+	d := binaryDecoder{
+		b:     b,
+		s:     unsafeBtoS(b),
+		off:   binaryHeaderSize,
+		nodes: make([]V, cnt),
+		ptrs:  make([]*V, cnt-1),
+	}
+	res, err := d.decode()
+	if err != nil {
+		return err
+	}
+	if d.off != len(b) {
+		return d.errorf("%d extra bytes", len(b)-d.off)
+	}
+	if d.used != len(d.nodes) {
+		return d.errorf("value count mismatch, %d expected but %d got", len(d.nodes), d.used)
+	}
+	*v = *res
+	return nil
+}
+
+// ---------------- encoding ----------------
+
+func (v *V) marshalBinary(buf *bytes.Buffer, cnt *int) error {
+	*cnt++
+
+	switch v.valueType {
+	default:
+		return ErrValueUninitialized
+
+	case Null:
+		buf.WriteByte(binaryNull)
+
+	case Boolean:
+		if v.valueBool {
+			buf.WriteByte(binaryTrue)
+		} else {
+			buf.WriteByte(binaryFalse)
+		}
+
+	case Number:
+		v.marshalBinaryNumber(buf)
+
+	case String:
+		buf.WriteByte(binaryString)
+		writeUvarint(buf, uint64(len(v.valueStr)))
+		buf.WriteString(v.valueStr)
+
+	case Array:
+		buf.WriteByte(binaryArray)
+		writeUvarint(buf, uint64(len(v.children.arr)))
+		for _, child := range v.children.arr {
+			if err := child.marshalBinary(buf, cnt); err != nil {
+				return err
+			}
+		}
+
+	case Object:
+		buf.WriteByte(binaryObject)
+		writeUvarint(buf, uint64(len(v.children.object)))
+		var err error
+		v.RangeObjectsBySetSequence(func(k string, child *V) bool {
+			writeUvarint(buf, uint64(len(k)))
+			buf.WriteString(k)
+			err = child.marshalBinary(buf, cnt)
+			return err == nil
+		})
+		return err
+	}
+
+	return nil
+}
+
+func (v *V) marshalBinaryNumber(buf *bytes.Buffer) {
+	buf.WriteByte(binaryNumber)
+
+	n := v.num
+	flags := byte(0)
+	if n.negative {
+		flags |= binaryNumNegative
+	}
+	if n.floated {
+		flags |= binaryNumFloated
+	} else if math.Float64bits(n.f64) != math.Float64bits(binaryIntToFloat(n.u64, n.negative)) {
+		flags |= binaryNumF64
+	}
+	var arr [32]byte
+	if bytes.Equal(appendBinaryCanonicalNumber(arr[:0], &n), v.srcByte) {
+		flags |= binaryNumCanonical
+	}
+	buf.WriteByte(flags)
+
+	if n.floated {
+		writeLittleEndian(buf, math.Float64bits(n.f64), 8)
+	} else {
+		writeUvarint(buf, n.u64)
+		if flags&binaryNumF64 != 0 {
+			writeLittleEndian(buf, math.Float64bits(n.f64), 8)
+		}
+	}
+
+	if flags&binaryNumCanonical == 0 {
+		writeUvarint(buf, uint64(len(v.srcByte)))
+		buf.Write(v.srcByte)
+	}
+}
+
+// appendBinaryCanonicalNumber appends the default formatting of a number, which is the same as NewInt64(),
+// NewUint64() and NewFloat64().
+func appendBinaryCanonicalNumber(b []byte, n *num) []byte {
+	if !n.floated {
+		if n.negative {
+			return strconv.AppendInt(b, int64(n.u64), 10)
+		}
+		return strconv.AppendUint(b, n.u64, 10)
+	}
+	if !isValidFloat(n.f64) {
+		return b
+	}
+	format := byte('f')
+	if abs := math.Abs(n.f64); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	return strconv.AppendFloat(b, n.f64, format, -1, 64)
+}
+
+func binaryIntToFloat(u uint64, negative bool) float64 {
+	if negative {
+		return float64(int64(u))
+	}
+	return float64(u)
+}
+
+func writeUvarint(buf *bytes.Buffer, u uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], u)
+	buf.Write(b[:n])
+}
+
+// ---------------- decoding ----------------
+
+type binaryDecoder struct {
+	b     []byte
+	s     string
+	off   int
+	nodes []V
+	used  int
+	ptrs  []*V   // children of all arrays, which are less than nodes
+	texts []byte // source texts of numbers which are omitted in data
+}
+
+func (d *binaryDecoder) errorf(format string, a ...any) error {
+	return fmt.Errorf("%w: binary at offset %d: %s", ErrRawBytesUnrecignized, d.off, fmt.Sprintf(format, a...))
+}
+
+func (d *binaryDecoder) readUvarint() (uint64, error) {
+	u, n := binary.Uvarint(d.b[d.off:])
+	if n <= 0 {
+		return 0, d.errorf("invalid varint")
+	}
+	d.off += n
+	return u, nil
+}
+
+// readLength reads a length or count, which should not exceed remaining bytes.
+func (d *binaryDecoder) readLength() (int, error) {
+	u, err := d.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	if u > uint64(len(d.b)-d.off) {
+		return 0, d.errorf("length %d exceeds remaining %d bytes", u, len(d.b)-d.off)
+	}
+	return int(u), nil
+}
+
+func (d *binaryDecoder) readFloat64() (float64, error) {
+	if len(d.b)-d.off < 8 {
+		return 0, d.errorf("unexpected end of data")
+	}
+	f := math.Float64frombits(binary.LittleEndian.Uint64(d.b[d.off:]))
+	d.off += 8
+	return f, nil
+}
+
+func (d *binaryDecoder) decode() (*V, error) {
+	if d.used >= len(d.nodes) {
+		return nil, d.errorf("more values than declared %d", len(d.nodes))
+	}
+	if d.off >= len(d.b) {
+		return nil, d.errorf("unexpected end of data")
+	}
+	v := &d.nodes[d.used]
+	d.used++
+	typ := d.b[d.off]
+	d.off++
+
+	switch typ {
+	default:
+		d.off--
+		return nil, d.errorf("unknown type 0x%02x", typ)
+
+	case binaryNull:
+		v.valueType = Null
+
+	case binaryFalse, binaryTrue:
+		v.valueType = Boolean
+		v.valueBool = typ == binaryTrue
+
+	case binaryNumber:
+		v.valueType = Number
+		if err := d.decodeNumber(v); err != nil {
+			return nil, err
+		}
+
+	case binaryString:
+		v.valueType = String
+		le, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		v.valueStr = d.s[d.off : d.off+le]
+		d.off += le
+
+	case binaryArray:
+		v.valueType = Array
+		cnt, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		if cnt > len(d.ptrs) {
+			return nil, d.errorf("more values than declared %d", len(d.nodes))
+		}
+		v.children.arr = d.ptrs[:cnt:cnt]
+		d.ptrs = d.ptrs[cnt:]
+		for i := range v.children.arr {
+			if v.children.arr[i], err = d.decode(); err != nil {
+				return nil, err
+			}
+		}
+
+	case binaryObject:
+		v.valueType = Object
+		cnt, err := d.readLength()
+		if err != nil {
+			return nil, err
+		}
+		v.children.object = make(map[string]childWithProperty, cnt)
+		for i := 0; i < cnt; i++ {
+			le, err := d.readLength()
+			if err != nil {
+				return nil, err
+			}
+			k := d.s[d.off : d.off+le]
+			d.off += le
+			child, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			v.setToObjectChildren(k, child)
+		}
+	}
+
+	return v, nil
+}
+
+func (d *binaryDecoder) decodeNumber(v *V) error {
+	if d.off >= len(d.b) {
+		return d.errorf("unexpected end of data")
+	}
+	flags := d.b[d.off]
+	d.off++
+
+	n := &v.num
+	n.negative = flags&binaryNumNegative != 0
+	n.floated = flags&binaryNumFloated != 0
+
+	var err error
+	if n.floated {
+		if n.f64, err = d.readFloat64(); err != nil {
+			return err
+		}
+		n.i64 = int64(n.f64)
+		n.u64 = uint64(n.f64)
+	} else {
+		if n.u64, err = d.readUvarint(); err != nil {
+			return err
+		}
+		n.i64 = int64(n.u64)
+		if flags&binaryNumF64 != 0 {
+			if n.f64, err = d.readFloat64(); err != nil {
+				return err
+			}
+		} else {
+			n.f64 = binaryIntToFloat(n.u64, n.negative)
+		}
+	}
+
+	if flags&binaryNumCanonical != 0 {
+		start := len(d.texts)
+		d.texts = appendBinaryCanonicalNumber(d.texts, n)
+		if end := len(d.texts); end > start {
+			v.srcByte = d.texts[start:end:end]
+		}
+		return nil
+	}
+
+	le, err := d.readLength()
+	if err != nil {
+		return err
+	}
+	if le > 0 {
+		v.srcByte = d.b[d.off : d.off+le : d.off+le]
+	}
+	d.off += le
+	return nil
+}
@@ -0,0 +1,171 @@
+package jsonvalue
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func testBinary(t *testing.T) {
+	cv("round trip", func() { testBinaryRoundTrip(t) })
+	cv("numbers", func() { testBinaryNumbers(t) })
+	cv("set sequence", func() { testBinarySetSequence(t) })
+	cv("errors", func() { testBinaryErrors(t) })
+}
+
+func binaryRoundTrip(v *V) *V {
+	b, err := v.MarshalBinary()
+	so(err, isNil)
+	res := &V{}
+	err = res.UnmarshalBinary(b)
+	so(err, isNil)
+	return res
+}
+
+func testBinaryRoundTrip(t *testing.T) {
+	raw := `{"str":"Hello, 世界","esc":"\"\\\/\u0000\n","num":[1.50,1e3,-0,18446744073709551615,-9223372036854775808,0.1],` +
+		`"bool":[true,false],"nil":null,"obj":{"arr":[[],{}],"empty":""}}`
+	v := MustUnmarshalString(raw)
+	res := binaryRoundTrip(v)
+	so(res.Equal(v), isTrue)
+	so(res.MustMarshalString(OptSetSequence()), eq, v.MustMarshalString(OptSetSequence()))
+
+	// string contents are preserved exactly
+	for _, s := range []string{"", "a\x00b", "\xff\xfe invalid UTF-8", "emoji 😀"} {
+		res := binaryRoundTrip(NewString(s))
+		so(res.ValueType(), eq, String)
+		so(res.String(), eq, s)
+	}
+
+	// scalars at root
+	so(binaryRoundTrip(NewNull()).IsNull(), isTrue)
+	so(binaryRoundTrip(NewBool(true)).Bool(), isTrue)
+	so(binaryRoundTrip(NewBool(false)).IsBoolean(), isTrue)
+	so(binaryRoundTrip(NewBool(false)).Bool(), isFalse)
+
+	// decoded value is independent of the source bytes and could be modified
+	b, err := v.MustGet("obj").MarshalBinary()
+	so(err, isNil)
+	res = &V{}
+	so(res.UnmarshalBinary(b), isNil)
+	for i := range b {
+		b[i] = 0
+	}
+	res.SetString("x").At("arr", 0)
+	res.SetString("y").At("new")
+	so(res.MustMarshalString(OptSetSequence()), eq, `{"arr":["x",{}],"empty":"","new":"y"}`)
+	so(res.MustGet("empty").ValueType(), eq, String)
+}
+
+func testBinaryNumbers(t *testing.T) {
+	v := MustUnmarshalString(`[1.50,1E+3,-0,0.0,18446744073709551615,-9223372036854775808,-5,1.5e300]`)
+	res := binaryRoundTrip(v)
+	so(res.MustMarshalString(), eq, `[1.50,1E+3,-0,0.0,18446744073709551615,-9223372036854775808,-5,1.5e300]`)
+
+	for i, child := range v.children.arr {
+		got := res.children.arr[i]
+		so(string(got.srcByte), eq, string(child.srcByte))
+		so(got.IsFloat(), eq, child.IsFloat())
+		so(got.IsNegative(), eq, child.IsNegative())
+		so(got.Int64(), eq, child.Int64())
+		so(got.Uint64(), eq, child.Uint64())
+		so(math.Float64bits(got.Float64()), eq, math.Float64bits(child.Float64()))
+	}
+
+	// numbers created by NewXxx()
+	values := []*V{
+		NewInt64(-42), NewInt64(math.MinInt64), NewUint64(math.MaxUint64), NewFloat64(3.14),
+		NewFloat64(math.NaN()), NewFloat64(math.Inf(1)), NewFloat64(math.Inf(-1)), NewFloat32(0.5),
+	}
+	for _, v := range values {
+		got := binaryRoundTrip(v)
+		so(got.num.negative, eq, v.num.negative)
+		so(got.num.floated, eq, v.num.floated)
+		so(got.num.i64, eq, v.num.i64)
+		so(got.num.u64, eq, v.num.u64)
+		so(math.Float64bits(got.num.f64), eq, math.Float64bits(v.num.f64))
+		so(string(got.srcByte), eq, string(v.srcByte))
+	}
+
+	// source texts in default formatting are omitted: type, flags and uvarint for each number
+	b, err := MustUnmarshalString(`[1,2,3]`).MarshalBinary()
+	so(err, isNil)
+	so(len(b), eq, binaryHeaderSize+2+3*3)
+	b, err = MustUnmarshalString(`[1.5,-2]`).MarshalBinary()
+	so(err, isNil)
+	so(len(b), eq, binaryHeaderSize+2+(2+8)+(2+10+8))
+	b, err = MustUnmarshalString(`[1.50,1e-7]`).MarshalBinary()
+	so(err, isNil)
+	so(len(b), eq, binaryHeaderSize+2+(2+8+1+4)+(2+8+1+4))
+}
+
+func testBinarySetSequence(t *testing.T) {
+	v := NewObject()
+	v.SetInt(1).At("c")
+	v.SetInt(2).At("a")
+	v.SetInt(3).At("b")
+	v.SetObject().At("d")
+	v.SetInt(4).At("d", "z")
+	v.SetInt(5).At("d", "y")
+
+	res := binaryRoundTrip(v)
+	so(res.MustMarshalString(OptSetSequence()), eq, `{"c":1,"a":2,"b":3,"d":{"z":4,"y":5}}`)
+
+	res.SetInt(6).At("0")
+	so(res.MustMarshalString(OptSetSequence()), eq, `{"c":1,"a":2,"b":3,"d":{"z":4,"y":5},"0":6}`)
+
+	// caseless getting
+	v = MustUnmarshalString(`{"Hello":"world"}`)
+	res = binaryRoundTrip(v)
+	so(res.Caseless().MustGet("hello").String(), eq, "world")
+}
+
+func testBinaryErrors(t *testing.T) {
+	_, err := (&V{}).MarshalBinary()
+	so(err, isErr)
+	_, err = (*V)(nil).MarshalBinary()
+	so(err, isErr)
+	so((*V)(nil).UnmarshalBinary(nil), isErr)
+
+	v := MustUnmarshalString(`{"a":[1.5,-2,"s",true,null,{"b":{}}],"c":"d"}`)
+	b, err := v.MarshalBinary()
+	so(err, isNil)
+
+	// truncated data
+	for i := 0; i < len(b); i++ {
+		err := (&V{}).UnmarshalBinary(b[:i])
+		so(err, isErr)
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+	}
+
+	expectErr := func(modify func(b []byte) []byte, msg string) {
+		data := modify(append([]byte{}, b...))
+		err := (&V{}).UnmarshalBinary(data)
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+		so(err.Error(), hasSubStr, msg)
+	}
+
+	expectErr(func(b []byte) []byte { b[0] = 'X'; return b }, "invalid header")
+	expectErr(func(b []byte) []byte { b[3] = 2; return b }, "unsupported version")
+	expectErr(func(b []byte) []byte { return append(b, 0) }, "extra bytes")
+	expectErr(func(b []byte) []byte { b[4] = 0; return b }, "invalid value count")
+	expectErr(func(b []byte) []byte { b[4], b[5], b[6], b[7] = 0xFF, 0xFF, 0xFF, 0x7F; return b }, "invalid value count")
+	expectErr(func(b []byte) []byte { b[4]--; return b }, "more values than declared")
+	expectErr(func(b []byte) []byte { b[4]++; return b }, "value count mismatch")
+	expectErr(func(b []byte) []byte { b[binaryHeaderSize] = 0x7F; return b }, "unknown type")
+
+	// huge counts and lengths
+	expectErr(func(b []byte) []byte {
+		b[4] = 1
+		return append(b[:binaryHeaderSize], binaryArray, 0xFF, 0xFF, 0xFF, 0xFF, 0x0F)
+	}, "exceeds remaining")
+	expectErr(func(b []byte) []byte {
+		b[4] = 1
+		return append(b[:binaryHeaderSize], binaryString, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01)
+	}, "invalid varint")
+
+	// target value is not changed on error
+	target := MustUnmarshalString(`{"keep":true}`)
+	so(target.UnmarshalBinary(b[:len(b)-1]), isErr)
+	so(target.MustMarshalString(), eq, `{"keep":true}`)
+}
@@ -0,0 +1,976 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// UnmarshalBSON decodes a BSON document into an object *V. BSON types without JSON counterparts are represented in
+// MongoDB Extended JSON v2 format, such as {"$oid": "..."} for ObjectId and {"$numberDecimal": "..."} for
+// Decimal128. Relaxed format is used by default, where int32, int64 and finite doubles are decoded into plain
+// numbers, and dates between year 1970 and 9999 are decoded into ISO-8601 strings. Use OptBSONCanonical() for
+// canonical format, which preserves type information of all numbers.
+//
+// Field order is kept as set sequence, which is also used by MarshalBSON() by default.
+//
+// UnmarshalBSON 将一个 BSON 文档解码为 object 类型的 *V。JSON 中没有对应类型的 BSON 类型以 MongoDB Extended JSON v2 格式表示，
+// 比如 ObjectId 表示为 {"$oid": "..."}, Decimal128 表示为 {"$numberDecimal": "..."}。默认使用宽松 (relaxed) 格式，即 int32、
+// int64 以及有限的 double 值被解码为普通数字，1970 年至 9999 年之间的日期被解码为 ISO-8601 字符串。使用 OptBSONCanonical()
+// 则输出规范 (canonical) 格式，保留所有数字的类型信息。
+//
+// 字段顺序被保存为 set 顺序，MarshalBSON() 默认也使用该顺序。
+func UnmarshalBSON(b []byte, opts ...Option) (*V, error) {
+	d := bsonDecoder{b: b, canonical: combineOptions(opts).bsonCanonical}
+	v, err := d.decodeDocument(false)
+	if err != nil {
+		return &V{}, err
+	}
+	if d.off != len(b) {
+		return &V{}, fmt.Errorf("%w: %d extra bytes after BSON document", ErrRawBytesUnrecignized, len(b)-d.off)
+	}
+	return v, nil
+}
+
+// MarshalBSON encodes an object *V into a BSON document. Objects in MongoDB Extended JSON v2 format, either
+// canonical or relaxed, are converted into corresponding BSON types. Plain integers are encoded as int32 if
+// possible, otherwise int64, while floats are encoded as double.
+//
+// Fields are encoded in set sequence by default, unless OptKeySequence() or OptKeySequenceWithLessFunc() is given.
+//
+// MarshalBSON 将 object 类型的 *V 编码为 BSON 文档。MongoDB Extended JSON v2 格式的 object, 无论规范格式还是宽松格式，均会被转为
+// 对应的 BSON 类型。普通整数尽可能编码为 int32, 否则编码为 int64; 浮点数则编码为 double。
+//
+// 默认情况下，字段按照 set 顺序编码，除非指定了 OptKeySequence() 或 OptKeySequenceWithLessFunc()。
+func (v *V) MarshalBSON(opts ...Option) ([]byte, error) {
+	if v == nil || v.valueType == NotExist {
+		return nil, ErrValueUninitialized
+	}
+	if v.valueType != Object {
+		return nil, ErrNotObjectValue
+	}
+
+	opt := combineOptions(opts)
+	if opt.MarshalLessFunc == nil && len(opt.MarshalKeySequence) == 0 {
+		opt.marshalBySetSequence = true
+	}
+
+	buf := bytes.Buffer{}
+	if err := v.marshalBSONDocument(nil, &buf, opt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// BSON element types
+const (
+	bsonDouble     byte = 0x01
+	bsonString     byte = 0x02
+	bsonDocument   byte = 0x03
+	bsonArray      byte = 0x04
+	bsonBinary     byte = 0x05
+	bsonUndefined  byte = 0x06
+	bsonObjectID   byte = 0x07
+	bsonBoolean    byte = 0x08
+	bsonDateTime   byte = 0x09
+	bsonNull       byte = 0x0a
+	bsonRegex      byte = 0x0b
+	bsonDBPointer  byte = 0x0c
+	bsonJavaScript byte = 0x0d
+	bsonSymbol     byte = 0x0e
+	bsonCodeWScope byte = 0x0f
+	bsonInt32      byte = 0x10
+	bsonTimestamp  byte = 0x11
+	bsonInt64      byte = 0x12
+	bsonDecimal128 byte = 0x13
+	bsonMinKey     byte = 0xff
+	bsonMaxKey     byte = 0x7f
+)
+
+// ---------------- decoding ----------------
+
+type bsonDecoder struct {
+	b         []byte
+	off       int
+	canonical bool
+}
+
+func (d *bsonDecoder) errorf(format string, a ...any) error {
+	return fmt.Errorf("%w: BSON at offset %d: %s", ErrRawBytesUnrecignized, d.off, fmt.Sprintf(format, a...))
+}
+
+func (d *bsonDecoder) readN(n int) ([]byte, error) {
+	if n < 0 || len(d.b)-d.off < n {
+		return nil, d.errorf("unexpected end of data")
+	}
+	b := d.b[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *bsonDecoder) readInt32() (int32, error) {
+	b, err := d.readN(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+func (d *bsonDecoder) readUint64() (uint64, error) {
+	b, err := d.readN(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (d *bsonDecoder) readCString() (string, error) {
+	i := bytes.IndexByte(d.b[d.off:], 0)
+	if i < 0 {
+		return "", d.errorf("unterminated cstring")
+	}
+	b := d.b[d.off : d.off+i]
+	if !utf8.Valid(b) {
+		return "", d.errorf("invalid UTF-8 cstring")
+	}
+	d.off += i + 1
+	return string(b), nil
+}
+
+func (d *bsonDecoder) readString() (string, error) {
+	le, err := d.readInt32()
+	if err != nil {
+		return "", err
+	}
+	if le < 1 {
+		return "", d.errorf("invalid string length %d", le)
+	}
+	b, err := d.readN(int(le))
+	if err != nil {
+		return "", err
+	}
+	if b[le-1] != 0 {
+		return "", d.errorf("string is not terminated with zero")
+	}
+	b = b[:le-1]
+	if !utf8.Valid(b) {
+		return "", d.errorf("invalid UTF-8 string")
+	}
+	return string(b), nil
+}
+
+func (d *bsonDecoder) decodeDocument(isArray bool) (*V, error) {
+	start := d.off
+	le, err := d.readInt32()
+	if err != nil {
+		return nil, err
+	}
+	if le < 5 || int(le) > len(d.b)-start {
+		return nil, d.errorf("invalid document length %d", le)
+	}
+	end := start + int(le)
+
+	var res *V
+	if isArray {
+		res = newArray()
+	} else {
+		res = newObject()
+	}
+
+	for {
+		if d.off >= end {
+			return nil, d.errorf("document is not terminated with zero")
+		}
+		typ := d.b[d.off]
+		d.off++
+		if typ == 0 {
+			break
+		}
+		k, err := d.readCString()
+		if err != nil {
+			return nil, err
+		}
+		child, err := d.decodeElement(typ)
+		if err != nil {
+			return nil, err
+		}
+		if isArray {
+			res.appendToArr(child)
+		} else {
+			res.setToObjectChildren(k, child)
+		}
+	}
+
+	if d.off != end {
+		return nil, d.errorf("document length %d mismatches actual length %d", le, d.off-start)
+	}
+	return res, nil
+}
+
+func (d *bsonDecoder) decodeElement(typ byte) (*V, error) {
+	switch typ {
+	default:
+		d.off--
+		return nil, d.errorf("unsupported element type 0x%02x", typ)
+
+	case bsonDouble:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		f := math.Float64frombits(u)
+		if d.canonical || math.IsNaN(f) || math.IsInf(f, 0) {
+			return bsonExt("$numberDouble", NewString(formatBSONDouble(f))), nil
+		}
+		return newDecodedFloat(NewFloat64(f)), nil
+
+	case bsonString:
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		return NewString(s), nil
+
+	case bsonDocument:
+		return d.decodeDocument(false)
+
+	case bsonArray:
+		return d.decodeDocument(true)
+
+	case bsonBinary:
+		return d.decodeBinary()
+
+	case bsonUndefined:
+		return bsonExt("$undefined", NewBool(true)), nil
+
+	case bsonObjectID:
+		b, err := d.readN(12)
+		if err != nil {
+			return nil, err
+		}
+		return bsonExt("$oid", NewString(hex.EncodeToString(b))), nil
+
+	case bsonBoolean:
+		b, err := d.readN(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] > 1 {
+			d.off--
+			return nil, d.errorf("invalid boolean value %d", b[0])
+		}
+		return NewBool(b[0] == 1), nil
+
+	case bsonDateTime:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return d.dateValue(int64(u)), nil
+
+	case bsonNull:
+		return NewNull(), nil
+
+	case bsonRegex:
+		pattern, err := d.readCString()
+		if err != nil {
+			return nil, err
+		}
+		options, err := d.readCString()
+		if err != nil {
+			return nil, err
+		}
+		re := NewObject()
+		re.SetString(pattern).At("pattern")
+		re.SetString(options).At("options")
+		return bsonExt("$regularExpression", re), nil
+
+	case bsonDBPointer:
+		ref, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.readN(12)
+		if err != nil {
+			return nil, err
+		}
+		ptr := NewObject()
+		ptr.SetString(ref).At("$ref")
+		ptr.Set(bsonExt("$oid", NewString(hex.EncodeToString(b)))).At("$id")
+		return bsonExt("$dbPointer", ptr), nil
+
+	case bsonJavaScript:
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		return bsonExt("$code", NewString(s)), nil
+
+	case bsonSymbol:
+		s, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		return bsonExt("$symbol", NewString(s)), nil
+
+	case bsonCodeWScope:
+		return d.decodeCodeWithScope()
+
+	case bsonInt32:
+		i, err := d.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		if d.canonical {
+			return bsonExt("$numberInt", NewString(strconv.FormatInt(int64(i), 10))), nil
+		}
+		return NewInt32(i), nil
+
+	case bsonTimestamp:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		ts := NewObject()
+		ts.SetUint32(uint32(u >> 32)).At("t")
+		ts.SetUint32(uint32(u)).At("i")
+		return bsonExt("$timestamp", ts), nil
+
+	case bsonInt64:
+		u, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		if d.canonical {
+			return bsonExt("$numberLong", NewString(strconv.FormatInt(int64(u), 10))), nil
+		}
+		return NewInt64(int64(u)), nil
+
+	case bsonDecimal128:
+		lo, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		hi, err := d.readUint64()
+		if err != nil {
+			return nil, err
+		}
+		return bsonExt("$numberDecimal", NewString(formatDecimal128(lo, hi))), nil
+
+	case bsonMinKey:
+		return bsonExt("$minKey", NewInt(1)), nil
+
+	case bsonMaxKey:
+		return bsonExt("$maxKey", NewInt(1)), nil
+	}
+}
+
+func (d *bsonDecoder) decodeBinary() (*V, error) {
+	le, err := d.readInt32()
+	if err != nil {
+		return nil, err
+	}
+	subType, err := d.readN(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.readN(int(le))
+	if err != nil {
+		return nil, err
+	}
+	if subType[0] == 0x02 {
+		// the old binary subtype contains an extra length
+		if len(data) < 4 || int(binary.LittleEndian.Uint32(data)) != len(data)-4 {
+			return nil, d.errorf("invalid length of old binary subtype")
+		}
+		data = data[4:]
+	}
+
+	bin := NewObject()
+	bin.SetString(base64.StdEncoding.EncodeToString(data)).At("base64")
+	bin.SetString(fmt.Sprintf("%02x", subType[0])).At("subType")
+	return bsonExt("$binary", bin), nil
+}
+
+func (d *bsonDecoder) decodeCodeWithScope() (*V, error) {
+	start := d.off
+	le, err := d.readInt32()
+	if err != nil {
+		return nil, err
+	}
+	code, err := d.readString()
+	if err != nil {
+		return nil, err
+	}
+	scope, err := d.decodeDocument(false)
+	if err != nil {
+		return nil, err
+	}
+	if int(le) != d.off-start {
+		return nil, d.errorf("code with scope length %d mismatches actual length %d", le, d.off-start)
+	}
+
+	res := NewObject()
+	res.SetString(code).At("$code")
+	res.Set(scope).At("$scope")
+	return res, nil
+}
+
+func (d *bsonDecoder) dateValue(ms int64) *V {
+	if !d.canonical {
+		t := time.Unix(ms/1000, ms%1000*int64(time.Millisecond)).UTC()
+		if y := t.Year(); y >= 1970 && y <= 9999 {
+			return bsonExt("$date", NewString(t.Format("2006-01-02T15:04:05.999Z07:00")))
+		}
+	}
+	return bsonExt("$date", bsonExt("$numberLong", NewString(strconv.FormatInt(ms, 10))))
+}
+
+func bsonExt(k string, v *V) *V {
+	res := NewObject()
+	res.Set(v).At(k)
+	return res
+}
+
+// formatBSONDouble formats double in the way of Extended JSON "$numberDouble", such as "1.0" and "1.0E+300".
+func formatBSONDouble(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+
+	s := strconv.FormatFloat(f, 'G', -1, 64)
+	mant, exp := s, ""
+	if i := strings.IndexByte(s, 'E'); i >= 0 {
+		mant, exp = s[:i], s[i:]
+	}
+	if !strings.Contains(mant, ".") {
+		mant += ".0"
+	}
+	return mant + exp
+}
+
+// ---------------- encoding ----------------
+
+func (v *V) marshalBSONDocument(parentInfo *ParentInfo, buf *bytes.Buffer, opt *Opt) error {
+	start := buf.Len()
+	buf.Write([]byte{0, 0, 0, 0})
+
+	switch v.valueType {
+	case Array:
+		for i, child := range v.children.arr {
+			var par *ParentInfo
+			if opt.MarshalLessFunc != nil {
+				par = v.newParentInfo(parentInfo, intKey(i))
+			}
+			if err := child.marshalBSONElement(par, strconv.Itoa(i), buf, opt); err != nil {
+				return err
+			}
+		}
+
+	default:
+		keys, values := v.sortedObjectChildren(parentInfo, opt)
+		for i, k := range keys {
+			child := values[i]
+			if opt.OmitNull && child.valueType == Null {
+				continue
+			}
+			var par *ParentInfo
+			if opt.MarshalLessFunc != nil {
+				par = child.newParentInfo(parentInfo, stringKey(k))
+			}
+			if err := child.marshalBSONElement(par, k, buf, opt); err != nil {
+				return err
+			}
+		}
+	}
+
+	buf.WriteByte(0)
+	binary.LittleEndian.PutUint32(buf.Bytes()[start:], uint32(buf.Len()-start))
+	return nil
+}
+
+func (v *V) marshalBSONElement(parentInfo *ParentInfo, k string, buf *bytes.Buffer, opt *Opt) error {
+	if strings.IndexByte(k, 0) >= 0 {
+		return fmt.Errorf("%w: key %q contains zero byte", ErrIllegalString, k)
+	}
+
+	switch v.valueType {
+	default:
+		return fmt.Errorf("%w: %v", ErrTypeNotMatch, v.valueType)
+
+	case Null:
+		writeBSONHead(buf, bsonNull, k)
+
+	case Boolean:
+		writeBSONHead(buf, bsonBoolean, k)
+		if v.valueBool {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+
+	case Number:
+		if v.isFloatNumber() {
+			writeBSONHead(buf, bsonDouble, k)
+			writeLittleEndian(buf, math.Float64bits(v.num.f64), 8)
+			break
+		}
+		if !v.num.negative && v.num.u64 > math.MaxInt64 {
+			return fmt.Errorf("%w: %v exceeds range of int64", ErrOutOfRange, v)
+		}
+		i := v.num.i64
+		if !v.num.negative {
+			i = int64(v.num.u64)
+		}
+		if i >= math.MinInt32 && i <= math.MaxInt32 {
+			writeBSONHead(buf, bsonInt32, k)
+			writeLittleEndian(buf, uint64(i), 4)
+		} else {
+			writeBSONHead(buf, bsonInt64, k)
+			writeLittleEndian(buf, uint64(i), 8)
+		}
+
+	case String:
+		writeBSONHead(buf, bsonString, k)
+		writeBSONString(buf, v.valueStr)
+
+	case Array:
+		writeBSONHead(buf, bsonArray, k)
+		return v.marshalBSONDocument(parentInfo, buf, opt)
+
+	case Object:
+		if handled, err := v.marshalBSONExtended(k, buf); handled || err != nil {
+			return err
+		}
+		writeBSONHead(buf, bsonDocument, k)
+		return v.marshalBSONDocument(parentInfo, buf, opt)
+	}
+	return nil
+}
+
+func writeBSONHead(buf *bytes.Buffer, typ byte, k string) {
+	buf.WriteByte(typ)
+	buf.WriteString(k)
+	buf.WriteByte(0)
+}
+
+func writeBSONString(buf *bytes.Buffer, s string) {
+	writeLittleEndian(buf, uint64(len(s)+1), 4)
+	buf.WriteString(s)
+	buf.WriteByte(0)
+}
+
+func writeBSONCString(buf *bytes.Buffer, s string) error {
+	if strings.IndexByte(s, 0) >= 0 {
+		return fmt.Errorf("%w: %q contains zero byte", ErrIllegalString, s)
+	}
+	buf.WriteString(s)
+	buf.WriteByte(0)
+	return nil
+}
+
+func writeLittleEndian(buf *bytes.Buffer, u uint64, size int) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], u)
+	buf.Write(b[:size])
+}
+
+// bsonExtError returns error of invalid Extended JSON value.
+func bsonExtError(key string, v *V) error {
+	return fmt.Errorf("%w: invalid %s value %s", ErrTypeNotMatch, key, v.MustMarshalString())
+}
+
+// marshalBSONExtended writes an Extended JSON object as corresponding BSON type. If v is not in Extended JSON
+// format, handled would be false.
+func (v *V) marshalBSONExtended(k string, buf *bytes.Buffer) (handled bool, err error) {
+	var keys []string
+	v.RangeObjectsBySetSequence(func(key string, _ *V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) == 0 || len(keys) > 2 || !strings.HasPrefix(keys[0], "$") {
+		return false, nil
+	}
+
+	if len(keys) == 2 {
+		code, _ := v.Get("$code")
+		scope, _ := v.Get("$scope")
+		if !code.IsString() || !scope.IsObject() {
+			return false, nil
+		}
+		writeBSONHead(buf, bsonCodeWScope, k)
+		start := buf.Len()
+		buf.Write([]byte{0, 0, 0, 0})
+		writeBSONString(buf, code.String())
+		if err := scope.marshalBSONDocument(nil, buf, &Opt{marshalBySetSequence: true}); err != nil {
+			return true, err
+		}
+		binary.LittleEndian.PutUint32(buf.Bytes()[start:], uint32(buf.Len()-start))
+		return true, nil
+	}
+
+	key := keys[0]
+	val := v.children.object[key].v
+
+	switch key {
+	default:
+		return false, nil
+
+	case "$oid":
+		b, err := parseObjectID(val)
+		if err != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonObjectID, k)
+		buf.Write(b)
+
+	case "$symbol", "$code":
+		if !val.IsString() {
+			return true, bsonExtError(key, val)
+		}
+		if key == "$symbol" {
+			writeBSONHead(buf, bsonSymbol, k)
+		} else {
+			writeBSONHead(buf, bsonJavaScript, k)
+		}
+		writeBSONString(buf, val.String())
+
+	case "$numberInt":
+		i, err := strconv.ParseInt(val.String(), 10, 32)
+		if !val.IsString() || err != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonInt32, k)
+		writeLittleEndian(buf, uint64(i), 4)
+
+	case "$numberLong":
+		i, err := strconv.ParseInt(val.String(), 10, 64)
+		if !val.IsString() || err != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonInt64, k)
+		writeLittleEndian(buf, uint64(i), 8)
+
+	case "$numberDouble":
+		f, err := parseBSONDouble(val)
+		if err != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonDouble, k)
+		writeLittleEndian(buf, math.Float64bits(f), 8)
+
+	case "$numberDecimal":
+		lo, hi, err := parseDecimal128(val.String())
+		if !val.IsString() || err != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonDecimal128, k)
+		writeLittleEndian(buf, lo, 8)
+		writeLittleEndian(buf, hi, 8)
+
+	case "$binary":
+		b64, _ := val.GetString("base64")
+		sub, _ := val.GetString("subType")
+		data, err1 := base64.StdEncoding.DecodeString(b64)
+		subType, err2 := strconv.ParseUint(sub, 16, 8)
+		if val.Len() != 2 || err1 != nil || err2 != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonBinary, k)
+		if subType == 0x02 {
+			writeLittleEndian(buf, uint64(len(data)+4), 4)
+			buf.WriteByte(byte(subType))
+			writeLittleEndian(buf, uint64(len(data)), 4)
+		} else {
+			writeLittleEndian(buf, uint64(len(data)), 4)
+			buf.WriteByte(byte(subType))
+		}
+		buf.Write(data)
+
+	case "$timestamp":
+		t, err1 := val.Get("t")
+		i, err2 := val.Get("i")
+		if val.Len() != 2 || err1 != nil || err2 != nil || !isUint32Value(t) || !isUint32Value(i) {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonTimestamp, k)
+		writeLittleEndian(buf, t.Uint64()<<32|i.Uint64(), 8)
+
+	case "$regularExpression":
+		pattern, err1 := val.Get("pattern")
+		options, err2 := val.Get("options")
+		if val.Len() != 2 || err1 != nil || err2 != nil || !pattern.IsString() || !options.IsString() {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonRegex, k)
+		if err := writeBSONCString(buf, pattern.String()); err != nil {
+			return true, err
+		}
+		if err := writeBSONCString(buf, options.String()); err != nil {
+			return true, err
+		}
+
+	case "$dbPointer":
+		ref, err1 := val.Get("$ref")
+		id, err2 := val.Get("$id", "$oid")
+		if err1 != nil || err2 != nil || !ref.IsString() || val.Len() != 2 {
+			return true, bsonExtError(key, val)
+		}
+		b, err := parseObjectID(id)
+		if err != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonDBPointer, k)
+		writeBSONString(buf, ref.String())
+		buf.Write(b)
+
+	case "$date":
+		ms, err := parseBSONDate(val)
+		if err != nil {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonDateTime, k)
+		writeLittleEndian(buf, uint64(ms), 8)
+
+	case "$minKey", "$maxKey":
+		if !val.IsNumber() || val.Int() != 1 {
+			return true, bsonExtError(key, val)
+		}
+		if key == "$minKey" {
+			writeBSONHead(buf, bsonMinKey, k)
+		} else {
+			writeBSONHead(buf, bsonMaxKey, k)
+		}
+
+	case "$undefined":
+		if !val.IsBoolean() || !val.Bool() {
+			return true, bsonExtError(key, val)
+		}
+		writeBSONHead(buf, bsonUndefined, k)
+	}
+
+	return true, nil
+}
+
+func isUint32Value(v *V) bool {
+	return v.valueType == Number && !v.num.floated && !v.num.negative && v.num.u64 <= math.MaxUint32
+}
+
+func parseObjectID(v *V) ([]byte, error) {
+	if !v.IsString() || len(v.String()) != 24 {
+		return nil, ErrTypeNotMatch
+	}
+	return hex.DecodeString(v.String())
+}
+
+func parseBSONDouble(v *V) (float64, error) {
+	if !v.IsString() {
+		return 0, ErrTypeNotMatch
+	}
+	switch s := v.String(); s {
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	default:
+		if strings.ContainsAny(s, "iInN") {
+			// reject forms like "Inf" which are accepted by strconv.ParseFloat
+			return 0, ErrTypeNotMatch
+		}
+		return strconv.ParseFloat(s, 64)
+	}
+}
+
+// parseBSONDate parses canonical format {"$numberLong": "..."} or relaxed ISO-8601 format of "$date" into
+// milliseconds since epoch.
+func parseBSONDate(v *V) (int64, error) {
+	switch v.valueType {
+	case String:
+		t, err := time.Parse(time.RFC3339Nano, v.valueStr)
+		if err != nil {
+			return 0, err
+		}
+		return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond), nil
+	case Object:
+		s, err := v.GetString("$numberLong")
+		if err != nil || v.Len() != 1 {
+			return 0, ErrTypeNotMatch
+		}
+		return strconv.ParseInt(s, 10, 64)
+	case Number:
+		// legacy format
+		if !v.IsInteger() {
+			return 0, ErrTypeNotMatch
+		}
+		return v.Int64(), nil
+	default:
+		return 0, ErrTypeNotMatch
+	}
+}
+
+// ---------------- Decimal128 ----------------
+
+const (
+	decimal128ExponentBias = 6176
+	decimal128MaxExponent  = 6111
+	decimal128MinExponent  = -6176
+	decimal128MaxDigits    = 34
+)
+
+var decimal128MaxCoefficient, _ = (&big.Int{}).SetString(strings.Repeat("9", decimal128MaxDigits), 10)
+
+// formatDecimal128 formats IEEE 754-2008 128-bit decimal in BID encoding, following the BSON Decimal128
+// specification.
+func formatDecimal128(lo, hi uint64) string {
+	neg := hi>>63 == 1
+	exp := 0
+	coef := &big.Int{}
+
+	switch {
+	case (hi>>58)&0x1f == 0x1f:
+		return "NaN"
+	case (hi>>58)&0x1f == 0x1e:
+		if neg {
+			return "-Infinity"
+		}
+		return "Infinity"
+	case (hi>>61)&0x3 == 0x3:
+		// coefficient in this form always exceeds the maximum, which is treated as zero
+		exp = int((hi>>47)&0x3fff) - decimal128ExponentBias
+	default:
+		exp = int((hi>>49)&0x3fff) - decimal128ExponentBias
+		coef.SetUint64(hi & (1<<49 - 1))
+		coef.Lsh(coef, 64).Or(coef, (&big.Int{}).SetUint64(lo))
+		if coef.Cmp(decimal128MaxCoefficient) > 0 {
+			coef.SetUint64(0)
+		}
+	}
+
+	digits := coef.String()
+	adjusted := exp + len(digits) - 1
+
+	s := ""
+	switch {
+	case exp == 0:
+		s = digits
+	case exp < 0 && adjusted >= -6:
+		n := -exp
+		if len(digits) > n {
+			s = digits[:len(digits)-n] + "." + digits[len(digits)-n:]
+		} else {
+			s = "0." + strings.Repeat("0", n-len(digits)) + digits
+		}
+	default:
+		s = digits[:1]
+		if len(digits) > 1 {
+			s += "." + digits[1:]
+		}
+		s += "E"
+		if adjusted >= 0 {
+			s += "+"
+		}
+		s += strconv.Itoa(adjusted)
+	}
+
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+// parseDecimal128 parses a decimal string into IEEE 754-2008 128-bit decimal in BID encoding. Values which could
+// not be represented exactly are rejected.
+func parseDecimal128(s string) (lo, hi uint64, err error) {
+	var sign uint64
+	str := s
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		if str[0] == '-' {
+			sign = 1 << 63
+		}
+		str = str[1:]
+	}
+
+	switch strings.ToLower(str) {
+	case "inf", "infinity":
+		return 0, sign | 0x78<<56, nil
+	case "nan":
+		return 0, 0x7c << 56, nil
+	}
+
+	invalid := fmt.Errorf("%w: invalid decimal %q", ErrNotValidNumberValue, s)
+
+	mant, expPart := str, ""
+	if i := strings.IndexAny(str, "eE"); i >= 0 {
+		mant, expPart = str[:i], str[i+1:]
+		if expPart == "" {
+			return 0, 0, invalid
+		}
+	}
+	intPart, fracPart := mant, ""
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		intPart, fracPart = mant[:i], mant[i+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return 0, 0, invalid
+	}
+
+	exp := 0
+	if expPart != "" {
+		if strings.Trim(strings.TrimLeft(expPart, "+-"), "0123456789") != "" || len(expPart) > 8 {
+			return 0, 0, invalid
+		}
+		if exp, err = strconv.Atoi(expPart); err != nil {
+			return 0, 0, invalid
+		}
+	}
+	exp -= len(fracPart)
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		// zero, clamp the exponent
+		if exp > decimal128MaxExponent {
+			exp = decimal128MaxExponent
+		} else if exp < decimal128MinExponent {
+			exp = decimal128MinExponent
+		}
+		return 0, sign | uint64(exp+decimal128ExponentBias)<<49, nil
+	}
+
+	for len(digits) > decimal128MaxDigits && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+		exp++
+	}
+	for exp < decimal128MinExponent && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+		exp++
+	}
+	for exp > decimal128MaxExponent && len(digits) < decimal128MaxDigits {
+		digits += "0"
+		exp--
+	}
+	if len(digits) > decimal128MaxDigits || exp > decimal128MaxExponent || exp < decimal128MinExponent {
+		return 0, 0, fmt.Errorf("%w: decimal %q could not be represented exactly", ErrOutOfRange, s)
+	}
+
+	coef, _ := (&big.Int{}).SetString(digits, 10)
+	lo = (&big.Int{}).And(coef, (&big.Int{}).SetUint64(math.MaxUint64)).Uint64()
+	hi = (&big.Int{}).Rsh(coef, 64).Uint64()
+	hi |= sign | uint64(exp+decimal128ExponentBias)<<49
+	return lo, hi, nil
+}
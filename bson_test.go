@@ -0,0 +1,234 @@
+package jsonvalue
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testBSON(t *testing.T) {
+	cv("basic documents", func() { testBSONBasic(t) })
+	cv("extended JSON", func() { testBSONExtended(t) })
+	cv("canonical mode", func() { testBSONCanonical(t) })
+	cv("decimal128", func() { testBSONDecimal128(t) })
+	cv("errors", func() { testBSONErrors(t) })
+}
+
+func testBSONBasic(t *testing.T) {
+	v := MustUnmarshalString(`{"hello":"world"}`)
+	b, err := v.MarshalBSON()
+	so(err, isNil)
+	so(string(b), eq, "\x16\x00\x00\x00\x02hello\x00\x06\x00\x00\x00world\x00\x00")
+
+	// field order follows set sequence by default
+	v = NewObject()
+	v.SetInt(1).At("z")
+	v.SetInt64(1 << 40).At("y")
+	v.SetFloat64(1.5).At("x")
+	v.SetBool(true).At("w")
+	v.SetNull().At("v")
+	v.Set(MustUnmarshalString(`[1,"a",{"b":[]}]`)).At("u")
+	v.SetInt(-5).At("t")
+
+	b, err = v.MarshalBSON()
+	so(err, isNil)
+	got, err := UnmarshalBSON(b)
+	so(err, isNil)
+	so(got.MustMarshalString(OptSetSequence()), eq, `{"z":1,"y":1099511627776,"x":1.5,"w":true,"v":null,"u":[1,"a",{"b":[]}],"t":-5}`)
+
+	// int32 and int64
+	canonical, err := UnmarshalBSON(b, OptBSONCanonical())
+	so(err, isNil)
+	so(canonical.MustGet("z", "$numberInt").String(), eq, "1")
+	so(canonical.MustGet("y", "$numberLong").String(), eq, "1099511627776")
+	so(canonical.MustGet("x", "$numberDouble").String(), eq, "1.5")
+	so(canonical.MustGet("t", "$numberInt").String(), eq, "-5")
+
+	// key sequence options
+	b, err = v.MarshalBSON(OptKeySequence([]string{"t", "u"}), OptOmitNull(true))
+	so(err, isNil)
+	got, err = UnmarshalBSON(b)
+	so(err, isNil)
+	so(got.MustMarshalString(OptSetSequence()), eq, `{"t":-5,"u":[1,"a",{"b":[]}],"w":true,"x":1.5,"y":1099511627776,"z":1}`)
+
+	// modifying with Get/Set keeps order
+	got.MustGet("u").MustGet(2).SetString("c").At("b")
+	got.SetString("new").At("a")
+	b, err = got.MarshalBSON()
+	so(err, isNil)
+	got, err = UnmarshalBSON(b)
+	so(err, isNil)
+	so(got.MustMarshalString(OptSetSequence()), eq, `{"t":-5,"u":[1,"a",{"b":"c"}],"w":true,"x":1.5,"y":1099511627776,"z":1,"a":"new"}`)
+}
+
+func testBSONExtended(t *testing.T) {
+	src := `{` +
+		`"_id":{"$oid":"57e193d7a9cc81b4027498b5"},` +
+		`"date":{"$date":"2012-12-24T12:15:30.501Z"},` +
+		`"old":{"$date":{"$numberLong":"-62135596800000"}},` +
+		`"dec":{"$numberDecimal":"1.2345678E+100"},` +
+		`"bin":{"$binary":{"base64":"AQIDBA==","subType":"04"}},` +
+		`"old_bin":{"$binary":{"base64":"AQIDBA==","subType":"02"}},` +
+		`"long":{"$numberLong":"7"},` +
+		`"int":{"$numberInt":"-7"},` +
+		`"inf":{"$numberDouble":"-Infinity"},` +
+		`"ts":{"$timestamp":{"t":123456789,"i":42}},` +
+		`"re":{"$regularExpression":{"pattern":"^a.*","options":"i"}},` +
+		`"ptr":{"$dbPointer":{"$ref":"coll","$id":{"$oid":"57e193d7a9cc81b4027498b5"}}},` +
+		`"code":{"$code":"function(){}"},` +
+		`"scope":{"$code":"x","$scope":{"x":1}},` +
+		`"sym":{"$symbol":"s"},` +
+		`"min":{"$minKey":1},` +
+		`"max":{"$maxKey":1},` +
+		`"undef":{"$undefined":true},` +
+		`"dbref":{"$ref":"coll","$id":1}` +
+		`}`
+	v := MustUnmarshalString(src)
+	b, err := v.MarshalBSON()
+	so(err, isNil)
+
+	got, err := UnmarshalBSON(b)
+	so(err, isNil)
+	expected := strings.Replace(src, `{"$numberLong":"7"}`, `7`, 1)
+	expected = strings.Replace(expected, `{"$numberInt":"-7"}`, `-7`, 1)
+	so(got.MustMarshalString(OptSetSequence(), OptEscapeSlash(false)), eq, expected)
+
+	canonical, err := UnmarshalBSON(b, OptBSONCanonical())
+	so(err, isNil)
+	so(canonical.MustGet("date", "$date", "$numberLong").String(), eq, "1356351330501")
+	so(canonical.MustGet("long", "$numberLong").String(), eq, "7")
+
+	// the old binary subtype contains an extra length
+	so(hex.EncodeToString(b), hasSubStr, hex.EncodeToString([]byte("old_bin\x00\x08\x00\x00\x00\x02\x04\x00\x00\x00\x01\x02\x03\x04")))
+
+	// invalid extended JSON values
+	for _, s := range []string{
+		`{"a":{"$oid":"1234"}}`,
+		`{"a":{"$numberInt":"2147483648"}}`,
+		`{"a":{"$numberLong":1}}`,
+		`{"a":{"$numberDouble":"Inf"}}`,
+		`{"a":{"$numberDecimal":"1.2.3"}}`,
+		`{"a":{"$binary":{"base64":"!!","subType":"00"}}}`,
+		`{"a":{"$timestamp":{"t":-1,"i":0}}}`,
+		`{"a":{"$date":"yesterday"}}`,
+		`{"a":{"$minKey":2}}`,
+		`{"a":{"$regularExpression":{"pattern":"a\u0000","options":""}}}`,
+	} {
+		_, err := MustUnmarshalString(s).MarshalBSON()
+		so(err, isErr)
+	}
+
+	// objects with unknown "$" keys are plain documents
+	b, err = MustUnmarshalString(`{"a":{"$set":{"b":1}}}`).MarshalBSON()
+	so(err, isNil)
+	got, err = UnmarshalBSON(b)
+	so(err, isNil)
+	so(got.MustMarshalString(), eq, `{"a":{"$set":{"b":1}}}`)
+}
+
+func testBSONCanonical(t *testing.T) {
+	v := NewObject()
+	v.Set(MustUnmarshalString(`1.0`)).At("a")
+	v.SetFloat64(-0.25).At("b")
+	v.SetFloat64(1e300).At("c")
+	v.Set(MustUnmarshalString(`{"$date":"1960-01-01T00:00:00Z"}`)).At("d")
+	b, err := v.MarshalBSON()
+	so(err, isNil)
+
+	got, err := UnmarshalBSON(b, OptBSONCanonical())
+	so(err, isNil)
+	so(got.MustMarshalString(OptSetSequence()), eq,
+		`{"a":{"$numberDouble":"1.0"},"b":{"$numberDouble":"-0.25"},"c":{"$numberDouble":"1.0E+300"},`+
+			`"d":{"$date":{"$numberLong":"-315619200000"}}}`)
+
+	// dates out of range of relaxed format
+	got, err = UnmarshalBSON(b)
+	so(err, isNil)
+	so(got.MustGet("d").MustMarshalString(), eq, `{"$date":{"$numberLong":"-315619200000"}}`)
+
+	// round trip of canonical values
+	b2, err := got.MarshalBSON()
+	so(err, isNil)
+	so(hex.EncodeToString(b2), eq, hex.EncodeToString(b))
+}
+
+func testBSONDecimal128(t *testing.T) {
+	// from BSON corpus
+	b, _ := hex.DecodeString("1800000013640001000000000000000000000000003E3000")
+	v, err := UnmarshalBSON(b)
+	so(err, isNil)
+	so(v.MustGet("d", "$numberDecimal").String(), eq, "0.1")
+
+	cases := map[string]string{
+		"0":                                     "0",
+		"-0":                                    "-0",
+		"0E+3":                                  "0E+3",
+		"1E+3":                                  "1E+3",
+		"1000":                                  "1000",
+		"0.001234":                              "0.001234",
+		"0.0000001234":                          "1.234E-7",
+		"-12.50":                                "-12.50",
+		"1.23e-7":                               "1.23E-7",
+		"Infinity":                              "Infinity",
+		"-inf":                                  "-Infinity",
+		"NaN":                                   "NaN",
+		"1E+6144":                               "1.000000000000000000000000000000000E+6144",
+		"9999999999999999999999999999999999":    "9999999999999999999999999999999999",
+		"1234567890123456789012345678901234000": "1.234567890123456789012345678901234E+36",
+	}
+	for in, out := range cases {
+		lo, hi, err := parseDecimal128(in)
+		so(err, isNil)
+		so(formatDecimal128(lo, hi), eq, out)
+	}
+
+	for _, in := range []string{"", "-", "1.", "E3", "1E", "12345678901234567890123456789012345", "1E-6177", "1E+6145", "abc"} {
+		_, _, err := parseDecimal128(in)
+		if in == "1." {
+			so(err, isNil)
+			continue
+		}
+		so(err, isErr)
+	}
+}
+
+func testBSONErrors(t *testing.T) {
+	_, err := (&V{}).MarshalBSON()
+	so(errors.Is(err, ErrValueUninitialized), isTrue)
+	_, err = NewArray().MarshalBSON()
+	so(errors.Is(err, ErrNotObjectValue), isTrue)
+	_, err = NewUint64(1 << 63).MarshalBSON()
+	so(errors.Is(err, ErrNotObjectValue), isTrue)
+
+	o := NewObject()
+	o.SetUint64(1 << 63).At("a")
+	_, err = o.MarshalBSON()
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+
+	o = NewObject()
+	o.SetInt(1).At("a\x00b")
+	_, err = o.MarshalBSON()
+	so(errors.Is(err, ErrIllegalString), isTrue)
+
+	for _, s := range []string{
+		"",
+		"05000000",                         // truncated
+		"0400000000",                       // invalid length
+		"0600000000",                       // length exceeds data
+		"050000000000",                     // extra bytes
+		"0500000001",                       // not terminated
+		"0c0000000861000200000000",         // invalid boolean
+		"0c0000002061000100000000",         // unsupported type
+		"0d00000002610005000000616200",     // string exceeds data
+		"0f0000000261000200000061ff0000",   // string not terminated with zero
+		"0f00000002610002000000c32800",     // invalid UTF-8
+		"0d000000056100ffffff7f0000000000", // binary length exceeds data
+	} {
+		b, _ := hex.DecodeString(s)
+		v, err := UnmarshalBSON(b)
+		so(err, isErr)
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+		so(v.ValueType(), eq, NotExist)
+	}
+}
@@ -0,0 +1,183 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// reference:
+// - [RFC 8785 JSON Canonicalization Scheme (JCS)](https://www.rfc-editor.org/rfc/rfc8785)
+// - [ECMA-262 Number::toString](https://tc39.es/ecma262/#sec-numeric-types-number-tostring)
+
+func (v *V) marshalCanonicalToBuffer(buf *bytes.Buffer) error {
+	switch v.valueType {
+	default:
+		return nil
+	case String:
+		marshalCanonicalString(v.valueStr, buf)
+		return nil
+	case Boolean:
+		v.marshalBoolean(buf)
+		return nil
+	case Number:
+		return v.marshalCanonicalNumber(buf)
+	case Null:
+		v.marshalNull(buf)
+		return nil
+	case Object:
+		return v.marshalCanonicalObject(buf)
+	case Array:
+		return v.marshalCanonicalArray(buf)
+	}
+}
+
+func (v *V) marshalCanonicalObject(buf *bytes.Buffer) error {
+	type keyAndUTF16 struct {
+		k string
+		u []uint16
+	}
+
+	keys := make([]keyAndUTF16, 0, len(v.children.object))
+	for k := range v.children.object {
+		keys = append(keys, keyAndUTF16{
+			k: k,
+			u: utf16.Encode([]rune(k)),
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessUTF16(keys[i].u, keys[j].u)
+	})
+
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		marshalCanonicalString(k.k, buf)
+		buf.WriteByte(':')
+		if err := v.children.object[k.k].v.marshalCanonicalToBuffer(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func lessUTF16(u1, u2 []uint16) bool {
+	for i := 0; i < len(u1) && i < len(u2); i++ {
+		if u1[i] != u2[i] {
+			return u1[i] < u2[i]
+		}
+	}
+	return len(u1) < len(u2)
+}
+
+func (v *V) marshalCanonicalArray(buf *bytes.Buffer) error {
+	buf.WriteByte('[')
+	for i, child := range v.children.arr {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := child.marshalCanonicalToBuffer(buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// marshalCanonicalString escapes string as ECMAScript JSON.stringify() does,
+// which is required by RFC 8785 section 3.2.2.2.
+func marshalCanonicalString(s string, buf *bytes.Buffer) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(fmt.Sprintf("\\u%04x", r))
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+func (v *V) marshalCanonicalNumber(buf *bytes.Buffer) error {
+	f := v.num.f64
+	if len(v.srcByte) > 0 {
+		parsed, err := strconv.ParseFloat(unsafeBtoS(v.srcByte), 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrUnsupportedFloat, v.srcByte)
+		}
+		f = parsed
+	}
+	if !isValidFloat(f) {
+		return fmt.Errorf("%w: %v", ErrUnsupportedFloat, f)
+	}
+	buf.WriteString(formatFloatES6(f))
+	return nil
+}
+
+// formatFloatES6 formats a float64 value as ECMAScript Number.prototype.toString() does.
+func formatFloatES6(f float64) string {
+	if f == 0 {
+		return "0" // including -0
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = math.Abs(f)
+	}
+
+	// shortest round-trip representation like "d.ddddde±xx"
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, expStr := s, "0"
+	if idx := strings.IndexByte(s, 'e'); idx >= 0 {
+		mantissa, expStr = s[:idx], s[idx+1:]
+	}
+	digits := strings.Replace(mantissa, ".", "", 1)
+	exp, _ := strconv.Atoi(expStr)
+
+	k := len(digits)
+	n := exp + 1
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	expSign := "+"
+	if n-1 < 0 {
+		expSign = "-"
+	}
+	expAbs := strconv.Itoa(int(math.Abs(float64(n - 1))))
+	if k == 1 {
+		return sign + digits + "e" + expSign + expAbs
+	}
+	return sign + digits[:1] + "." + digits[1:] + "e" + expSign + expAbs
+}
@@ -0,0 +1,95 @@
+package jsonvalue
+
+import (
+	"math"
+	"testing"
+)
+
+func testCanonical(t *testing.T) {
+	cv("RFC 8785 sample", func() { testCanonicalSample(t) })
+	cv("key sorting by UTF-16", func() { testCanonicalKeySorting(t) })
+	cv("number formatting", func() { testCanonicalNumbers(t) })
+	cv("errors", func() { testCanonicalErrors(t) })
+}
+
+func testCanonicalSample(t *testing.T) {
+	raw := `{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+		"literals": [null, true, false]
+	}`
+	expected := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],` +
+		`"string":"€$\u000f\nA'B\"\\\\\"/"}`
+
+	v, err := UnmarshalString(raw)
+	so(err, isNil)
+
+	s, err := v.MarshalString(OptCanonical())
+	so(err, isNil)
+	so(s, eq, expected)
+
+	// other options should be ignored
+	s, err = v.MarshalString(OptCanonical(), OptIndent("", "  "), OptEscapeSlash(true))
+	so(err, isNil)
+	so(s, eq, expected)
+
+	s, err = v.MarshalString(OptSetSequence(), OptCanonical())
+	so(err, isNil)
+	so(s, eq, expected)
+}
+
+func testCanonicalKeySorting(t *testing.T) {
+	raw := `{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh",` +
+		`"1":"One","😀":"Emoji: Grinning Face","\u0080":"Control","ö":"Latin Small Letter O With Diaeresis"}`
+	expected := `{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis",` +
+		`"€":"Euro Sign","😀":"Emoji: Grinning Face","` + "\ufb33" + `":"Hebrew Letter Dalet With Dagesh"}`
+
+	v, err := UnmarshalString(raw)
+	so(err, isNil)
+
+	s, err := v.MarshalString(OptCanonical())
+	so(err, isNil)
+	so(s, eq, expected)
+}
+
+func testCanonicalNumbers(t *testing.T) {
+	cases := map[float64]string{
+		0:                       "0",
+		math.Copysign(0, -1):    "0",
+		1:                       "1",
+		-1.5:                    "-1.5",
+		1e21:                    "1e+21",
+		1e20:                    "100000000000000000000",
+		999999999999999868928:   "999999999999999900000",
+		295147905179352830000:   "295147905179352830000",
+		9007199254740992:        "9007199254740992",
+		0.000001:                "0.000001",
+		1e-7:                    "1e-7",
+		5e-324:                  "5e-324",
+		-1.7976931348623157e308: "-1.7976931348623157e+308",
+		123e-20:                 "1.23e-18",
+	}
+
+	for f, expected := range cases {
+		s, err := NewFloat64(f).MarshalString(OptCanonical())
+		so(err, isNil)
+		so(s, eq, expected)
+	}
+
+	v := MustUnmarshalString(`[10.000, -0.0, 1E2, 12345678901234567890]`)
+	s, err := v.MarshalString(OptCanonical())
+	so(err, isNil)
+	so(s, eq, `[10,0,100,12345678901234567000]`)
+}
+
+func testCanonicalErrors(t *testing.T) {
+	v := NewObject()
+	v.SetFloat64(math.NaN()).At("nan")
+	_, err := v.Marshal(OptCanonical(), OptFloatNaNToNull())
+	so(err, isErr)
+
+	v = NewArray()
+	v.AppendFloat64(math.Inf(1)).InTheEnd()
+	_, err = v.Marshal(OptCanonical())
+	so(err, isErr)
+}
@@ -0,0 +1,659 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"unicode/utf8"
+)
+
+// CBORConversion defines how CBOR data items without JSON counterparts, which are tags, byte strings and the
+// undefined value, are converted in UnmarshalCBOR().
+//
+// CBORConversion 定义在 UnmarshalCBOR() 中，如何转换那些在 JSON 中没有对应类型的 CBOR 数据项，也就是 tag、字节串以及 undefined 值。
+type CBORConversion uint8
+
+const (
+	// CBORConvertDefault is the default conversion. Tag numbers are dropped and the tagged contents are decoded,
+	// except that bignums (tag 2 and 3) are decoded into numbers. Byte strings are decoded into base64 strings, just
+	// like NewBytes() does. Undefined and other unassigned simple values are decoded into null.
+	//
+	// CBORConvertDefault 表示默认转换方式。tag 号将被丢弃并解析被标记的内容，但 bignum (tag 2 和 3) 会被解析为数字。字节串被解析为
+	// base64 字符串，与 NewBytes() 一致。undefined 以及其他未分配的简单值被解析为 null。
+	CBORConvertDefault CBORConversion = 0
+	// CBORConvertToError indicates that an error will be returned.
+	//
+	// CBORConvertToError 表示返回错误。
+	CBORConvertToError CBORConversion = 1
+	// CBORConvertToNull indicates that the data item will be decoded into null.
+	//
+	// CBORConvertToNull 表示将数据项解析为 null。
+	CBORConvertToNull CBORConversion = 2
+	// CBORConvertToBase64 indicates that the data item will be decoded into a base64 string. For byte strings, the
+	// content is encoded. For tags and simple values, the whole raw data item including its header is encoded, so that
+	// it could be kept as it is.
+	//
+	// CBORConvertToBase64 表示将数据项解析为 base64 字符串。对于字节串，编码的是其内容；对于 tag 和简单值，编码的是包含头部在内的
+	// 整个原始数据项，以便原样保留。
+	CBORConvertToBase64 CBORConversion = 3
+)
+
+// MarshalCBOR encodes *V into CBOR (RFC 8949) format. Integers are encoded in the shortest form, while floats are
+// encoded in the shortest of half, single and double precision form which preserves the value. Lengths are always
+// definite. Key sequence options and OptOmitNull() are respected.
+//
+// Integers out of ranges of int64 and uint64, such as those decoded from CBOR bignums, are encoded as bignums.
+//
+// With OptCBORDeterministic(), core deterministic encoding requirements (RFC 8949 section 4.2.1) are applied, which
+// is useful for signing. Map keys are sorted in bytewise lexicographic order of their encodings, and key sequence
+// options are ignored.
+//
+// MarshalCBOR 将 *V 编码为 CBOR (RFC 8949) 格式。整数以最短的形式编码，浮点数则以半精度、单精度和双精度中能够保留原值的最短形式
+// 编码。长度总是确定的。支持键顺序选项以及 OptOmitNull()。
+//
+// 超出 int64 和 uint64 范围的整数 (如从 CBOR bignum 解析而来的数字) 以 bignum 编码。
+//
+// 使用 OptCBORDeterministic() 选项时，将按照核心确定性编码要求 (RFC 8949 第 4.2.1 节) 进行编码，适用于签名的场景。map 的键按照其编码后
+// 的字节序进行排序，键顺序选项将被忽略。
+func (v *V) MarshalCBOR(opts ...Option) ([]byte, error) {
+	if v == nil || v.valueType == NotExist {
+		return nil, ErrValueUninitialized
+	}
+
+	buf := bytes.Buffer{}
+	opt := combineOptions(opts)
+	if err := v.marshalCBORToBuffer(nil, &buf, opt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCBOR decodes CBOR data into *V. Both definite and indefinite lengths are supported. Text and integer map
+// keys are accepted, while integer keys are converted into decimal strings. Key sequence is kept as set sequence.
+// Integers out of ranges of int64 and uint64, including bignums, are kept exactly in decimal texts, and an error
+// wrapping ErrOutOfRange is returned if they exceed float64. Conversions of tags, byte strings and the undefined value could be configured by OptCBORTag(),
+// OptCBORByteString() and OptCBORUndefined().
+//
+// UnmarshalCBOR 将 CBOR 数据解码为 *V。支持确定长度和不定长度。map 的键可以是文本或整数，整数键会被转为十进制字符串。键的顺序被
+// 保存为 set 顺序。超出 int64 和 uint64 范围的整数 (包括 bignum) 以十进制文本精确保存，如果超出 float64 的范围则返回包含
+// ErrOutOfRange 的错误。tag、字节串和 undefined 值的转换方式可以通过 OptCBORTag()、OptCBORByteString() 和 OptCBORUndefined()
+// 配置。
+func UnmarshalCBOR(b []byte, opts ...Option) (*V, error) {
+	d := cborDecoder{b: b, opt: combineOptions(opts)}
+	v, err := d.decode()
+	if err != nil {
+		return &V{}, err
+	}
+	if d.off != len(b) {
+		return &V{}, fmt.Errorf("%w: %d extra bytes after CBOR data item", ErrRawBytesUnrecignized, len(b)-d.off)
+	}
+	return v, nil
+}
+
+// CBOR major types
+const (
+	cborUint   byte = 0 << 5
+	cborNegInt byte = 1 << 5
+	cborBytes  byte = 2 << 5
+	cborText   byte = 3 << 5
+	cborArray  byte = 4 << 5
+	cborMap    byte = 5 << 5
+	cborTag    byte = 6 << 5
+	cborSimple byte = 7 << 5
+)
+
+// ---------------- encoding ----------------
+
+func (v *V) marshalCBORToBuffer(parentInfo *ParentInfo, buf *bytes.Buffer, opt *Opt) error {
+	switch v.valueType {
+	default:
+		return fmt.Errorf("%w: %v", ErrTypeNotMatch, v.valueType)
+
+	case Null:
+		buf.WriteByte(cborSimple | 22)
+
+	case Boolean:
+		if v.valueBool {
+			buf.WriteByte(cborSimple | 21)
+		} else {
+			buf.WriteByte(cborSimple | 20)
+		}
+
+	case Number:
+		switch {
+		case v.isCBORBigInt():
+			writeCBORBigInt(buf, v.srcByte)
+		case v.isFloatNumber():
+			writeCBORFloat(buf, v.num.f64)
+		case v.num.negative:
+			writeCBORHead(buf, cborNegInt, uint64(-1-v.num.i64))
+		default:
+			writeCBORHead(buf, cborUint, v.num.u64)
+		}
+
+	case String:
+		writeCBORHead(buf, cborText, uint64(len(v.valueStr)))
+		buf.WriteString(v.valueStr)
+
+	case Array:
+		writeCBORHead(buf, cborArray, uint64(len(v.children.arr)))
+		for i, child := range v.children.arr {
+			var par *ParentInfo
+			if opt.MarshalLessFunc != nil {
+				par = v.newParentInfo(parentInfo, intKey(i))
+			}
+			if err := child.marshalCBORToBuffer(par, buf, opt); err != nil {
+				return err
+			}
+		}
+
+	case Object:
+		var keys []string
+		var values []*V
+		if opt.cborDeterministic {
+			keys, values = v.cborDeterministicChildren()
+		} else {
+			keys, values = v.sortedObjectChildren(parentInfo, opt)
+		}
+		cnt := len(keys)
+		if opt.OmitNull {
+			for _, child := range values {
+				if child.valueType == Null {
+					cnt--
+				}
+			}
+		}
+
+		writeCBORHead(buf, cborMap, uint64(cnt))
+		for i, k := range keys {
+			child := values[i]
+			if opt.OmitNull && child.valueType == Null {
+				continue
+			}
+			var par *ParentInfo
+			if opt.MarshalLessFunc != nil && !opt.cborDeterministic {
+				par = child.newParentInfo(parentInfo, stringKey(k))
+			}
+			writeCBORHead(buf, cborText, uint64(len(k)))
+			buf.WriteString(k)
+			if err := child.marshalCBORToBuffer(par, buf, opt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isCBORBigInt tells whether the number is an integer literal out of ranges of int64 and uint64.
+func (v *V) isCBORBigInt() bool {
+	if len(v.srcByte) < len(intMinStr)-1 || !v.isFloatNumber() {
+		return false
+	}
+	for i, b := range v.srcByte {
+		if (b < '0' || b > '9') && (i > 0 || b != '-') {
+			return false
+		}
+	}
+	n, ok := (&big.Int{}).SetString(string(v.srcByte), 10)
+	return ok && !n.IsInt64() && !n.IsUint64()
+}
+
+// writeCBORBigInt writes an integer literal as tag 2 (unsigned bignum) or tag 3 (negative bignum, -1 - n), unless
+// it fits in a negative integer.
+func writeCBORBigInt(buf *bytes.Buffer, lit []byte) {
+	n, _ := (&big.Int{}).SetString(string(lit), 10)
+	if n.Sign() < 0 {
+		n.Neg(n).Sub(n, big.NewInt(1))
+		if n.IsUint64() {
+			writeCBORHead(buf, cborNegInt, n.Uint64())
+			return
+		}
+		writeCBORHead(buf, cborTag, 3)
+	} else {
+		writeCBORHead(buf, cborTag, 2)
+	}
+	b := n.Bytes()
+	writeCBORHead(buf, cborBytes, uint64(len(b)))
+	buf.Write(b)
+}
+
+// cborDeterministicChildren returns children sorted in bytewise lexicographic order of encoded keys. As all keys are
+// text strings, it is equivalent to sorting by length first and then by bytes.
+func (v *V) cborDeterministicChildren() (keys []string, values []*V) {
+	keys = make([]string, 0, len(v.children.object))
+	for k := range v.children.object {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) < len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	values = make([]*V, len(keys))
+	for i, k := range keys {
+		values[i] = v.children.object[k].v
+	}
+	return keys, values
+}
+
+// writeCBORHead writes the initial byte and the following argument in the shortest form.
+func writeCBORHead(buf *bytes.Buffer, major byte, u uint64) {
+	switch {
+	case u < 24:
+		buf.WriteByte(major | byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(major | 24)
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		writeBigEndian(buf, u, 2)
+	case u <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		writeBigEndian(buf, u, 4)
+	default:
+		buf.WriteByte(major | 27)
+		writeBigEndian(buf, u, 8)
+	}
+}
+
+func writeCBORFloat(buf *bytes.Buffer, f float64) {
+	if math.IsNaN(f) {
+		// canonical NaN defined in RFC 8949 section 4.2.2
+		buf.Write([]byte{cborSimple | 25, 0x7e, 0x00})
+		return
+	}
+	if h, ok := float64ToHalf(f); ok {
+		buf.WriteByte(cborSimple | 25)
+		writeBigEndian(buf, uint64(h), 2)
+		return
+	}
+	if f32 := float32(f); float64(f32) == f {
+		buf.WriteByte(cborSimple | 26)
+		writeBigEndian(buf, uint64(math.Float32bits(f32)), 4)
+		return
+	}
+	buf.WriteByte(cborSimple | 27)
+	writeBigEndian(buf, math.Float64bits(f), 8)
+}
+
+// float64ToHalf converts f into IEEE 754 half precision if there is no precision lost.
+func float64ToHalf(f float64) (uint16, bool) {
+	var sign uint16
+	if math.Signbit(f) {
+		sign = 0x8000
+		f = -f
+	}
+
+	switch {
+	case f == 0:
+		return sign, true
+	case math.IsInf(f, 1):
+		return sign | 0x7c00, true
+	}
+
+	frac, exp := math.Frexp(f) // f = frac * 2^exp, 0.5 <= frac < 1
+	switch {
+	case exp > 16:
+		return 0, false
+
+	case exp >= -13:
+		// normal: f = 1.m * 2^(exp-1), with 10 bits of m
+		m := frac * (1 << 11)
+		if m != math.Trunc(m) {
+			return 0, false
+		}
+		return sign | uint16(exp-1+15)<<10 | uint16(m)&0x3ff, true
+
+	default:
+		// subnormal: f = m * 2^-24
+		m := math.Ldexp(f, 24)
+		if m != math.Trunc(m) || m < 1 {
+			return 0, false
+		}
+		return sign | uint16(m), true
+	}
+}
+
+func halfToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	m := float64(h & 0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(m, -24)
+	case 0x1f:
+		if m == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(m+1024, exp-25)
+	}
+
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
+
+// ---------------- decoding ----------------
+
+type cborDecoder struct {
+	b   []byte
+	off int
+	opt *Opt
+
+	// discarding is greater than zero when decoding contents of a tag which will be discarded, in which case no
+	// conversion errors are raised.
+	discarding int
+}
+
+func (d *cborDecoder) errorf(format string, a ...any) error {
+	return fmt.Errorf("%w: CBOR at offset %d: %s", ErrRawBytesUnrecignized, d.off, fmt.Sprintf(format, a...))
+}
+
+func (d *cborDecoder) readN(n uint64) ([]byte, error) {
+	if n > uint64(len(d.b)-d.off) {
+		return nil, d.errorf("unexpected end of data")
+	}
+	b := d.b[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// readHead reads the initial byte and its argument. For indefinite length, indefinite would be true.
+func (d *cborDecoder) readHead() (major byte, info byte, u uint64, indefinite bool, err error) {
+	b, err := d.readN(1)
+	if err != nil {
+		return
+	}
+	major, info = b[0]&0xe0, b[0]&0x1f
+
+	switch {
+	case info < 24:
+		u = uint64(info)
+	case info <= 27:
+		b, err = d.readN(1 << (info - 24))
+		if err != nil {
+			return
+		}
+		for _, c := range b {
+			u = u<<8 | uint64(c)
+		}
+	case info == 31:
+		indefinite = true
+	default:
+		d.off--
+		err = d.errorf("reserved additional information %d", info)
+	}
+	return
+}
+
+func (d *cborDecoder) decode() (*V, error) {
+	start := d.off
+	major, info, u, indefinite, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+	if indefinite {
+		switch major {
+		case cborUint, cborNegInt, cborTag:
+			d.off--
+			return nil, d.errorf("unexpected indefinite length")
+		case cborSimple:
+			d.off--
+			return nil, d.errorf("unexpected break")
+		}
+	}
+
+	switch major {
+	default: // cborUint
+		return NewUint64(u), nil
+
+	case cborNegInt:
+		if u <= math.MaxInt64 {
+			return NewInt64(-1 - int64(u)), nil
+		}
+		n := (&big.Int{}).SetUint64(u)
+		return newCBORBigInt(n.Neg(n).Sub(n, big.NewInt(1)))
+
+	case cborBytes:
+		b, err := d.readString(cborBytes, u, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		return d.convertBytes(b)
+
+	case cborText:
+		b, err := d.readString(cborText, u, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(b) {
+			return nil, d.errorf("invalid UTF-8 text string")
+		}
+		return NewString(string(b)), nil
+
+	case cborArray:
+		return d.decodeArray(u, indefinite)
+
+	case cborMap:
+		return d.decodeMap(u, indefinite)
+
+	case cborTag:
+		return d.decodeTag(start, u)
+
+	case cborSimple:
+		return d.decodeSimple(start, info, u)
+	}
+}
+
+// readString reads a byte or text string, concatenating chunks if its length is indefinite.
+func (d *cborDecoder) readString(major byte, le uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		return d.readN(le)
+	}
+
+	var res []byte
+	for {
+		if d.off < len(d.b) && d.b[d.off] == 0xff {
+			d.off++
+			return res, nil
+		}
+		m, _, le, indefinite, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if m != major || indefinite {
+			return nil, d.errorf("invalid chunk in indefinite length string")
+		}
+		b, err := d.readN(le)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, b...)
+	}
+}
+
+// isBreak consumes the "break" stop code if it is the next byte.
+func (d *cborDecoder) isBreak() bool {
+	if d.off < len(d.b) && d.b[d.off] == 0xff {
+		d.off++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) decodeArray(le uint64, indefinite bool) (*V, error) {
+	// each element takes at least one byte
+	if !indefinite && le > uint64(len(d.b)-d.off) {
+		return nil, d.errorf("array length %d exceeds data", le)
+	}
+	arr := newArray()
+	for i := uint64(0); indefinite || i < le; i++ {
+		if indefinite && d.isBreak() {
+			break
+		}
+		child, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		arr.appendToArr(child)
+	}
+	return arr, nil
+}
+
+func (d *cborDecoder) decodeMap(le uint64, indefinite bool) (*V, error) {
+	// each key-value pair takes at least two bytes
+	if !indefinite && le > uint64(len(d.b)-d.off)/2 {
+		return nil, d.errorf("map length %d exceeds data", le)
+	}
+	obj := newObject()
+	for i := uint64(0); indefinite || i < le; i++ {
+		if indefinite && d.isBreak() {
+			break
+		}
+		k, err := d.decodeKey()
+		if err != nil {
+			return nil, err
+		}
+		child, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		obj.setToObjectChildren(k, child)
+	}
+	return obj, nil
+}
+
+func (d *cborDecoder) decodeKey() (string, error) {
+	if d.off < len(d.b) {
+		switch d.b[d.off] & 0xe0 {
+		case cborUint, cborNegInt, cborText:
+			k, err := d.decode()
+			if err != nil {
+				return "", err
+			}
+			return k.String(), nil
+		}
+	}
+	return "", d.errorf("unsupported map key")
+}
+
+func (d *cborDecoder) convertBytes(b []byte) (*V, error) {
+	switch d.opt.cborByteString {
+	default:
+		return NewBytes(b), nil
+	case CBORConvertToNull:
+		return NewNull(), nil
+	case CBORConvertToError:
+		if d.discarding > 0 {
+			return NewNull(), nil
+		}
+		return nil, d.errorf("byte string is not allowed")
+	}
+}
+
+func (d *cborDecoder) decodeTag(start int, tag uint64) (*V, error) {
+	conv := d.opt.cborTag
+	if conv == CBORConvertToError && d.discarding == 0 {
+		d.off = start
+		return nil, d.errorf("tag %d is not allowed", tag)
+	}
+
+	if conv != CBORConvertDefault {
+		// decode and discard the content, in order to move to the end of this data item
+		d.discarding++
+		_, err := d.decode()
+		d.discarding--
+		if err != nil {
+			return nil, err
+		}
+		if conv == CBORConvertToBase64 {
+			return NewBytes(d.b[start:d.off]), nil
+		}
+		return NewNull(), nil
+	}
+
+	if tag != 2 && tag != 3 {
+		return d.decode()
+	}
+
+	// bignums
+	major, _, le, indefinite, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+	if major != cborBytes {
+		return nil, d.errorf("invalid content of bignum")
+	}
+	b, err := d.readString(cborBytes, le, indefinite)
+	if err != nil {
+		return nil, err
+	}
+	n := (&big.Int{}).SetBytes(b)
+	if tag == 3 {
+		n.Neg(n).Sub(n, big.NewInt(1))
+	}
+	switch {
+	case n.IsUint64():
+		return NewUint64(n.Uint64()), nil
+	case n.IsInt64():
+		return NewInt64(n.Int64()), nil
+	default:
+		return newCBORBigInt(n)
+	}
+}
+
+// newCBORBigInt keeps an integer out of ranges of int64 and uint64 exactly in its decimal text, like a JSON number
+// literal, while its float64 value is an approximation.
+func newCBORBigInt(n *big.Int) (*V, error) {
+	s := n.String()
+	v, err := iter(s).parseFloatResult(0, len(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: CBOR integer with %d digits exceeds float64", ErrOutOfRange, len(s))
+	}
+	return v, nil
+}
+
+func (d *cborDecoder) decodeSimple(start int, info byte, u uint64) (*V, error) {
+	switch info {
+	case 25:
+		return newDecodedFloat(NewFloat32(float32(halfToFloat64(uint16(u))))), nil
+	case 26:
+		return newDecodedFloat(NewFloat32(math.Float32frombits(uint32(u)))), nil
+	case 27:
+		return newDecodedFloat(NewFloat64(math.Float64frombits(u))), nil
+	}
+
+	switch u {
+	case 20:
+		return NewBool(false), nil
+	case 21:
+		return NewBool(true), nil
+	case 22:
+		return NewNull(), nil
+	}
+
+	switch d.opt.cborUndefined {
+	default:
+		return NewNull(), nil
+	case CBORConvertToBase64:
+		return NewBytes(d.b[start:d.off]), nil
+	case CBORConvertToError:
+		if d.discarding > 0 {
+			return NewNull(), nil
+		}
+		d.off = start
+		if u == 23 {
+			return nil, d.errorf("undefined is not allowed")
+		}
+		return nil, d.errorf("simple value %d is not allowed", u)
+	}
+}
@@ -0,0 +1,280 @@
+package jsonvalue
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testCBOR(t *testing.T) {
+	cv("encoding", func() { testCBOREncoding(t) })
+	cv("deterministic encoding", func() { testCBORDeterministic(t) })
+	cv("decoding", func() { testCBORDecoding(t) })
+	cv("conversions", func() { testCBORConversions(t) })
+	cv("errors", func() { testCBORErrors(t) })
+}
+
+func cborHex(v *V, opts ...Option) string {
+	b, err := v.MarshalCBOR(opts...)
+	so(err, isNil)
+	return hex.EncodeToString(b)
+}
+
+func cborDecodeHex(s string, opts ...Option) (*V, error) {
+	b, err := hex.DecodeString(s)
+	so(err, isNil)
+	return UnmarshalCBOR(b, opts...)
+}
+
+func testCBOREncoding(t *testing.T) {
+	// examples from RFC 8949 appendix A
+	so(cborHex(NewInt(0)), eq, "00")
+	so(cborHex(NewInt(23)), eq, "17")
+	so(cborHex(NewInt(24)), eq, "1818")
+	so(cborHex(NewInt(1000)), eq, "1903e8")
+	so(cborHex(NewInt(1000000)), eq, "1a000f4240")
+	so(cborHex(NewInt64(1000000000000)), eq, "1b000000e8d4a51000")
+	so(cborHex(NewUint64(math.MaxUint64)), eq, "1bffffffffffffffff")
+	so(cborHex(NewInt(-1)), eq, "20")
+	so(cborHex(NewInt(-100)), eq, "3863")
+	so(cborHex(NewInt(-1000)), eq, "3903e7")
+	so(cborHex(NewInt64(math.MinInt64)), eq, "3b7fffffffffffffff")
+
+	so(cborHex(MustUnmarshalString(`0.0`)), eq, "f90000")
+	so(cborHex(NewFloat64(math.Copysign(0, -1))), eq, "f98000")
+	so(cborHex(MustUnmarshalString(`1.0`)), eq, "f93c00")
+	so(cborHex(NewFloat64(1.1)), eq, "fb3ff199999999999a")
+	so(cborHex(NewFloat64(1.5)), eq, "f93e00")
+	so(cborHex(MustUnmarshalString(`65504.0`)), eq, "f97bff")
+	so(cborHex(MustUnmarshalString(`100000.0`)), eq, "fa47c35000")
+	so(cborHex(NewFloat64(3.4028234663852886e+38)), eq, "fa7f7fffff")
+	so(cborHex(NewFloat64(1.0e+300)), eq, "fb7e37e43c8800759c")
+	so(cborHex(NewFloat64(5.960464477539063e-8)), eq, "f90001")
+	so(cborHex(NewFloat64(0.00006103515625)), eq, "f90400")
+	so(cborHex(MustUnmarshalString(`-4.0`)), eq, "f9c400")
+	so(cborHex(NewFloat64(-4.1)), eq, "fbc010666666666666")
+	so(cborHex(NewFloat64(math.Inf(1))), eq, "f97c00")
+	so(cborHex(NewFloat64(math.NaN())), eq, "f97e00")
+	so(cborHex(NewFloat64(math.Inf(-1))), eq, "f9fc00")
+
+	// integral values created by NewFloat64() are encoded as integers
+	so(cborHex(NewFloat64(1)), eq, "01")
+	so(cborHex(NewFloat64(-4)), eq, "23")
+
+	so(cborHex(NewBool(false)), eq, "f4")
+	so(cborHex(NewBool(true)), eq, "f5")
+	so(cborHex(NewNull()), eq, "f6")
+	so(cborHex(NewString("")), eq, "60")
+	so(cborHex(NewString("IETF")), eq, "6449455446")
+	so(cborHex(NewString("水")), eq, "63e6b0b4")
+	so(cborHex(MustUnmarshalString(`[1,[2,3],[4,5]]`)), eq, "8301820203820405")
+	so(cborHex(MustUnmarshalString(`["a",{"b":"c"}]`)), eq, "826161a161626163")
+
+	v := MustUnmarshalString(`{"b":1,"a":null,"c":[true]}`)
+	so(cborHex(v, OptSetSequence()), eq, "a36162016161f66163"+"81f5")
+	so(cborHex(v, OptKeySequence([]string{"c"}), OptOmitNull(true)), eq, "a2616381f5616201")
+	so(cborHex(v, OptDefaultStringSequence()), eq, "a36161f66162016163"+"81f5")
+
+	// round trip
+	v = MustUnmarshalString(`{"int":-12345,"uint":18446744073709551615,"float":3.14159,"str":"中文",` +
+		`"arr":[null,true,false,{},[]],"obj":{"a":{"b":1}}}`)
+	b, err := v.MarshalCBOR()
+	so(err, isNil)
+	got, err := UnmarshalCBOR(b)
+	so(err, isNil)
+	so(got.Equal(v), isTrue)
+
+	long := strings.Repeat("x", 300)
+	b, err = NewString(long).MarshalCBOR()
+	so(err, isNil)
+	so(hex.EncodeToString(b[:3]), eq, "79012c")
+	got, err = UnmarshalCBOR(b)
+	so(err, isNil)
+	so(got.String(), eq, long)
+}
+
+func testCBORDeterministic(t *testing.T) {
+	v := NewObject()
+	v.SetInt(1).At("bb")
+	v.SetInt(2).At("a")
+	v.SetInt(3).At("b")
+	v.SetInt(4).At(strings.Repeat("k", 24))
+	v.SetNull().At("aaa")
+
+	b, err := v.MarshalCBOR(OptCBORDeterministic(), OptKeySequence([]string{"bb"}))
+	so(err, isNil)
+	got, err := UnmarshalCBOR(b)
+	so(err, isNil)
+	keys := []string{}
+	got.RangeObjectsBySetSequence(func(k string, _ *V) bool {
+		keys = append(keys, k)
+		return true
+	})
+	so(strings.Join(keys, ","), eq, "a,b,bb,aaa,"+strings.Repeat("k", 24))
+
+	// identical output regardless of set sequence
+	another := NewObject()
+	another.SetNull().At("aaa")
+	another.SetInt(4).At(strings.Repeat("k", 24))
+	another.SetInt(3).At("b")
+	another.SetInt(2).At("a")
+	another.SetInt(1).At("bb")
+	b2, err := another.MarshalCBOR(OptCBORDeterministic())
+	so(err, isNil)
+	so(hex.EncodeToString(b2), eq, hex.EncodeToString(b))
+
+	// OptCanonical() is for JSON only
+	b3, err := another.MarshalCBOR(OptCanonical(), OptSetSequence())
+	so(err, isNil)
+	got, err = UnmarshalCBOR(b3)
+	so(err, isNil)
+	so(got.MustMarshalString(OptSetSequence()), eq, another.MustMarshalString(OptSetSequence()))
+}
+
+func testCBORDecoding(t *testing.T) {
+	cases := map[string]string{
+		"1bffffffffffffffff":         `18446744073709551615`,
+		"3b7fffffffffffffff":         `-9223372036854775808`,
+		"f93c00":                     `1`,
+		"f93e00":                     `1.5`,
+		"f90001":                     `5.960464477539063e-8`,
+		"fa47c35000":                 `100000`,
+		"fb3ff199999999999a":         `1.1`,
+		"9fff":                       `[]`,
+		"9f018202039f0405ffff":       `[1,[2,3],[4,5]]`,
+		"bf61610161629f0203ffff":     `{"a":1,"b":[2,3]}`,
+		"7f657374726561646d696e67ff": `"streaming"`,
+		"a201020304":                 `{"1":2,"3":4}`,
+		"a1206161":                   `{"-1":"a"}`,
+		"c074323031332d30332d32315432303a30343a30305a": `"2013-03-21T20:04:00Z"`,
+		"c11a514b67b0":           `1363896240`,
+		"c3420100":               `-257`,
+		"c249000000000000000001": `1`,
+		"d9d9f7a0":               `{}`,
+	}
+	for s, expected := range cases {
+		v, err := cborDecodeHex(s)
+		so(err, isNil)
+		exp := MustUnmarshalString(expected)
+		if exp.IsNumber() && exp.IsFloat() {
+			so(v.Float64(), eq, exp.Float64())
+			continue
+		}
+		so(v.MustMarshalString(OptSetSequence()), eq, exp.MustMarshalString(OptSetSequence()))
+	}
+
+	// integers out of ranges of int64 and uint64 are kept exactly
+	bigInts := map[string]string{
+		"3bffffffffffffffff":                   "-18446744073709551616",
+		"3b8000000000000000":                   "-9223372036854775809",
+		"c249010000000000000000":               "18446744073709551616",
+		"c3500123456789abcdef0123456789abcdef": "-1512366075204170929049582354406559216",
+		"c25001000000000000000000000000000001": "1329227995784915872903807060280344577",
+	}
+	for s, expected := range bigInts {
+		v, err := cborDecodeHex(s)
+		so(err, isNil)
+		so(v.IsNumber(), isTrue)
+		so(v.MustMarshalString(), eq, expected)
+		so(cborHex(v), eq, s)
+	}
+	v, err := cborDecodeHex("3bffffffffffffffff")
+	so(err, isNil)
+	so(v.Float64(), eq, -18446744073709551616.0)
+
+	// bignums beyond float64
+	_, err = cborDecodeHex("c2590101" + strings.Repeat("ff", 257))
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+
+	v, err = cborDecodeHex("f97c00")
+	so(err, isNil)
+	so(math.IsInf(v.Float64(), 1), isTrue)
+
+	v, err = cborDecodeHex("f97e00")
+	so(err, isNil)
+	so(math.IsNaN(v.Float64()), isTrue)
+}
+
+func testCBORConversions(t *testing.T) {
+	// byte strings
+	v, err := cborDecodeHex("4401020304")
+	so(err, isNil)
+	so(v.String(), eq, NewBytes([]byte{1, 2, 3, 4}).String())
+
+	v, err = cborDecodeHex("5f42010243030405ff")
+	so(err, isNil)
+	so(v.String(), eq, NewBytes([]byte{1, 2, 3, 4, 5}).String())
+
+	v, err = cborDecodeHex("4401020304", OptCBORByteString(CBORConvertToNull))
+	so(err, isNil)
+	so(v.IsNull(), isTrue)
+
+	_, err = cborDecodeHex("4401020304", OptCBORByteString(CBORConvertToError))
+	so(err, isErr)
+
+	// undefined
+	v, err = cborDecodeHex("f7")
+	so(err, isNil)
+	so(v.IsNull(), isTrue)
+
+	v, err = cborDecodeHex("f7", OptCBORUndefined(CBORConvertToBase64))
+	so(err, isNil)
+	so(v.String(), eq, NewBytes([]byte{0xf7}).String())
+
+	_, err = cborDecodeHex("f7", OptCBORUndefined(CBORConvertToError))
+	so(err, isErr)
+	_, err = cborDecodeHex("f0", OptCBORUndefined(CBORConvertToError))
+	so(err, isErr)
+
+	v, err = cborDecodeHex("f8ff")
+	so(err, isNil)
+	so(v.IsNull(), isTrue)
+
+	// tags
+	v, err = cborDecodeHex("82c11a514b67b001", OptCBORTag(CBORConvertToNull))
+	so(err, isNil)
+	so(v.MustMarshalString(), eq, `[null,1]`)
+
+	v, err = cborDecodeHex("82c11a514b67b001", OptCBORTag(CBORConvertToBase64))
+	so(err, isNil)
+	so(v.MustGet(0).String(), eq, NewBytes([]byte{0xc1, 0x1a, 0x51, 0x4b, 0x67, 0xb0}).String())
+
+	_, err = cborDecodeHex("82c11a514b67b001", OptCBORTag(CBORConvertToError))
+	so(err, isErr)
+	so(err.Error(), hasSubStr, "tag 1")
+
+	// contents of discarded tags do not raise conversion errors
+	v, err = cborDecodeHex("d8184401020304", OptCBORTag(CBORConvertToNull), OptCBORByteString(CBORConvertToError))
+	so(err, isNil)
+	so(v.IsNull(), isTrue)
+}
+
+func testCBORErrors(t *testing.T) {
+	_, err := (&V{}).MarshalCBOR()
+	so(errors.Is(err, ErrValueUninitialized), isTrue)
+
+	for _, s := range []string{
+		"",                   // empty
+		"19",                 // truncated
+		"1c",                 // reserved additional information
+		"1f",                 // indefinite integer
+		"ff",                 // unexpected break
+		"6261",               // truncated text
+		"62c328",             // invalid UTF-8
+		"9b7fffffffffffffff", // array length exceeds data
+		"bb7fffffffffffffff", // map length exceeds data
+		"a1f6f6",             // null key
+		"a1410101",           // byte string key
+		"7f4101ff",           // invalid chunk
+		"9f01",               // missing break
+		"c26161",             // invalid bignum
+		"0102",               // extra bytes
+	} {
+		v, err := cborDecodeHex(s)
+		so(err, isErr)
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+		so(v.ValueType(), eq, NotExist)
+	}
+}
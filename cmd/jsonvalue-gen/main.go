@@ -0,0 +1,82 @@
+// Command jsonvalue-gen generates Go struct definitions from sample JSON documents.
+//
+// Usage:
+//
+//	jsonvalue-gen [-type Name] [-package main] [-each] [-o output.go] [sample.json ...]
+//
+// Each file is treated as one sample, and standard input is read if no file is given. With -each, elements of a
+// root array are treated as separated samples.
+//
+// jsonvalue-gen 命令根据 JSON 样本文档生成 Go 结构体定义。每个文件视为一个样本，如果没有指定文件，则读取标准输入。
+// 指定 -each 时，根数组中的每一个元素均视为一个独立样本。
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+	"github.com/Andrew-M-C/go.jsonvalue/jsonvaluegen"
+)
+
+func main() {
+	typeName := flag.String("type", "Root", "name of the root type")
+	pkg := flag.String("package", "main", "package name of generated file")
+	each := flag.Bool("each", false, "treat elements of root array as separated samples")
+	output := flag.String("o", "", "output file, standard output if not specified")
+	flag.Parse()
+
+	if err := run(*typeName, *pkg, *each, *output, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "jsonvalue-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(typeName, pkg string, each bool, output string, files []string) error {
+	var samples []*jsonvalue.V
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, f := range files {
+		b, err := readFile(f)
+		if err != nil {
+			return err
+		}
+		v, err := jsonvalue.Unmarshal(b)
+		if err != nil {
+			return fmt.Errorf("parsing %s error: %w", f, err)
+		}
+		if each && v.IsArray() {
+			samples = append(samples, v.ForRangeArr()...)
+		} else {
+			samples = append(samples, v)
+		}
+	}
+
+	code, err := jsonvaluegen.GenerateStructs(typeName, samples...)
+	if err != nil {
+		return err
+	}
+
+	buff := bytes.Buffer{}
+	buff.WriteString("// Code generated by jsonvalue-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buff, "package %s\n\n", pkg)
+	buff.Write(code)
+
+	if output == "" {
+		_, err = os.Stdout.Write(buff.Bytes())
+		return err
+	}
+	return ioutil.WriteFile(output, buff.Bytes(), 0644)
+}
+
+func readFile(name string) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(name)
+}
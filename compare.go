@@ -0,0 +1,226 @@
+package jsonvalue
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// typeSequenceInCompare defines the order of value types in Compare().
+var typeSequenceInCompare = [Unknown + 1]int{
+	NotExist: 0,
+	Null:     1,
+	Boolean:  2,
+	Number:   3,
+	String:   4,
+	Array:    5,
+	Object:   6,
+	Unknown:  7,
+}
+
+// Compare returns an integer comparing two JSON values with a total order. The result will be 0 if a == b,
+// -1 if a < b, and +1 if a > b. The order is defined as below:
+//
+// 1. Values are firstly ordered by type: NotExist (including nil) < null < boolean < number < string < array < object.
+//
+// 2. For booleans, false < true.
+//
+// 3. Numbers are compared as decimals, so 1.0 equals to 1. NaN < -Inf < all other numbers < +Inf.
+//
+// 4. Strings are compared by bytes, just like strings.Compare().
+//
+// 5. Arrays are compared element by element recursively. If all elements of the shorter one equal to
+// those of the longer one, the shorter one is less.
+//
+// 6. Objects are compared by their key-value pairs in dictionary sequence of keys. Keys are compared
+// before values. If all pairs of the smaller object equal to those of the larger one, the smaller object is less.
+//
+// Compare(a, b) == 0 is equivalent to a.Equal(b) for all valid values.
+//
+// Compare 按照一个确定的全序关系比较两个 JSON 值。如果 a == b 则返回 0，a < b 返回 -1，a > b 返回 +1。顺序定义如下:
+//
+// 1. 首先按类型排序: NotExist（包括 nil）< null < boolean < number < string < array < object。
+//
+// 2. 布尔值中 false < true。
+//
+// 3. 数字按照十进制数值进行比较，因此 1.0 等于 1。NaN < -Inf < 其他所有数字 < +Inf。
+//
+// 4. 字符串按字节比较，与 strings.Compare() 相同。
+//
+// 5. 数组逐个成员递归比较。如果较短数组的所有成员均与较长数组的对应成员相等，则较短的数组更小。
+//
+// 6. object 按照键的字典序，逐个比较键值对，先比较键，再比较值。如果成员较少的 object 的所有键值对均与另一个相等，则成员较少者更小。
+//
+// 对于所有合法的值，Compare(a, b) == 0 等价于 a.Equal(b)。
+func Compare(a, b *V) int {
+	ta, tb := compareTypeSequence(a), compareTypeSequence(b)
+	if ta != tb {
+		return compareInt(ta, tb)
+	}
+	if a == nil || b == nil {
+		return 0
+	}
+
+	switch a.valueType {
+	default: // NotExist, Unknown and nil
+		return 0
+	case Null:
+		return 0
+	case Boolean:
+		return compareBool(a.valueBool, b.valueBool)
+	case Number:
+		return numberCompare(a, b)
+	case String:
+		return strings.Compare(a.valueStr, b.valueStr)
+	case Array:
+		return arrayCompare(a, b)
+	case Object:
+		return objectCompare(a, b)
+	}
+}
+
+func compareTypeSequence(v *V) int {
+	if v == nil {
+		return typeSequenceInCompare[NotExist]
+	}
+	t := v.valueType
+	if t < NotExist || t > Unknown {
+		t = Unknown
+	}
+	return typeSequenceInCompare[t]
+}
+
+func compareInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func compareBool(a, b bool) int {
+	if a == b {
+		return 0
+	}
+	if b {
+		return -1
+	}
+	return 1
+}
+
+func numberCompare(left, right *V) int {
+	if len(left.srcByte) > 0 && len(right.srcByte) > 0 {
+		d1, err1 := decimal.NewFromString(string(left.srcByte))
+		d2, err2 := decimal.NewFromString(string(right.srcByte))
+		if err1 == nil && err2 == nil {
+			return d1.Cmp(d2)
+		}
+	}
+
+	// NaN, +Inf or -Inf involved
+	s1, s2 := invalidFloatSequence(left.num.f64), invalidFloatSequence(right.num.f64)
+	if s1 != s2 {
+		return compareInt(s1, s2)
+	}
+	return 0
+}
+
+// invalidFloatSequence returns sequence of special float values, while all other valid numbers return 2.
+func invalidFloatSequence(f float64) int {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case math.IsInf(f, -1):
+		return 1
+	case math.IsInf(f, 1):
+		return 3
+	default:
+		return 2
+	}
+}
+
+func arrayCompare(left, right *V) int {
+	for i, leftChild := range left.children.arr {
+		if i >= len(right.children.arr) {
+			return 1
+		}
+		if res := Compare(leftChild, right.children.arr[i]); res != 0 {
+			return res
+		}
+	}
+	return compareInt(len(left.children.arr), len(right.children.arr))
+}
+
+func objectCompare(left, right *V) int {
+	leftKeys := sortedObjectKeys(left)
+	rightKeys := sortedObjectKeys(right)
+
+	for i, k := range leftKeys {
+		if i >= len(rightKeys) {
+			return 1
+		}
+		if res := strings.Compare(k, rightKeys[i]); res != 0 {
+			return res
+		}
+		res := Compare(left.children.object[k].v, right.children.object[k].v)
+		if res != 0 {
+			return res
+		}
+	}
+	return compareInt(len(leftKeys), len(rightKeys))
+}
+
+func sortedObjectKeys(v *V) []string {
+	keys := make([]string, 0, len(v.children.object))
+	for k := range v.children.object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AscendingArrayLess is an ArrayLessFunc which sorts array members in ascending order defined by Compare().
+//
+// AscendingArrayLess 是一个 ArrayLessFunc，按照 Compare() 定义的顺序将数组成员升序排列。
+func AscendingArrayLess(v1, v2 *V) bool {
+	return Compare(v1, v2) < 0
+}
+
+// DescendingArrayLess is an ArrayLessFunc which sorts array members in descending order defined by Compare().
+//
+// DescendingArrayLess 是一个 ArrayLessFunc，按照 Compare() 定义的顺序将数组成员降序排列。
+func DescendingArrayLess(v1, v2 *V) bool {
+	return Compare(v1, v2) > 0
+}
+
+// ArrayLessByPath returns an ArrayLessFunc which sorts array members in ascending order by the values at given
+// sub-path, with the same parameter format as Get(). Members without given sub-path are placed at first. For
+// example, sorting an array of objects by "created_at" field:
+//
+//	v.SortArray(jsonvalue.ArrayLessByPath("created_at"))
+//
+// ArrayLessByPath 返回一个 ArrayLessFunc，按照每个数组成员在指定子路径上的值进行升序排列，路径参数格式与 Get() 相同。
+// 不存在该子路径的成员会被排在最前面。比如对一个 object 数组按照 "created_at" 字段排序:
+//
+//	v.SortArray(jsonvalue.ArrayLessByPath("created_at"))
+func ArrayLessByPath(firstParam any, otherParams ...any) ArrayLessFunc {
+	return func(v1, v2 *V) bool {
+		c1, _ := v1.Get(firstParam, otherParams...)
+		c2, _ := v2.Get(firstParam, otherParams...)
+		return Compare(c1, c2) < 0
+	}
+}
+
+// ReverseArrayLess returns an ArrayLessFunc which reverses the order of given one. It is useful to build
+// descending order with ArrayLessByPath().
+//
+// ReverseArrayLess 返回一个与给定的 ArrayLessFunc 顺序相反的 ArrayLessFunc。可以搭配 ArrayLessByPath() 实现降序排列。
+func ReverseArrayLess(lessFunc ArrayLessFunc) ArrayLessFunc {
+	return func(v1, v2 *V) bool {
+		return lessFunc(v2, v1)
+	}
+}
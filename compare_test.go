@@ -0,0 +1,127 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func testCompare(t *testing.T) {
+	cv("compare types", func() { testCompareTypes(t) })
+	cv("compare values", func() { testCompareValues(t) })
+	cv("consistent with Equal", func() { testCompareConsistentWithEqual(t) })
+	cv("less functions", func() { testCompareLessFuncs(t) })
+}
+
+func testCompareTypes(t *testing.T) {
+	seq := []*V{
+		nil,
+		NewNull(),
+		NewBool(false),
+		NewInt(-100),
+		NewString(""),
+		NewArray(),
+		NewObject(),
+	}
+
+	for i, v1 := range seq {
+		for j, v2 := range seq {
+			so(Compare(v1, v2), eq, compareInt(i, j))
+		}
+	}
+
+	so(Compare(&V{}, nil), eq, 0)
+}
+
+func testCompareValues(t *testing.T) {
+	cv("boolean", func() {
+		so(Compare(NewBool(false), NewBool(true)), eq, -1)
+		so(Compare(NewBool(true), NewBool(false)), eq, 1)
+		so(Compare(NewBool(true), NewBool(true)), eq, 0)
+	})
+
+	cv("number", func() {
+		so(Compare(MustUnmarshalString("1.0"), NewInt(1)), eq, 0)
+		so(Compare(MustUnmarshalString("1e2"), NewInt(99)), eq, 1)
+		so(Compare(NewInt(-1), NewUint64(math.MaxUint64)), eq, -1)
+		so(Compare(
+			MustUnmarshalString("12345678901234567890.000000000000000001"),
+			MustUnmarshalString("12345678901234567890"),
+		), eq, 1)
+
+		nan, infP, infN := NewFloat64(math.NaN()), NewFloat64(math.Inf(1)), NewFloat64(math.Inf(-1))
+		so(Compare(nan, infN), eq, -1)
+		so(Compare(infN, NewFloat64(-math.MaxFloat64)), eq, -1)
+		so(Compare(infP, NewFloat64(math.MaxFloat64)), eq, 1)
+		so(Compare(infP, infP), eq, 0)
+	})
+
+	cv("string", func() {
+		so(Compare(NewString("a"), NewString("b")), eq, -1)
+		so(Compare(NewString("ab"), NewString("a")), eq, 1)
+		so(Compare(NewString("a"), NewString("a")), eq, 0)
+	})
+
+	cv("array", func() {
+		so(Compare(MustUnmarshalString(`[1,2]`), MustUnmarshalString(`[1,2,0]`)), eq, -1)
+		so(Compare(MustUnmarshalString(`[1,3]`), MustUnmarshalString(`[1,2,0]`)), eq, 1)
+		so(Compare(MustUnmarshalString(`[1,[2]]`), MustUnmarshalString(`[1,[2.0]]`)), eq, 0)
+	})
+
+	cv("object", func() {
+		so(Compare(MustUnmarshalString(`{"a":1}`), MustUnmarshalString(`{"b":0}`)), eq, -1)
+		so(Compare(MustUnmarshalString(`{"a":2}`), MustUnmarshalString(`{"a":1,"b":0}`)), eq, 1)
+		so(Compare(MustUnmarshalString(`{"a":1}`), MustUnmarshalString(`{"a":1,"b":0}`)), eq, -1)
+		so(Compare(MustUnmarshalString(`{"b":{"c":[]},"a":1}`), MustUnmarshalString(`{"a":1,"b":{"c":[]}}`)), eq, 0)
+	})
+}
+
+func testCompareConsistentWithEqual(t *testing.T) {
+	raws := []string{
+		`null`, `true`, `false`, `0`, `-0.0`, `1`, `1.00`, `"1"`, `""`, `[]`, `{}`,
+		`[1,2]`, `[1,2.0]`, `{"a":[1]}`, `{"a":[1.0]}`, `{"a":[1],"b":null}`,
+	}
+	for _, r1 := range raws {
+		for _, r2 := range raws {
+			v1, v2 := MustUnmarshalString(r1), MustUnmarshalString(r2)
+			so(Compare(v1, v2) == 0, eq, v1.Equal(v2))
+			so(Compare(v1, v2), eq, -Compare(v2, v1))
+		}
+	}
+}
+
+func testCompareLessFuncs(t *testing.T) {
+	cv("ascending and descending", func() {
+		v := MustUnmarshalString(`[3, "a", null, 1.5, {}, [], true, -2]`)
+		v.SortArray(AscendingArrayLess)
+		so(v.MustMarshalString(), eq, `[null,true,-2,1.5,3,"a",[],{}]`)
+
+		v.SortArray(DescendingArrayLess)
+		so(v.MustMarshalString(), eq, `[{},[],"a",3,1.5,-2,true,null]`)
+	})
+
+	cv("by path", func() {
+		v := MustUnmarshalString(`[
+			{"id":1,"meta":{"created_at":300}},
+			{"id":2,"meta":{"created_at":100}},
+			{"id":3},
+			{"id":4,"meta":{"created_at":200}}
+		]`)
+
+		v.SortArray(ArrayLessByPath("meta", "created_at"))
+		ids := []int{}
+		v.RangeArray(func(_ int, c *V) bool {
+			ids = append(ids, c.MustGet("id").Int())
+			return true
+		})
+		so(fmt.Sprint(ids), eq, "[3 2 4 1]")
+
+		v.SortArray(ReverseArrayLess(ArrayLessByPath("meta", "created_at")))
+		ids = ids[:0]
+		v.RangeArray(func(_ int, c *V) bool {
+			ids = append(ids, c.MustGet("id").Int())
+			return true
+		})
+		so(fmt.Sprint(ids), eq, "[1 4 2 3]")
+	})
+}
@@ -0,0 +1,362 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// MismatchError is returned by CheckContains(), showing where and why the first mismatch occurs. KeyPath is the
+// path of the mismatch in the checked value, including the inPath parameters. SubKeyPath is the path of the
+// corresponding expected value in the subset, whose array indexes are those in the subset rather than in the
+// checked value.
+//
+// MismatchError 由 CheckContains() 返回，表示第一个不匹配的位置以及原因。KeyPath 是不匹配位置在被检查值中的路径，包含 inPath
+// 参数。SubKeyPath 则是对应的期望值在子集中的路径，其中的数组下标是子集中的下标，而不是被检查值中的下标。
+type MismatchError struct {
+	KeyPath    KeyPath
+	SubKeyPath KeyPath
+	Reason     string
+}
+
+// Error implements error interface.
+//
+// Error 实现 error 接口。
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("mismatch at %v: %s", e.KeyPath, e.Reason)
+}
+
+func mismatchErrorf(path, subPath KeyPath, format string, a ...any) *MismatchError {
+	return &MismatchError{
+		KeyPath:    path,
+		SubKeyPath: subPath,
+		Reason:     fmt.Sprintf(format, a...),
+	}
+}
+
+// Contains identifies whether a value has a subset. Please refer to CheckContains() for details.
+//
+// Contains 表示是否包含某个子集。详情请参见 CheckContains()。
+func Contains(v *V, sub any, inPath ...any) bool {
+	return CheckContains(v, sub, inPath...) == nil
+}
+
+// CheckContains checks whether v contains subset sub in path inPath. A *MismatchError will be returned
+// if not. The sub parameter can be any type, just like the parameter of New() does, and matchers generated by
+// MatchXxx() functions can be used anywhere in it. It is checked by following sequence:
+//
+// - If sub is a matcher generated by MatchXxx() functions, the matcher decides.
+//
+// - If the types of two values are different, it is a mismatch.
+//
+// - If neither array nor object, the two values should equal to each other.
+//
+// - If object typed, every key in sub should exist in v, and each value of v should contain the
+// corresponding value of sub recursively.
+//
+// - If array typed, sub should be a contiguous sub-array of v, and each element of v should contain
+// the corresponding element of sub recursively.
+//
+// CheckContains 检查 v 在 inPath 路径下是否包含子集 sub，如果不包含，则返回一个 *MismatchError。sub 可以是任意类型，
+// 与 New() 函数的参数相同，并且可以在其中任意位置使用由 MatchXxx() 函数生成的匹配器。按照以下顺序检查:
+//
+// - 如果 sub 是一个由 MatchXxx() 函数生成的匹配器，则由匹配器决定。
+//
+// - 如果两个值的类型不同，则不匹配。
+//
+// - 如果既不是数组也不是 object，那么两个值需相等。
+//
+// - 如果是 object 类型，则 sub 中的每一个键在 v 中都需要存在，并且 v 的值需递归地包含 sub 的对应值。
+//
+// - 如果是数组类型，则 sub 需是 v 的一个连续子数组，并且 v 的成员需递归地包含 sub 的对应成员。
+func CheckContains(v *V, sub any, inPath ...any) error {
+	if v == nil {
+		return mismatchErrorf(nil, nil, "nil value")
+	}
+
+	exp, err := newExpectedSubset(sub)
+	if err != nil {
+		return mismatchErrorf(nil, nil, "invalid subset: %v", err)
+	}
+
+	var path KeyPath
+	for i, p := range inPath {
+		if s, err := intfToString(p); err == nil {
+			path = appendKeyPath(path, stringKey(s))
+		} else if n, err := intfToInt(p); err == nil {
+			path = appendKeyPath(path, intKey(n))
+		} else {
+			return mismatchErrorf(path, nil, "invalid path parameter %v", inPath[i])
+		}
+	}
+
+	if len(inPath) > 0 {
+		var err error
+		v, err = v.Get(inPath[0], inPath[1:]...)
+		if err != nil {
+			return mismatchErrorf(path, nil, "%v", err)
+		}
+	}
+
+	if err := exp.check(v, exp.v, path, nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+func appendKeyPath(path KeyPath, key Key) KeyPath {
+	res := make(KeyPath, 0, len(path)+1)
+	res = append(res, path...)
+	return append(res, &key)
+}
+
+// expectedSubset is an imported subset parameter. Each matcher in the parameter is imported as a placeholder
+// string value, which is bound to the matcher here. Placeholders never leave the subset, so they could not be
+// copied or modified.
+type expectedSubset struct {
+	v        *V
+	matchers map[*V]*Matcher
+}
+
+var typeOfMatcher = reflect.TypeOf((*Matcher)(nil))
+
+func newExpectedSubset(sub any) (*expectedSubset, error) {
+	exp := &expectedSubset{
+		matchers: map[*V]*Matcher{},
+	}
+	toV := func(rv reflect.Value) (*V, error) {
+		m := rv.Interface().(*Matcher)
+		placeholder := NewString(m.desc)
+		exp.matchers[placeholder] = m
+		return placeholder, nil
+	}
+
+	v, err := Import(sub, OptTypeConverter(typeOfMatcher, toV, nil))
+	if err != nil {
+		return nil, err
+	}
+	exp.v = v
+	return exp, nil
+}
+
+func (exp *expectedSubset) check(v, sub *V, path, subPath KeyPath) *MismatchError {
+	if m, exist := exp.matchers[sub]; exist {
+		if err := m.m.match(v, path); err != nil {
+			err.SubKeyPath = subPath
+			return err
+		}
+		return nil
+	}
+
+	if v.valueType != sub.valueType {
+		return mismatchErrorf(path, subPath, "expected %v, got %v", sub.valueType, v.valueType)
+	}
+
+	switch v.valueType {
+	default:
+		if !v.Equal(sub) {
+			return mismatchErrorf(
+				path, subPath, "expected %s, got %s", sub.MustMarshalString(), v.MustMarshalString(),
+			)
+		}
+		return nil
+	case Object:
+		return exp.objectContains(v, sub, path, subPath)
+	case Array:
+		return exp.arrayContains(v, sub, path, subPath)
+	}
+}
+
+func (exp *expectedSubset) objectContains(v, sub *V, path, subPath KeyPath) (err *MismatchError) {
+	sub.RangeObjectsBySetSequence(func(k string, subChild *V) bool {
+		childPath := appendKeyPath(path, stringKey(k))
+		subChildPath := appendKeyPath(subPath, stringKey(k))
+		child, exist := v.getFromObjectChildren(false, k)
+		if !exist {
+			err = mismatchErrorf(childPath, subChildPath, "key not found")
+			return false
+		}
+		err = exp.check(child, subChild, childPath, subChildPath)
+		return err == nil
+	})
+	return
+}
+
+func (exp *expectedSubset) arrayContains(v, sub *V, path, subPath KeyPath) *MismatchError {
+	lenV, lenSub := len(v.children.arr), len(sub.children.arr)
+
+	if lenSub == 0 {
+		return nil
+	}
+	if lenSub > lenV {
+		return mismatchErrorf(path, subPath, "expected at least %d elements, got %d", lenSub, lenV)
+	}
+	if lenV == lenSub {
+		return exp.subArrayContainedAt(v, sub, 0, path, subPath)
+	}
+
+	for start := 0; start <= lenV-lenSub; start++ {
+		if exp.subArrayContainedAt(v, sub, start, path, subPath) == nil {
+			return nil
+		}
+	}
+	return mismatchErrorf(path, subPath, "sub-array %s not found", sub.MustMarshalString())
+}
+
+func (exp *expectedSubset) subArrayContainedAt(v, sub *V, start int, path, subPath KeyPath) *MismatchError {
+	for i, subChild := range sub.children.arr {
+		err := exp.check(
+			v.children.arr[start+i], subChild, appendKeyPath(path, intKey(start+i)), appendKeyPath(subPath, intKey(i)),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------------- matchers ----------------
+
+// Matcher is generated by MatchXxx() functions, and is used in expected subset of Contains() and CheckContains().
+// Matchers are only recognized in the sub parameter of these functions, including values of Go maps, slices and
+// structs in it. Please note that a *V cannot hold matchers, since Import() and New() convert them to plain
+// strings, which are the descriptions of them.
+//
+// Matcher 由 MatchXxx() 函数生成，用在 Contains() 和 CheckContains() 的预期子集中。匹配器只有在这些函数的 sub 参数中，
+// 包括其中的 Go map、切片和结构体的值中，才会被识别。请注意 *V 无法持有匹配器，因为 Import() 和 New() 会将其转换为普通字符串，
+// 也就是匹配器的描述。
+type Matcher struct {
+	desc string
+	m    valueMatcher
+}
+
+// String returns the description of the matcher.
+//
+// String 返回匹配器的描述。
+func (m *Matcher) String() string {
+	return m.desc
+}
+
+// MarshalJSON implements json.Marshaler, marshaling the matcher as a string of its description.
+//
+// MarshalJSON 实现 json.Marshaler 接口，将匹配器序列化为其描述字符串。
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	return NewString(m.desc).Marshal()
+}
+
+type valueMatcher interface {
+	match(v *V, path KeyPath) *MismatchError
+}
+
+// MatchAny returns a matcher used in expected subset of Contains() and CheckContains(), which matches
+// any existing value. The returned matcher is marshaled as string "$any".
+//
+// MatchAny 返回一个用于 Contains() 和 CheckContains() 预期子集中的匹配器，匹配任意存在的值。返回的匹配器序列化后为字符串 "$any"。
+func MatchAny() *Matcher {
+	return &Matcher{desc: "$any", m: matchAny{}}
+}
+
+type matchAny struct{}
+
+func (matchAny) match(v *V, path KeyPath) *MismatchError {
+	if v.valueType == NotExist {
+		return mismatchErrorf(path, nil, "value not exist")
+	}
+	return nil
+}
+
+// MatchType returns a matcher used in expected subset of Contains() and CheckContains(), which matches any
+// value of given type.
+//
+// MatchType 返回一个用于 Contains() 和 CheckContains() 预期子集中的匹配器，匹配指定类型的任意值。
+func MatchType(t ValueType) *Matcher {
+	return &Matcher{desc: fmt.Sprintf("$any %v", t), m: matchType(t)}
+}
+
+type matchType ValueType
+
+func (m matchType) match(v *V, path KeyPath) *MismatchError {
+	if v.valueType != ValueType(m) {
+		return mismatchErrorf(path, nil, "expected any %v, got %v", ValueType(m), v.valueType)
+	}
+	return nil
+}
+
+// MatchRegexp returns a matcher used in expected subset of Contains() and CheckContains(), which matches
+// string values matching given regular expression. It panics if the expression cannot be parsed, just like
+// regexp.MustCompile() does.
+//
+// MatchRegexp 返回一个用于 Contains() 和 CheckContains() 预期子集中的匹配器，匹配符合给定正则表达式的字符串值。
+// 与 regexp.MustCompile() 一样，如果正则表达式无法解析，则会 panic。
+func MatchRegexp(expr string) *Matcher {
+	re := regexp.MustCompile(expr)
+	return &Matcher{desc: fmt.Sprintf("$string matching /%s/", expr), m: matchRegexp{re: re}}
+}
+
+type matchRegexp struct {
+	re *regexp.Regexp
+}
+
+func (m matchRegexp) match(v *V, path KeyPath) *MismatchError {
+	if v.valueType != String {
+		return mismatchErrorf(path, nil, "expected string, got %v", v.valueType)
+	}
+	if !m.re.MatchString(v.valueStr) {
+		return mismatchErrorf(path, nil, "string %q does not match /%s/", v.valueStr, m.re.String())
+	}
+	return nil
+}
+
+// MatchNumberRange returns a matcher used in expected subset of Contains() and CheckContains(), which matches
+// number values in range [min, max].
+//
+// MatchNumberRange 返回一个用于 Contains() 和 CheckContains() 预期子集中的匹配器，匹配在 [min, max] 范围内的数字值。
+func MatchNumberRange(min, max float64) *Matcher {
+	desc := fmt.Sprintf("$number in [%v, %v]", min, max)
+	return &Matcher{desc: desc, m: matchNumberRange{min: min, max: max}}
+}
+
+type matchNumberRange struct {
+	min, max float64
+}
+
+func (m matchNumberRange) match(v *V, path KeyPath) *MismatchError {
+	if v.valueType != Number {
+		return mismatchErrorf(path, nil, "expected number, got %v", v.valueType)
+	}
+	if f := v.Float64(); f < m.min || f > m.max {
+		return mismatchErrorf(path, nil, "number %v out of range [%v, %v]", v, m.min, m.max)
+	}
+	return nil
+}
+
+// MatchArrayContaining returns a matcher used in expected subset of Contains() and CheckContains(), which
+// matches arrays having at least one element anywhere containing given subset. The elem parameter can be
+// any type, including other matchers. It panics if elem cannot be imported.
+//
+// MatchArrayContaining 返回一个用于 Contains() 和 CheckContains() 预期子集中的匹配器，匹配至少有一个成员（任意位置）
+// 包含给定子集的数组。elem 参数可以是任意类型，包括其他匹配器。如果 elem 无法导入，则会 panic。
+func MatchArrayContaining(elem any) *Matcher {
+	exp, err := newExpectedSubset(elem)
+	if err != nil {
+		panic(err)
+	}
+	desc := fmt.Sprintf("$array containing %s", exp.v.MustMarshalString())
+	return &Matcher{desc: desc, m: matchArrayContaining{elem: exp}}
+}
+
+type matchArrayContaining struct {
+	elem *expectedSubset
+}
+
+func (m matchArrayContaining) match(v *V, path KeyPath) *MismatchError {
+	if v.valueType != Array {
+		return mismatchErrorf(path, nil, "expected array, got %v", v.valueType)
+	}
+	for i, child := range v.children.arr {
+		if m.elem.check(child, m.elem.v, appendKeyPath(path, intKey(i)), nil) == nil {
+			return nil
+		}
+	}
+	return mismatchErrorf(path, nil, "no element contains %s", m.elem.v.MustMarshalString())
+}
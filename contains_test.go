@@ -0,0 +1,162 @@
+package jsonvalue
+
+import (
+	"errors"
+	"testing"
+)
+
+func testContains(t *testing.T) {
+	cv("general subset", func() { testContainsGeneral(t) })
+	cv("matchers", func() { testContainsMatchers(t) })
+	cv("mismatch KeyPath", func() { testContainsMismatchKeyPath(t) })
+}
+
+func testContainsGeneral(t *testing.T) {
+	type P = []any
+
+	f := func(res bool, vStr string, path P, subStr string) {
+		v := MustUnmarshalString(vStr)
+		sub := MustUnmarshalString(subStr)
+		so(Contains(v, sub, path...), eq, res)
+	}
+
+	f(true, `[1,2,3,4]`, nil, `[2,3]`)
+	f(false, `[1,2,3,4]`, nil, `[1,3]`)
+	f(true, `{"num":1234,"str":"Hello"}`, nil, `{"num":1234.00}`)
+	f(false, `{"num":1234,"str":"Hello"}`, nil, `{"num":1234,"str":"hello"}`)
+
+	f(true, `{}`, nil, `{}`)
+	f(false, `{"obj":{}}`, nil, `{"Obj":{}}`)
+	f(false, `{"a":2}`, nil, `{"a":3}`)
+	f(false, `{"a":1}`, nil, `{"a":2, "obj":null}`)
+	f(true, `{"a":[2,2,3]}`, nil, `{"a":[2,3]}`)
+	f(true, `{"a":[2,3], "obj":{"a":{"b":23}}}`, nil, `{"obj":{"a":{"b":23}}}`)
+	f(true, `{"a":[2,2,3]}`, P{"a", 2}, `3`)
+	f(false, `{"a":[2,2,3]}`, P{"a"}, `2`)
+	f(true, `{"a":[{}, {"b":22,"c":222}, 23, "hello"]}`, P{"a", 1}, `{"c":222}`)
+	f(false, `{"a":[{}, {"b":22,"c":222}, 23, "hello"]}`, P{"a", 4}, `22`)
+
+	// elements of arrays are checked recursively
+	f(true, `[{"id":1,"name":"A"},{"id":2,"name":"B"}]`, nil, `[{"id":2}]`)
+
+	so(Contains(nil, 1), isFalse)
+	so(Contains(NewInt(1), make(chan int)), isFalse)
+	so(Contains(NewObject(), NewObject(), 1.5), isFalse)
+
+	// Go types as subset
+	v := MustUnmarshalString(`{"list":[1,2,3],"name":"Andrew"}`)
+	so(Contains(v, M{"name": "Andrew"}), isTrue)
+	so(Contains(v, []int{2, 3}, "list"), isTrue)
+}
+
+func testContainsMatchers(t *testing.T) {
+	v := MustUnmarshalString(`{
+		"id": "a1b2c3",
+		"count": 42,
+		"price": 12.5,
+		"tags": ["x", "y", "z"],
+		"items": [{"sku":"A","qty":1},{"sku":"B","qty":3}],
+		"deleted": null
+	}`)
+
+	cv("any", func() {
+		so(Contains(v, M{"id": MatchAny(), "deleted": MatchAny()}), isTrue)
+		so(Contains(v, M{"not_exist": MatchAny()}), isFalse)
+
+		so(Contains(v, MatchAny(), "id"), isTrue)
+
+		// matchers imported into *V are plain strings
+		sub := New(M{"id": MatchAny()})
+		so(sub.MustGet("id").String(), eq, "$any")
+		so(Contains(v, sub), isFalse)
+		so(Contains(NewString("$any"), sub.MustGet("id")), isTrue)
+	})
+
+	cv("type", func() {
+		so(Contains(v, M{"id": MatchType(String), "count": MatchType(Number)}), isTrue)
+		so(Contains(v, M{"id": MatchType(Number)}), isFalse)
+	})
+
+	cv("regexp", func() {
+		so(Contains(v, M{"id": MatchRegexp(`^[a-z0-9]{6}$`)}), isTrue)
+		so(Contains(v, M{"id": MatchRegexp(`^\d+$`)}), isFalse)
+		so(Contains(v, M{"count": MatchRegexp(`42`)}), isFalse)
+		so(func() { MatchRegexp(`(`) }, shouldPanic)
+	})
+
+	cv("number range", func() {
+		so(Contains(v, M{"count": MatchNumberRange(0, 100), "price": MatchNumberRange(12.5, 12.5)}), isTrue)
+		so(Contains(v, M{"count": MatchNumberRange(43, 100)}), isFalse)
+		so(Contains(v, M{"id": MatchNumberRange(0, 100)}), isFalse)
+	})
+
+	cv("array containing", func() {
+		so(Contains(v, M{"tags": MatchArrayContaining("z")}), isTrue)
+		so(Contains(v, M{"tags": MatchArrayContaining("w")}), isFalse)
+		so(Contains(v, M{"id": MatchArrayContaining("a")}), isFalse)
+
+		so(Contains(v, M{"items": MatchArrayContaining(M{"sku": "B", "qty": MatchNumberRange(2, 5)})}), isTrue)
+		so(Contains(v, M{"items": MatchArrayContaining(M{"sku": "A", "qty": MatchNumberRange(2, 5)})}), isFalse)
+		so(func() { MatchArrayContaining(make(chan int)) }, shouldPanic)
+	})
+
+	cv("matchers in arrays", func() {
+		so(Contains(v, []any{MatchType(String), MatchRegexp(`^z$`)}, "tags"), isTrue)
+		so(Contains(v, []*Matcher{MatchType(String), MatchRegexp(`^x$`)}, "tags"), isFalse)
+	})
+
+	cv("matchers in structs", func() {
+		type item struct {
+			SKU string   `json:"sku"`
+			Qty *Matcher `json:"qty"`
+		}
+		so(Contains(v, M{"items": []item{{SKU: "B", Qty: MatchNumberRange(2, 5)}}}), isTrue)
+		so(Contains(v, M{"items": []item{{SKU: "A", Qty: MatchNumberRange(2, 5)}}}), isFalse)
+	})
+
+	cv("marshaling", func() {
+		sub := M{"a": MatchAny(), "b": MatchType(Number)}
+		s := New(sub).MustMarshalString(OptDefaultStringSequence())
+		so(s, eq, `{"a":"$any","b":"$any number"}`)
+		so(MatchRegexp(`^a$`).String(), eq, `$string matching /^a$/`)
+	})
+}
+
+func testContainsMismatchKeyPath(t *testing.T) {
+	v := MustUnmarshalString(`{"data":{"items":[{"price":1},{"price":"2"}]}}`)
+
+	err := CheckContains(v, M{"data": M{"items": []any{M{"price": 1}, M{"price": MatchType(Number)}}}})
+	so(err, isErr)
+
+	var mismatch *MismatchError
+	so(errors.As(err, &mismatch), isTrue)
+	so(mismatch.KeyPath.String(), eq, `["data" "items" 1 "price"]`)
+	t.Log(err)
+
+	err = CheckContains(v, M{"price": 1}, "data", "items", 0)
+	so(err, isNil)
+
+	err = CheckContains(v, M{"price": 2}, "data", "items", 0)
+	so(errors.As(err, &mismatch), isTrue)
+	so(mismatch.KeyPath.String(), eq, `["data" "items" 0 "price"]`)
+
+	err = CheckContains(v, M{"cost": 2}, "data", "items", 0)
+	so(errors.As(err, &mismatch), isTrue)
+	so(mismatch.KeyPath.String(), eq, `["data" "items" 0 "cost"]`)
+	so(mismatch.Reason, eq, "key not found")
+
+	err = CheckContains(v, M{}, "data", "elements")
+	so(errors.As(err, &mismatch), isTrue)
+	so(mismatch.KeyPath.String(), eq, `["data" "elements"]`)
+	// paths in sub
+	v = MustUnmarshalString(`{"list":[0,{"a":2}]}`)
+	err = CheckContains(v, []any{0, M{"a": MatchType(String)}}, "list")
+	so(errors.As(err, &mismatch), isTrue)
+	so(mismatch.KeyPath.String(), eq, `["list" 1 "a"]`)
+	so(mismatch.SubKeyPath.String(), eq, `[1 "a"]`)
+
+	err = CheckContains(v, M{"list": []any{M{"a": 3}}})
+	so(errors.As(err, &mismatch), isTrue)
+	so(mismatch.KeyPath.String(), eq, `["list"]`)
+	so(mismatch.SubKeyPath.String(), eq, `["list"]`)
+}
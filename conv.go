@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"reflect"
+	"strconv"
 )
 
 func formatBool(b bool) string {
@@ -209,3 +210,78 @@ func intfToString(v any) (s string, err error) {
 
 // 	return
 // }
+
+// inferTextValue converts a text into a number or a boolean if possible, otherwise into a string. Numbers should be
+// written in JSON format, and the source text is kept.
+func inferTextValue(s string) *V {
+	switch s {
+	case "true":
+		return NewBool(true)
+	case "false":
+		return NewBool(false)
+	}
+	if IsNumberText(s) {
+		if v, end, reachEnd, err := iter(s).parseNumber(0); err == nil && reachEnd && end == len(s) {
+			return v
+		}
+	}
+	return NewString(s)
+}
+
+// IsNumberText tells whether s is a number literal in JSON format, which matches regular expression
+// -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?. Texts with spaces, leading zeros or "+" signs like " 42", "01234"
+// or "+86", as well as "NaN" and "Inf", are not number literals.
+//
+// IsNumberText 判断 s 是否为 JSON 格式的数字字面量，即是否匹配正则表达式 -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?。
+// 带有空格、前导零或者 "+" 号的文本 (如 " 42"、"01234"、"+86"), 以及 "NaN" 和 "Inf" 均不是数字字面量。
+func IsNumberText(s string) bool {
+	i := 0
+	digits := func() int {
+		start := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		return i - start
+	}
+
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	if n := digits(); n == 0 || (n > 1 && s[i-n] == '0') {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if digits() == 0 {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			i++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+// scalarText returns text representation of a scalar value for text-based formats, where null is represented by an
+// empty string, and number source text is kept.
+func scalarText(v *V) string {
+	switch v.valueType {
+	default:
+		return ""
+	case String:
+		return v.valueStr
+	case Boolean:
+		return formatBool(v.valueBool)
+	case Number:
+		if len(v.srcByte) > 0 {
+			return string(v.srcByte)
+		}
+		return strconv.FormatFloat(v.num.f64, 'g', -1, 64)
+	}
+}
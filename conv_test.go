@@ -0,0 +1,20 @@
+package jsonvalue
+
+import (
+	"testing"
+)
+
+func testConv(t *testing.T) {
+	cv("IsNumberText", func() { testIsNumberText(t) })
+}
+
+func testIsNumberText(t *testing.T) {
+	for _, s := range []string{"0", "-0", "42", "-1.5", "1e3", "1E+3", "0.5e-7", "18446744073709551616"} {
+		so(IsNumberText(s), isTrue)
+	}
+	for _, s := range []string{
+		"", "-", " 42", "42 ", "01234", "+86", "1.", ".5", "1e", "1e+", "0x1f", "NaN", "Inf", "-Inf", "1_000",
+	} {
+		so(IsNumberText(s), isFalse)
+	}
+}
@@ -0,0 +1,135 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+type typeConverter struct {
+	toV   func(reflect.Value) (*V, error)
+	fromV func(*V, reflect.Value) error
+}
+
+var (
+	typeConverterLock     sync.Mutex
+	typeConverterRegistry atomic.Value // map[reflect.Type]typeConverter, copy on write
+)
+
+func init() {
+	typeConverterRegistry.Store(map[reflect.Type]typeConverter{})
+}
+
+// RegisterTypeConverter registers conversions for a given type, which is useful for third-party types those cannot
+// be modified to implement json.Marshaler or json.Unmarshaler. Import(), New(), Set(), Append(), Insert(), Add() and
+// Export() consult registered converters before json.Marshaler, json.Unmarshaler and reflection. The type should be
+// matched exactly, and nil pointers are always converted to or from null without invoking converters.
+//
+// toV is used when converting a value of type t into *V, while fromV is used when exporting a *V into a settable
+// value of type t. Either of them could be nil, which means falling back to the default process in that direction.
+// If both are nil, the converter of the type will be unregistered.
+//
+// Registered converters are global. Please use OptTypeConverter() for converters in one function call.
+//
+// RegisterTypeConverter 为指定类型注册转换函数，适用于无法修改以实现 json.Marshaler 或 json.Unmarshaler 的第三方类型。
+// Import()、New()、Set()、Append()、Insert()、Add() 和 Export() 会先于 json.Marshaler、json.Unmarshaler 以及反射逻辑使用
+// 已注册的转换函数。类型需要完全匹配，并且 nil 指针总是与 null 互相转换，而不会调用转换函数。
+//
+// toV 用于将类型 t 的值转换为 *V, fromV 用于将 *V 导出到一个类型为 t 的可设置的值中。两者均可以为 nil, 表示该方向上使用默认的
+// 处理逻辑。如果两者均为 nil, 则注销该类型的转换函数。
+//
+// 注册的转换函数是全局生效的。如果仅需要在单次函数调用中生效，请使用 OptTypeConverter()。
+func RegisterTypeConverter(
+	t reflect.Type, toV func(reflect.Value) (*V, error), fromV func(*V, reflect.Value) error,
+) {
+	if t == nil {
+		return
+	}
+
+	typeConverterLock.Lock()
+	defer typeConverterLock.Unlock()
+
+	prev := typeConverterRegistry.Load().(map[reflect.Type]typeConverter)
+	m := make(map[reflect.Type]typeConverter, len(prev)+1)
+	for k, c := range prev {
+		m[k] = c
+	}
+	if toV == nil && fromV == nil {
+		delete(m, t)
+	} else {
+		m[t] = typeConverter{toV: toV, fromV: fromV}
+	}
+	typeConverterRegistry.Store(m)
+}
+
+func (ex ext) findToVConverter(t reflect.Type) func(reflect.Value) (*V, error) {
+	if c, exist := ex.converters[t]; exist && c.toV != nil {
+		return c.toV
+	}
+	if c, exist := typeConverterRegistry.Load().(map[reflect.Type]typeConverter)[t]; exist {
+		return c.toV
+	}
+	return nil
+}
+
+func (ex ext) findFromVConverter(t reflect.Type) func(*V, reflect.Value) error {
+	if c, exist := ex.converters[t]; exist && c.fromV != nil {
+		return c.fromV
+	}
+	if c, exist := typeConverterRegistry.Load().(map[reflect.Type]typeConverter)[t]; exist {
+		return c.fromV
+	}
+	return nil
+}
+
+// validateConverterAndReturnParser returns parser by registered type converters. Nil will be returned if no
+// converter matches.
+func validateConverterAndReturnParser(v reflect.Value, ex ext) parserFunc {
+	if !v.IsValid() || v.Kind() == reflect.Interface {
+		return nil
+	}
+
+	toV := ex.findToVConverter(v.Type())
+	if toV == nil {
+		return nil
+	}
+
+	return func(v reflect.Value, ex ext) (*V, error) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return parseNullValue(v, ex)
+		}
+		if ex.shouldOmitEmpty() && isEmptyValue(v) {
+			return nil, nil
+		}
+
+		res, err := toV(v)
+		if err != nil {
+			return nil, fmt.Errorf("jsonvalue: converting type %v error: %w", v.Type(), err)
+		}
+		if res == nil {
+			return NewNull(), nil
+		}
+		return res, nil
+	}
+}
+
+// validateConverterAndReturnExporter returns exporter by registered type converters. Nil will be returned if no
+// converter matches.
+func validateConverterAndReturnExporter(dst reflect.Value, ex ext) exportFunc {
+	fromV := ex.findFromVConverter(dst.Type())
+	if fromV == nil {
+		return nil
+	}
+
+	return func(v *V, dst reflect.Value, _ ext) error {
+		if v.valueType == Null && dst.Kind() == reflect.Ptr {
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		if err := fromV(v, dst); err != nil {
+			return fmt.Errorf("jsonvalue: converting to type %v error: %w", dst.Type(), err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,187 @@
+package jsonvalue
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testTypeConverter(t *testing.T) {
+	cv("registered converter", func() { testRegisteredTypeConverter(t) })
+	cv("per-call converter", func() { testOptTypeConverter(t) })
+	cv("converter errors", func() { testTypeConverterErrors(t) })
+}
+
+// converterTestPoint simulates a third-party type which cannot be modified
+type converterTestPoint struct {
+	X, Y int
+}
+
+var typeOfConverterTestPoint = reflect.TypeOf(converterTestPoint{})
+
+func converterTestPointToV(v reflect.Value) (*V, error) {
+	p := v.Interface().(converterTestPoint)
+	return NewString(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func converterTestPointFromV(v *V, dst reflect.Value) error {
+	p := converterTestPoint{}
+	if _, err := fmt.Sscanf(v.String(), "%d,%d", &p.X, &p.Y); err != nil {
+		return err
+	}
+	dst.Set(reflect.ValueOf(p))
+	return nil
+}
+
+type converterTestShape struct {
+	Name   string               `json:"name"`
+	Center converterTestPoint   `json:"center"`
+	Ptr    *converterTestPoint  `json:"ptr"`
+	Points []converterTestPoint `json:"points"`
+	Map    map[string]any       `json:"map"`
+	Nil    *converterTestPoint  `json:"nil,omitempty"`
+}
+
+func testRegisteredTypeConverter(t *testing.T) {
+	RegisterTypeConverter(typeOfConverterTestPoint, converterTestPointToV, converterTestPointFromV)
+	defer RegisterTypeConverter(typeOfConverterTestPoint, nil, nil)
+
+	shape := converterTestShape{
+		Name:   "triangle",
+		Center: converterTestPoint{1, 2},
+		Ptr:    &converterTestPoint{3, 4},
+		Points: []converterTestPoint{{5, 6}, {7, 8}},
+		Map:    map[string]any{"p": converterTestPoint{9, 10}},
+	}
+
+	v, err := Import(shape)
+	so(err, isNil)
+	so(v.MustGet("center").String(), eq, "1,2")
+	so(v.MustGet("ptr").String(), eq, "3,4")
+	so(v.MustGet("points", 1).String(), eq, "7,8")
+	so(v.MustGet("map", "p").String(), eq, "9,10")
+	so(v.MustGet("nil").ValueType(), eq, NotExist)
+
+	// New, Set, Append
+	so(New(converterTestPoint{1, 1}).String(), eq, "1,1")
+	o := NewObject()
+	o.Set(converterTestPoint{2, 2}).At("p")
+	so(o.MustGet("p").String(), eq, "2,2")
+	a := NewArray()
+	a.Append(&converterTestPoint{3, 3}).InTheEnd()
+	so(a.MustGet(0).String(), eq, "3,3")
+
+	// Export
+	got := converterTestShape{}
+	err = v.Export(&got)
+	so(err, isNil)
+	so(got.Center == shape.Center, isTrue)
+	so(*got.Ptr == *shape.Ptr, isTrue)
+	so(reflect.DeepEqual(got.Points, shape.Points), isTrue)
+	so(got.Map["p"], eq, "9,10")
+	so(got.Nil, isNil)
+
+	// unregistered
+	RegisterTypeConverter(typeOfConverterTestPoint, nil, nil)
+	so(New(converterTestPoint{1, 1}).IsObject(), isTrue)
+
+	// single direction
+	RegisterTypeConverter(typeOfConverterTestPoint, nil, converterTestPointFromV)
+	so(New(converterTestPoint{1, 1}).IsObject(), isTrue)
+	p := converterTestPoint{}
+	err = NewString("4,5").Export(&p)
+	so(err, isNil)
+	so(p.Y, eq, 5)
+}
+
+func testOptTypeConverter(t *testing.T) {
+	toUpper := func(v reflect.Value) (*V, error) {
+		return NewString(strings.ToUpper(v.String())), nil
+	}
+	fromUpper := func(v *V, dst reflect.Value) error {
+		dst.SetString(strings.ToLower(v.String()))
+		return nil
+	}
+	opt := OptTypeConverter(reflect.TypeOf(""), toUpper, fromUpper)
+
+	st := struct {
+		A string            `json:"a"`
+		B []string          `json:"b"`
+		C map[string]string `json:"c"`
+	}{"a", []string{"b"}, map[string]string{"k": "c"}}
+
+	v, err := Import(st, opt)
+	so(err, isNil)
+	so(v.MustMarshalString(OptDefaultStringSequence()), eq, `{"a":"A","b":["B"],"c":{"k":"C"}}`)
+
+	// not affecting other calls
+	v2, err := Import(st)
+	so(err, isNil)
+	so(v2.MustMarshalString(OptDefaultStringSequence()), eq, `{"a":"a","b":["b"],"c":{"k":"c"}}`)
+
+	s := ""
+	err = NewString("HELLO").Export(&s, opt)
+	so(err, isNil)
+	so(s, eq, "hello")
+
+	// priority over registered ones
+	RegisterTypeConverter(typeOfConverterTestPoint, converterTestPointToV, converterTestPointFromV)
+	defer RegisterTypeConverter(typeOfConverterTestPoint, nil, nil)
+
+	v, err = Import(converterTestPoint{1, 2}, OptTypeConverter(typeOfConverterTestPoint, func(reflect.Value) (*V, error) {
+		return NewInt(12), nil
+	}, nil))
+	so(err, isNil)
+	so(v.Int(), eq, 12)
+
+	// not affecting default options
+	prevDefault := defaultMarshalOption
+	defer func() { defaultMarshalOption = prevDefault }()
+	SetDefaultMarshalOptions(OptTypeConverter(reflect.TypeOf(0), func(reflect.Value) (*V, error) {
+		return NewString("int"), nil
+	}, nil))
+
+	v, err = Import([]any{1, "s"}, opt)
+	so(err, isNil)
+	so(v.MustMarshalString(), eq, `["int","S"]`)
+	so(New(1).String(), eq, "int")
+	so(New("s").String(), eq, "s")
+}
+
+func testTypeConverterErrors(t *testing.T) {
+	e := errors.New("converter error")
+
+	opt := OptTypeConverter(typeOfConverterTestPoint, func(reflect.Value) (*V, error) {
+		return nil, e
+	}, func(*V, reflect.Value) error {
+		return e
+	})
+
+	_, err := Import(converterTestPoint{}, opt)
+	so(err, isErr)
+	so(errors.Is(err, e), isTrue)
+
+	v, err := Import(converterTestPoint{}, OptTypeConverter(typeOfConverterTestPoint, func(reflect.Value) (*V, error) {
+		return nil, nil
+	}, nil))
+	so(err, isNil)
+	so(v.IsNull(), isTrue)
+
+	p := converterTestPoint{}
+	err = NewString("1,2").Export(&p, opt)
+	so(err, isErr)
+	so(errors.Is(err, e), isTrue)
+
+	// nil pointers are not passed to converters
+	var ptr *converterTestPoint
+	v, err = Import(ptr, opt)
+	so(err, isNil)
+	so(v.IsNull(), isTrue)
+
+	ptr = &converterTestPoint{}
+	err = NewNull().Export(&ptr, opt)
+	so(err, isNil)
+	so(ptr, isNil)
+}
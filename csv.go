@@ -0,0 +1,447 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVArrayEncoding defines how arrays nested in rows are encoded into CSV cells in ToCSV() and decoded in FromCSV().
+//
+// CSVArrayEncoding 定义 ToCSV() 中嵌套在行中的数组如何编码到 CSV 单元格中，以及在 FromCSV() 中如何解码。
+type CSVArrayEncoding uint8
+
+const (
+	// CSVArrayJSON is the default encoding, where an array is written into one cell as JSON text, such as
+	// {"tags": ["a", "b"]} ==> tags: ["a","b"]
+	//
+	// CSVArrayJSON 为默认编码方式，数组以 JSON 文本的形式写入一个单元格，如 {"tags": ["a", "b"]} ==> tags: ["a","b"]
+	CSVArrayJSON CSVArrayEncoding = 0
+	// CSVArrayIndex indicates that arrays are flattened like objects, with indexes in brackets, such as
+	// {"tags": ["a", "b"]} ==> tags[0]: a, tags[1]: b
+	//
+	// CSVArrayIndex 表示数组与 object 一样被展开，并以方括号表示下标，如 {"tags": ["a", "b"]} ==> tags[0]: a, tags[1]: b
+	CSVArrayIndex CSVArrayEncoding = 1
+	// CSVArrayJoin indicates that elements of an array are joined into one cell with the separator specified by
+	// OptCSVArraySeparator(), such as {"tags": ["a", "b"]} ==> tags: a;b. Non-scalar elements are written as JSON
+	// text. This encoding is lossy and is not recognized by FromCSV().
+	//
+	// CSVArrayJoin 表示数组成员以 OptCSVArraySeparator() 指定的分隔符连接后写入一个单元格，如 {"tags": ["a", "b"]} ==> tags: a;b。
+	// 非标量成员以 JSON 文本写入。该编码方式是有损的，FromCSV() 无法识别。
+	CSVArrayJoin CSVArrayEncoding = 2
+)
+
+// ToCSV writes an array of objects into w as CSV, one row per object. Nested objects are flattened, with keys
+// joined by ".", such as "a.b.c". Nested arrays are encoded as specified by OptCSVArrayEncoding(). Null values and
+// missing keys are written as empty cells, while empty objects and arrays are written as "{}" and "[]". Keys
+// containing "." may collide with flattened keys, such as {"b":{"c":1},"b.c":2}, and an error is returned then.
+//
+// Columns are the union of flattened keys of all rows, in set sequence by default, unless OptKeySequence() or
+// OptKeySequenceWithLessFunc() is given. Columns could also be specified explicitly by OptCSVColumns(). Use
+// OptCSVDelimiter('\t') for TSV, and OptCSVNoHeader() to omit the header line.
+//
+// ToCSV 将一个元素为 object 的数组以 CSV 格式写入 w, 每个 object 一行。嵌套的 object 被展开，key 以 "." 连接，如 "a.b.c"。嵌套的
+// 数组按照 OptCSVArrayEncoding() 的指定进行编码。null 值和不存在的 key 写为空单元格，空 object 和空数组则写为 "{}" 和 "[]"。
+// 带有 "." 的 key 可能与展开后的 key 冲突，如 {"b":{"c":1},"b.c":2}，此时返回错误。
+//
+// 各列为所有行展开后的 key 的并集，默认按照 set 顺序排列，除非指定了 OptKeySequence() 或 OptKeySequenceWithLessFunc()。也可以通过
+// OptCSVColumns() 显式指定各列。对于 TSV 请使用 OptCSVDelimiter('\t'), 使用 OptCSVNoHeader() 则不写入表头行。
+func (v *V) ToCSV(w io.Writer, opts ...Option) error {
+	if v == nil || v.valueType == NotExist {
+		return ErrValueUninitialized
+	}
+	if v.valueType != Array {
+		return ErrNotArrayValue
+	}
+
+	opt := combineOptions(opts)
+	if opt.MarshalLessFunc == nil && len(opt.MarshalKeySequence) == 0 {
+		opt.marshalBySetSequence = true
+	}
+	e := csvEncoder{opt: opt, jsonOpt: *opt}
+	e.jsonOpt.indent.enabled = false
+	if e.sep = opt.csvArraySeparator; e.sep == "" {
+		e.sep = ";"
+	}
+
+	rows := make([]map[string]string, 0, len(v.children.arr))
+	columns := opt.csvColumns
+	known := map[string]bool{}
+	for i, child := range v.children.arr {
+		if child.valueType != Object {
+			return fmt.Errorf("%w: row %d is %v, object expected", ErrTypeNotMatch, i, child.valueType)
+		}
+		var par *ParentInfo
+		if opt.MarshalLessFunc != nil {
+			par = v.newParentInfo(nil, intKey(i))
+		}
+		row := csvRow{cells: map[string]string{}}
+		if err := e.flattenObject(&row, par, "", child); err != nil {
+			return err
+		}
+		rows = append(rows, row.cells)
+		if len(opt.csvColumns) == 0 {
+			for _, k := range row.keys {
+				if !known[k] {
+					known[k] = true
+					columns = append(columns, k)
+				}
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if opt.csvDelimiter != 0 {
+		cw.Comma = opt.csvDelimiter
+	}
+	if !opt.csvNoHeader {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = row[col]
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// FromCSV reads CSV from r and converts each row into an object, returning an array of them. Column names are read
+// from the header line, or given by OptCSVColumns(). Column names with "." are unflattened into nested objects, and
+// with CSVArrayIndex encoding, names with indexes in brackets such as "tags[0]" are unflattened into arrays, where
+// indexes should be less than the count of columns.
+//
+// Numbers and booleans are inferred from cell texts. Cells starting with "[" or "{" which are valid JSON are decoded
+// as JSON, and empty cells are omitted. Use OptCSVDelimiter('\t') for TSV.
+//
+// Conflicting columns, such as duplicated ones, "a" and "a.b", or "a[0]" and "a.b", should not be both non-empty in
+// one row, otherwise an error is returned, regardless of the order of columns.
+//
+// FromCSV 从 r 中读取 CSV, 将每一行转换为一个 object, 并返回由它们组成的数组。列名从表头行读取，或通过 OptCSVColumns() 指定。带有
+// "." 的列名会被还原为嵌套的 object; 使用 CSVArrayIndex 编码方式时，带有方括号下标的列名如 "tags[0]" 会被还原为数组，下标需小于列数。
+//
+// 数字和布尔值会根据单元格文本自动推断。以 "[" 或 "{" 开头且为合法 JSON 的单元格按照 JSON 解析，空单元格则被忽略。对于 TSV 请使用
+// OptCSVDelimiter('\t')。
+//
+// 相互冲突的列，如重复的列、"a" 与 "a.b", 或 "a[0]" 与 "a.b", 在同一行中不能同时非空，否则返回错误，与列的顺序无关。
+func FromCSV(r io.Reader, opts ...Option) (*V, error) {
+	opt := combineOptions(opts)
+	cr := csv.NewReader(r)
+	if opt.csvDelimiter != 0 {
+		cr.Comma = opt.csvDelimiter
+	}
+	cr.FieldsPerRecord = -1
+
+	columns := opt.csvColumns
+	if !opt.csvNoHeader {
+		header, err := cr.Read()
+		if err == io.EOF {
+			return newArray(), nil
+		}
+		if err != nil {
+			return &V{}, csvError(err)
+		}
+		if len(columns) == 0 {
+			columns = append([]string{}, header...)
+		}
+	} else if len(columns) == 0 {
+		return &V{}, fmt.Errorf("%w: columns should be given by OptCSVColumns() without header", ErrNilParameter)
+	}
+
+	// Each array element takes at least one column, thus indexes should be less than count of columns. This also
+	// prevents huge allocations by untrusted headers.
+	paths := make([][]any, len(columns))
+	for i, col := range columns {
+		paths[i] = parseCSVColumn(col, opt.csvArrayEncoding == CSVArrayIndex)
+		for _, p := range paths[i] {
+			if n, isIndex := p.(int); isIndex && n >= len(columns) {
+				return &V{}, fmt.Errorf("%w: index %d in column %q is too large", ErrOutOfRange, n, col)
+			}
+		}
+	}
+
+	conflicts := csvColumnConflicts(paths)
+
+	arr := newArray()
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &V{}, csvError(err)
+		}
+		if len(record) > len(columns) {
+			return &V{}, fmt.Errorf("%w: CSV row %d has %d fields, more than %d columns", ErrRawBytesUnrecignized, row, len(record), len(columns))
+		}
+
+		obj := newObject()
+		for i, cell := range record {
+			if cell == "" {
+				continue
+			}
+			for _, j := range conflicts[i] {
+				if j < len(record) && record[j] != "" {
+					return &V{}, fmt.Errorf("%w: column %q conflicts with %q", ErrTypeNotMatch, columns[i], columns[j])
+				}
+			}
+			if err := setCSVCell(obj, paths[i], csvCellValue(cell)); err != nil {
+				return &V{}, fmt.Errorf("%w: column %q conflicts with other columns", err, columns[i])
+			}
+		}
+		arr.appendToArr(obj)
+	}
+	return arr, nil
+}
+
+func csvError(err error) error {
+	if _, ok := err.(*csv.ParseError); ok {
+		return fmt.Errorf("%w: CSV %v", ErrRawBytesUnrecignized, err)
+	}
+	return err
+}
+
+// ---------------- encoding ----------------
+
+type csvEncoder struct {
+	opt     *Opt
+	jsonOpt Opt
+	sep     string
+}
+
+type csvRow struct {
+	keys  []string
+	cells map[string]string
+}
+
+// add adds a cell into the row. Flattened keys could be duplicated, such as "b.c" of {"b":{"c":1},"b.c":2}, and
+// an error is returned instead of overwriting the cell.
+func (r *csvRow) add(k, cell string) error {
+	if _, exist := r.cells[k]; exist {
+		return fmt.Errorf("%w: duplicated column %q", ErrTypeNotMatch, k)
+	}
+	r.keys = append(r.keys, k)
+	r.cells[k] = cell
+	return nil
+}
+
+func (e *csvEncoder) flattenObject(row *csvRow, parentInfo *ParentInfo, prefix string, v *V) error {
+	keys, values := v.sortedObjectChildren(parentInfo, e.opt)
+	for i, k := range keys {
+		child := values[i]
+		if e.opt.OmitNull && child.valueType == Null {
+			continue
+		}
+		var par *ParentInfo
+		if e.opt.MarshalLessFunc != nil {
+			par = child.newParentInfo(parentInfo, stringKey(k))
+		}
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if err := e.flatten(row, par, k, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *csvEncoder) flatten(row *csvRow, parentInfo *ParentInfo, k string, v *V) error {
+	switch v.valueType {
+	default:
+		return row.add(k, scalarText(v))
+
+	case Object:
+		if len(v.children.object) == 0 {
+			return row.add(k, "{}")
+		}
+		return e.flattenObject(row, parentInfo, k, v)
+
+	case Array:
+		switch e.opt.csvArrayEncoding {
+		default:
+			s, err := e.marshalJSON(parentInfo, v)
+			if err != nil {
+				return err
+			}
+			return row.add(k, s)
+
+		case CSVArrayIndex:
+			if len(v.children.arr) == 0 {
+				return row.add(k, "[]")
+			}
+			for i, child := range v.children.arr {
+				var par *ParentInfo
+				if e.opt.MarshalLessFunc != nil {
+					par = v.newParentInfo(parentInfo, intKey(i))
+				}
+				if err := e.flatten(row, par, k+"["+strconv.Itoa(i)+"]", child); err != nil {
+					return err
+				}
+			}
+			return nil
+
+		case CSVArrayJoin:
+			parts := make([]string, 0, len(v.children.arr))
+			for i, child := range v.children.arr {
+				if child.valueType != Object && child.valueType != Array {
+					parts = append(parts, scalarText(child))
+					continue
+				}
+				var par *ParentInfo
+				if e.opt.MarshalLessFunc != nil {
+					par = v.newParentInfo(parentInfo, intKey(i))
+				}
+				s, err := e.marshalJSON(par, child)
+				if err != nil {
+					return err
+				}
+				parts = append(parts, s)
+			}
+			return row.add(k, strings.Join(parts, e.sep))
+		}
+	}
+}
+
+func (e *csvEncoder) marshalJSON(parentInfo *ParentInfo, v *V) (string, error) {
+	buf := bytes.Buffer{}
+	if err := v.marshalToBuffer(parentInfo, &buf, &e.jsonOpt); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ---------------- decoding ----------------
+
+// parseCSVColumn splits a column name like "a.b[0].c" into keys and indexes.
+func parseCSVColumn(col string, withIndex bool) []any {
+	var path []any
+	for _, seg := range strings.Split(col, ".") {
+		if !withIndex {
+			path = append(path, seg)
+			continue
+		}
+
+		var indexes []any
+		for strings.HasSuffix(seg, "]") {
+			i := strings.LastIndexByte(seg, '[')
+			if i < 0 || !isDecimalDigits(seg[i+1:len(seg)-1]) {
+				break
+			}
+			n, err := strconv.Atoi(seg[i+1 : len(seg)-1])
+			if err != nil {
+				break
+			}
+			indexes = append([]any{n}, indexes...)
+			seg = seg[:i]
+		}
+		path = append(path, seg)
+		path = append(path, indexes...)
+	}
+	return path
+}
+
+func csvCellValue(cell string) *V {
+	if c := cell[0]; c == '[' || c == '{' {
+		if v, err := UnmarshalString(cell); err == nil {
+			return v
+		}
+	}
+	return inferTextValue(cell)
+}
+
+// csvColumnConflicts returns indexes of conflicting columns of each column, which could not be both non-empty in one
+// row. Two columns conflict if one path is the prefix of the other, such as "a" and "a.b", or they are the same, or
+// an index and a key are used in the same place, such as "a[0]" and "a.b". As conflicts are determined by paths
+// only, the result does not depend on the order of columns.
+func csvColumnConflicts(paths [][]any) [][]int {
+	conflicts := make([][]int, len(paths))
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			if csvPathsConflict(paths[i], paths[j]) {
+				conflicts[i] = append(conflicts[i], j)
+				conflicts[j] = append(conflicts[j], i)
+			}
+		}
+	}
+	return conflicts
+}
+
+func csvPathsConflict(a, b []any) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		_, aIsIndex := a[i].(int)
+		_, bIsIndex := b[i].(int)
+		return aIsIndex != bIsIndex
+	}
+	return true
+}
+
+// setCSVCell sets a value into nested objects and arrays by path, creating them if not exist. Missing array elements
+// before the index are filled with null.
+func setCSVCell(v *V, path []any, value *V) error {
+	for i, p := range path {
+		last := i == len(path)-1
+		var next *V
+		if !last {
+			if _, isIndex := path[i+1].(int); isIndex {
+				next = newArray()
+			} else {
+				next = newObject()
+			}
+		}
+
+		switch p := p.(type) {
+		case string:
+			if v.valueType != Object {
+				return ErrTypeNotMatch
+			}
+			if last {
+				v.setToObjectChildren(p, value)
+				return nil
+			}
+			child, exist := v.children.object[p]
+			if !exist || child.v.valueType == Null {
+				v.setToObjectChildren(p, next)
+			} else {
+				next = child.v
+			}
+
+		case int:
+			if v.valueType != Array {
+				return ErrTypeNotMatch
+			}
+			for len(v.children.arr) < p {
+				v.appendToArr(NewNull())
+			}
+			if last {
+				if p < len(v.children.arr) {
+					v.children.arr[p] = value
+				} else {
+					v.appendToArr(value)
+				}
+				return nil
+			}
+			if p == len(v.children.arr) {
+				v.appendToArr(next)
+			} else if child := v.children.arr[p]; child.valueType == Null {
+				v.children.arr[p] = next
+			} else {
+				next = child
+			}
+		}
+		v = next
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testCSV(t *testing.T) {
+	cv("ToCSV", func() { testCSVToCSV(t) })
+	cv("array encodings", func() { testCSVArrayEncodings(t) })
+	cv("FromCSV", func() { testCSVFromCSV(t) })
+	cv("round trip", func() { testCSVRoundTrip(t) })
+	cv("errors", func() { testCSVErrors(t) })
+}
+
+func jsonToCSV(s string, opts ...Option) string {
+	buf := bytes.Buffer{}
+	err := MustUnmarshalString(s).ToCSV(&buf, opts...)
+	so(err, isNil)
+	return buf.String()
+}
+
+func csvToJSON(s string, opts ...Option) string {
+	v, err := FromCSV(strings.NewReader(s), opts...)
+	so(err, isNil)
+	return v.MustMarshalString(OptSetSequence())
+}
+
+func testCSVToCSV(t *testing.T) {
+	s := `[
+		{"id":1,"name":"Alice","addr":{"city":"Paris","geo":{"lat":48.85}},"vip":true},
+		{"name":"Bob, Jr.","id":2,"addr":{"zip":"75001"},"note":"say \"hi\"\nbye","extra":null,"meta":{}}
+	]`
+
+	so(jsonToCSV(s), eq, strings.Join([]string{
+		`id,name,addr.city,addr.geo.lat,vip,addr.zip,note,extra,meta`,
+		`1,Alice,Paris,48.85,true,,,,`,
+		`2,"Bob, Jr.",,,,75001,"say ""hi""`,
+		`bye",,{}`,
+		``,
+	}, "\n"))
+
+	so(jsonToCSV(s, OptCSVColumns("name", "addr.city", "missing"), OptCSVNoHeader()), eq,
+		"Alice,Paris,\n\"Bob, Jr.\",,\n")
+	so(jsonToCSV(s, OptCSVColumns("id", "name"), OptCSVDelimiter('\t')), eq,
+		"id\tname\n1\tAlice\n2\tBob, Jr.\n")
+	so(jsonToCSV(s, OptDefaultStringSequence(), OptOmitNull(true)), eq, strings.Join([]string{
+		`addr.city,addr.geo.lat,id,name,vip,addr.zip,meta,note`,
+		`Paris,48.85,1,Alice,true,,,`,
+		`,,2,"Bob, Jr.",,75001,{},"say ""hi""`,
+		`bye"`,
+		``,
+	}, "\n"))
+	so(jsonToCSV(`[]`), eq, "\n")
+
+	// literal keys with "." colliding with flattened keys
+	var buf bytes.Buffer
+	err := MustUnmarshalString(`[{"b":{"c":1},"b.c":2}]`).ToCSV(&buf)
+	so(errors.Is(err, ErrTypeNotMatch), isTrue)
+	err = MustUnmarshalString(`[{"t":[1],"t[0]":2}]`).ToCSV(&buf, OptCSVArrayEncoding(CSVArrayIndex))
+	so(errors.Is(err, ErrTypeNotMatch), isTrue)
+	so(jsonToCSV(`[{"b":{"c":1}},{"b.c":2}]`), eq, "b.c\n1\n2\n")
+}
+
+func testCSVArrayEncodings(t *testing.T) {
+	s := `[{"tags":["a","b"],"matrix":[[1,2],{"k":"v"}],"empty":[]},{"tags":["c"]}]`
+
+	so(jsonToCSV(s), eq, strings.Join([]string{
+		`tags,matrix,empty`,
+		`"[""a"",""b""]","[[1,2],{""k"":""v""}]",[]`,
+		`"[""c""]",,`,
+		``,
+	}, "\n"))
+	so(jsonToCSV(s, OptCSVArrayEncoding(CSVArrayIndex)), eq, strings.Join([]string{
+		`tags[0],tags[1],matrix[0][0],matrix[0][1],matrix[1].k,empty`,
+		`a,b,1,2,v,[]`,
+		`c,,,,,`,
+		``,
+	}, "\n"))
+	so(jsonToCSV(s, OptCSVArrayEncoding(CSVArrayJoin)), eq, strings.Join([]string{
+		`tags,matrix,empty`,
+		`a;b,"[1,2];{""k"":""v""}",`,
+		`c,,`,
+		``,
+	}, "\n"))
+	so(jsonToCSV(s, OptCSVArrayEncoding(CSVArrayJoin), OptCSVArraySeparator("|"), OptCSVColumns("tags")), eq,
+		"tags\na|b\nc\n")
+}
+
+func testCSVFromCSV(t *testing.T) {
+	src := strings.Join([]string{
+		`id,name,addr.city,addr.geo.lat,vip,zip,tags,raw`,
+		`1,Alice,Paris,48.85,true,01234,"[""a""]",{bad`,
+		`2,"Bob, Jr.",,-1.5e3,false,,,null`,
+		`3`,
+	}, "\n")
+	so(csvToJSON(src), eq, `[`+
+		`{"id":1,"name":"Alice","addr":{"city":"Paris","geo":{"lat":48.85}},"vip":true,"zip":"01234","tags":["a"],"raw":"{bad"},`+
+		`{"id":2,"name":"Bob, Jr.","addr":{"geo":{"lat":-1.5e3}},"vip":false,"raw":"null"},`+
+		`{"id":3}]`)
+
+	so(csvToJSON("1\tx\n", OptCSVDelimiter('\t'), OptCSVNoHeader(), OptCSVColumns("n", "s")), eq,
+		`[{"n":1,"s":"x"}]`)
+	so(csvToJSON("a,b\n1,2\n", OptCSVColumns("x", "y")), eq, `[{"x":1,"y":2}]`)
+	so(csvToJSON(""), eq, `[]`)
+	so(csvToJSON("a,b\n"), eq, `[]`)
+
+	// indexes
+	opt := OptCSVArrayEncoding(CSVArrayIndex)
+	so(csvToJSON("a[1],a[0],b[0][1].c,d[x],[0]\nx,y,z,w,v\n", opt), eq,
+		`[{"a":["y","x"],"b":[[null,{"c":"z"}]],"d[x]":"w","":["v"]}]`)
+	so(csvToJSON("a[0],a[2],b\n,3,\n", opt), eq, `[{"a":[null,null,3]}]`)
+	so(csvToJSON("a[0]\n1\n"), eq, `[{"a[0]":1}]`)
+}
+
+func testCSVRoundTrip(t *testing.T) {
+	s := `[{"id":1,"user":{"name":"Alice","langs":["go","c"],"extra":{}},"score":9.50},` +
+		`{"id":2,"user":{"name":"Bob","langs":[]},"ok":false}]`
+	v := MustUnmarshalString(s)
+
+	for _, enc := range []CSVArrayEncoding{CSVArrayJSON, CSVArrayIndex} {
+		buf := bytes.Buffer{}
+		err := v.ToCSV(&buf, OptCSVArrayEncoding(enc), OptCSVDelimiter('\t'))
+		so(err, isNil)
+		got, err := FromCSV(&buf, OptCSVArrayEncoding(enc), OptCSVDelimiter('\t'))
+		so(err, isNil)
+		so(got.MustMarshalString(OptSetSequence()), eq, s)
+	}
+}
+
+func testCSVErrors(t *testing.T) {
+	buf := bytes.Buffer{}
+	so(errors.Is((&V{}).ToCSV(&buf), ErrValueUninitialized), isTrue)
+	so(errors.Is(NewObject().ToCSV(&buf), ErrNotArrayValue), isTrue)
+	so(errors.Is(MustUnmarshalString(`[{},1]`).ToCSV(&buf), ErrTypeNotMatch), isTrue)
+	so(MustUnmarshalString(`[{"a":1}]`).ToCSV(&buf, OptCSVDelimiter('"')), isErr)
+
+	for _, s := range []string{
+		"a,b\n1,2,3\n",
+		"a\n\"unterminated\n",
+		"a\nx\"y\n",
+	} {
+		v, err := FromCSV(strings.NewReader(s))
+		so(err, isErr)
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+		so(v.ValueType(), eq, NotExist)
+	}
+
+	_, err := FromCSV(strings.NewReader("1,2\n"), OptCSVNoHeader())
+	so(errors.Is(err, ErrNilParameter), isTrue)
+	_, err = FromCSV(strings.NewReader("a,a.b\n1,2\n"))
+	so(errors.Is(err, ErrTypeNotMatch), isTrue)
+	_, err = FromCSV(strings.NewReader("a.b,a[0]\n1,2\n"), OptCSVArrayEncoding(CSVArrayIndex))
+	so(errors.Is(err, ErrTypeNotMatch), isTrue)
+
+	// conflicts do not depend on the order of columns
+	for _, header := range []string{"a,a.b", "a.b,a", "a,a", "a.b,a.b", "a.b,a[0]", "a[0],a.b", "a[0],a[0].b", "a[0].b,a[0]"} {
+		_, err = FromCSV(strings.NewReader(header+"\n1,2\n"), OptCSVArrayEncoding(CSVArrayIndex))
+		so(errors.Is(err, ErrTypeNotMatch), isTrue)
+		_, err = FromCSV(strings.NewReader(header+"\n"+`"{""b"":1}",2`+"\n"), OptCSVArrayEncoding(CSVArrayIndex))
+		so(errors.Is(err, ErrTypeNotMatch), isTrue)
+
+		v, err := FromCSV(strings.NewReader(header+"\n1,\n,2\n"), OptCSVArrayEncoding(CSVArrayIndex))
+		so(err, isNil)
+		so(v.Len(), eq, 2)
+	}
+
+	// indexes are limited by count of columns
+	_, err = FromCSV(strings.NewReader("a[2000000000]\n1\n"), OptCSVArrayEncoding(CSVArrayIndex))
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+	_, err = FromCSV(strings.NewReader("1\n"), OptCSVNoHeader(), OptCSVColumns("a[0][1]"), OptCSVArrayEncoding(CSVArrayIndex))
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+	v, err := FromCSV(strings.NewReader("a[2],b\n1,2\n"), OptCSVArrayEncoding(CSVArrayIndex))
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+	so(v.ValueType(), eq, NotExist)
+	v, err = FromCSV(strings.NewReader("a[1],b\n1,2\n"), OptCSVArrayEncoding(CSVArrayIndex))
+	so(err, isNil)
+	so(v.MustMarshalString(OptSetSequence()), eq, `[{"a":[null,1],"b":2}]`)
+}
@@ -0,0 +1,89 @@
+package jsonvalue
+
+import (
+	"sort"
+)
+
+// Difference shows a difference between two JSON values in Diff(). If a value does not exist in one side,
+// the corresponding field will be a *V with NotExist type.
+//
+// Difference 表示 Diff() 函数中两个 JSON 值之间的一处差异。如果某一侧的值不存在，那么对应的字段是一个 NotExist 类型的 *V。
+type Difference struct {
+	KeyPath KeyPath
+	Left    *V
+	Right   *V
+}
+
+// Diff returns all differences between two JSON values, with the same semantic of Equal(). That is to say,
+// numbers are compared as decimals, and key sequences of objects are ignored. Differences of objects are
+// listed in dictionary sequence of keys, and those of arrays are listed by indexes. Nil will be returned if
+// two values equal to each other.
+//
+// Diff 返回两个 JSON 值之间的所有差异，判断逻辑与 Equal() 相同，也就是说数字按照十进制数值比较，并且忽略 object 的键顺序。
+// object 的差异按照键的字典序列出，数组的差异则按照下标列出。如果两个值相等，则返回 nil。
+func Diff(left, right *V) []Difference {
+	if left == nil {
+		left = &V{}
+	}
+	if right == nil {
+		right = &V{}
+	}
+	return appendDifferences(nil, nil, left, right)
+}
+
+func appendDifferences(diffs []Difference, path KeyPath, left, right *V) []Difference {
+	if left.valueType != right.valueType {
+		return append(diffs, Difference{KeyPath: path, Left: left, Right: right})
+	}
+
+	switch left.valueType {
+	default:
+		if !left.Equal(right) {
+			diffs = append(diffs, Difference{KeyPath: path, Left: left, Right: right})
+		}
+		return diffs
+	case Object:
+		return appendObjectDifferences(diffs, path, left, right)
+	case Array:
+		return appendArrayDifferences(diffs, path, left, right)
+	}
+}
+
+func appendObjectDifferences(diffs []Difference, path KeyPath, left, right *V) []Difference {
+	keys := make([]string, 0, len(left.children.object)+len(right.children.object))
+	for k := range left.children.object {
+		keys = append(keys, k)
+	}
+	for k := range right.children.object {
+		if _, exist := left.children.object[k]; !exist {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		leftChild, _ := left.getFromObjectChildren(false, k)
+		rightChild, _ := right.getFromObjectChildren(false, k)
+		diffs = appendDifferences(diffs, appendKeyPath(path, stringKey(k)), leftChild, rightChild)
+	}
+	return diffs
+}
+
+func appendArrayDifferences(diffs []Difference, path KeyPath, left, right *V) []Difference {
+	le := len(left.children.arr)
+	if l := len(right.children.arr); l > le {
+		le = l
+	}
+
+	for i := 0; i < le; i++ {
+		leftChild, rightChild := &V{}, &V{}
+		if i < len(left.children.arr) {
+			leftChild = left.children.arr[i]
+		}
+		if i < len(right.children.arr) {
+			rightChild = right.children.arr[i]
+		}
+		diffs = appendDifferences(diffs, appendKeyPath(path, intKey(i)), leftChild, rightChild)
+	}
+	return diffs
+}
@@ -0,0 +1,46 @@
+package jsonvalue
+
+import (
+	"testing"
+)
+
+func testDiff(t *testing.T) {
+	cv("no difference", func() { testDiffNone(t) })
+	cv("general differences", func() { testDiffGeneral(t) })
+}
+
+func testDiffNone(t *testing.T) {
+	v1 := MustUnmarshalString(`{"a":[1,2.0,{"b":null}],"c":"d"}`)
+	v2 := MustUnmarshalString(`{"c":"d","a":[1.0,2,{"b":null}]}`)
+	so(len(Diff(v1, v2)), eq, 0)
+}
+
+func testDiffGeneral(t *testing.T) {
+	left := MustUnmarshalString(`{"a":[1,2,3],"b":{"c":true,"d":"x"},"e":1,"f":null}`)
+	right := MustUnmarshalString(`{"a":[1,5],"b":{"c":true,"d":"y","g":0},"e":"1"}`)
+
+	diffs := Diff(left, right)
+	so(len(diffs), eq, 6)
+
+	type expected struct {
+		path, left, right string
+	}
+	exp := []expected{
+		{`["a" 1]`, `2`, `5`},
+		{`["a" 2]`, `3`, ``},
+		{`["b" "d"]`, `"x"`, `"y"`},
+		{`["b" "g"]`, ``, `0`},
+		{`["e"]`, `1`, `"1"`},
+		{`["f"]`, `null`, ``},
+	}
+	for i, d := range diffs {
+		so(d.KeyPath.String(), eq, exp[i].path)
+		so(string(d.Left.MustMarshal()), eq, exp[i].left)
+		so(string(d.Right.MustMarshal()), eq, exp[i].right)
+	}
+
+	diffs = Diff(nil, NewInt(1))
+	so(len(diffs), eq, 1)
+	so(diffs[0].Left.ValueType(), eq, NotExist)
+	so(len(diffs[0].KeyPath), eq, 0)
+}
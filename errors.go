@@ -79,6 +79,16 @@ const (
 	// ErrUnsupportedFloat 表示 float64 是一个不支持的数值，如 +Inf, -Inf 和 NaN
 	ErrUnsupportedFloat = Error("unsupported float value")
 
+	// ErrCycleDetected shows that a reference cycle is detected in Import()
+	//
+	// ErrCycleDetected 表示 Import() 中检测到了循环引用
+	ErrCycleDetected = Error("reference cycle detected")
+
+	// ErrMaxDepthExceeded shows that nesting depth exceeds the limit configured by OptImportMaxDepth()
+	//
+	// ErrMaxDepthExceeded 表示嵌套深度超过了 OptImportMaxDepth() 配置的限制
+	ErrMaxDepthExceeded = Error("max depth exceeded")
+
 	// ErrUnsupportedFloatInOpt shows that float value in option is not supported, like +Inf, -Inf and NaN.
 	//
 	// ErrUnsupportedFloat 表示配置中的 float64 是一个不支持的数值，如 +Inf, -Inf 和 NaN
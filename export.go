@@ -0,0 +1,739 @@
+package jsonvalue
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonNumberType      = reflect.TypeOf(json.Number(""))
+)
+
+// exportFunc 将 *V 导出到对应 reflect.Value 的函数, 是 parserFunc 的反向操作。dst 必须是可设置的。
+type exportFunc func(v *V, dst reflect.Value, ex ext) error
+
+// ExportError is returned by Export() and ExportAt(), showing where and why exporting fails. It wraps
+// ErrTypeNotMatch, ErrOutOfRange or other underlying errors, which could be checked by errors.Is().
+//
+// ExportError 由 Export() 和 ExportAt() 返回，表示导出失败的位置以及原因。它包装了 ErrTypeNotMatch、ErrOutOfRange
+// 或其他底层错误，可以使用 errors.Is() 进行判断。
+type ExportError struct {
+	KeyPath KeyPath
+	Reason  string
+	Err     error
+
+	located bool
+}
+
+// Error implements error interface, in format like "data.items[3].price: expected number, got string".
+//
+// Error 实现 error 接口，格式如 "data.items[3].price: expected number, got string"。
+func (e *ExportError) Error() string {
+	return fmt.Sprintf("%s: %s", e.KeyPath.DotString(), e.Reason)
+}
+
+// Unwrap returns the underlying error.
+//
+// Unwrap 返回底层错误。
+func (e *ExportError) Unwrap() error {
+	return e.Err
+}
+
+func exportErrorf(err error, format string, a ...any) *ExportError {
+	return &ExportError{
+		Reason: fmt.Sprintf(format, a...),
+		Err:    err,
+	}
+}
+
+func exportTypeError(v *V, expected ValueType) error {
+	return exportErrorf(ErrTypeNotMatch, "expected %v, got %v", expected, v.valueType)
+}
+
+// exportState holds states shared in one Export() or ExportAt() call.
+type exportState struct {
+	path   KeyPath
+	strict bool
+}
+
+func (s *exportState) pushKey(k Key) {
+	if s != nil {
+		s.path = append(s.path, &k)
+	}
+}
+
+func (s *exportState) popKey() {
+	if s != nil {
+		s.path = s.path[:len(s.path)-1]
+	}
+}
+
+func (s *exportState) isStrict() bool {
+	return s != nil && s.strict
+}
+
+// locate converts err into *ExportError with current key path, if it is not located yet.
+func (s *exportState) locate(err error) error {
+	e, ok := err.(*ExportError)
+	if !ok {
+		e = &ExportError{Reason: err.Error(), Err: err}
+	}
+	if !e.located {
+		e.located = true
+		if s != nil {
+			e.KeyPath = append(KeyPath{}, s.path...)
+		}
+	}
+	return e
+}
+
+// exportToValue exports v into a settable reflect.Value.
+func exportToValue(v *V, dst reflect.Value, ex ext) error {
+	if err := exportToValueDirectly(v, dst, ex); err != nil {
+		return ex.exportState.locate(err)
+	}
+	return nil
+}
+
+func exportToValueDirectly(v *V, dst reflect.Value, ex ext) error {
+	if fu := validateConverterAndReturnExporter(dst, ex); fu != nil {
+		return fu(v, dst, ex)
+	}
+
+	if ex.toString {
+		return exportQuotedValue(v, dst, ex)
+	}
+
+	// json.Unmarshaler should handle null by itself
+	if dst.Kind() != reflect.Ptr && dst.CanAddr() && dst.Addr().Type().Implements(jsonUnmarshalerType) {
+		return exportJSONUnmarshalerValue(v, dst, ex)
+	}
+
+	if v.valueType == Null {
+		return exportNullValue(v, dst, ex)
+	}
+
+	if dst.Kind() != reflect.Ptr && dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		return exportTextUnmarshalerValue(v, dst, ex)
+	}
+
+	fu, err := validateDstAndReturnExporter(dst, ex)
+	if err != nil {
+		return err
+	}
+	return fu(v, dst, ex)
+}
+
+// validateDstAndReturnExporter 检查导出目标的合法性并返回相应的处理函数, 与 validateValAndReturnParser 相对应
+func validateDstAndReturnExporter(dst reflect.Value, ex ext) (fu exportFunc, err error) {
+	if dst.Type() == typeOfJSONValue {
+		fu = exportJSONValue
+		return
+	}
+
+	switch dst.Kind() {
+	default:
+		// case reflect.Invalid, reflect.Complex64, reflect.Complex128:
+		// case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		err = fmt.Errorf("jsonvalue: unsupported type: %v", dst.Type())
+
+	case reflect.Bool:
+		fu = exportBoolValue
+
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		fu = exportIntValue
+
+	case reflect.Uintptr, reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
+		fu = exportUintValue
+
+	case reflect.Float32, reflect.Float64:
+		fu = exportFloatValue
+
+	case reflect.Array:
+		fu = exportArrayValue
+
+	case reflect.Interface:
+		fu = exportInterfaceValue
+
+	case reflect.Map:
+		fu = exportMapValue
+
+	case reflect.Ptr:
+		fu = exportPtrValue
+
+	case reflect.Slice:
+		fu = exportSliceValue
+
+	case reflect.String:
+		if dst.Type() == jsonNumberType {
+			fu = exportJSONNumberValue
+		} else {
+			fu = exportStringValue
+		}
+
+	case reflect.Struct:
+		fu = exportStructValue
+	}
+
+	return
+}
+
+func exportJSONValue(v *V, dst reflect.Value, _ ext) error {
+	dst.Set(reflect.ValueOf(v))
+	return nil
+}
+
+func exportNullValue(v *V, dst reflect.Value, ex ext) error {
+	switch dst.Kind() {
+	case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+		dst.Set(reflect.Zero(dst.Type()))
+	default:
+		// otherwise, null has no effect, just like encoding/json does, except strict mode
+		if ex.exportState.isStrict() {
+			return exportTypeError(v, expectedValueTypeOf(dst))
+		}
+	}
+	return nil
+}
+
+// expectedValueTypeOf returns the JSON value type which matches given Go value.
+func expectedValueTypeOf(dst reflect.Value) ValueType {
+	switch dst.Kind() {
+	default:
+		return Unknown
+	case reflect.Bool:
+		return Boolean
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8,
+		reflect.Uintptr, reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8,
+		reflect.Float32, reflect.Float64:
+		return Number
+	case reflect.String:
+		if dst.Type() == jsonNumberType {
+			return Number
+		}
+		return String
+	case reflect.Array, reflect.Slice:
+		return Array
+	case reflect.Map, reflect.Struct:
+		return Object
+	}
+}
+
+func exportJSONUnmarshalerValue(v *V, dst reflect.Value, _ ext) error {
+	b, err := v.Marshal()
+	if err != nil {
+		return err
+	}
+	return dst.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(b)
+}
+
+func exportTextUnmarshalerValue(v *V, dst reflect.Value, _ ext) error {
+	if v.valueType != String {
+		return exportTypeError(v, String)
+	}
+	return dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(v.valueStr))
+}
+
+// exportQuotedValue handles fields with ",string" tag option.
+func exportQuotedValue(v *V, dst reflect.Value, ex ext) error {
+	ex.toString = false
+
+	switch v.valueType {
+	case Null:
+		return exportToValue(v, dst, ex)
+	case String:
+		// go on
+	default:
+		return exportErrorf(
+			ErrTypeNotMatch, "invalid use of ,string struct tag, trying to export %v value into %v",
+			v.valueType, dst.Type(),
+		)
+	}
+
+	inner, err := UnmarshalString(v.valueStr)
+	if err != nil {
+		return exportErrorf(
+			ErrTypeNotMatch, "invalid use of ,string struct tag, trying to export %q into %v",
+			v.valueStr, dst.Type(),
+		)
+	}
+
+	switch inner.valueType {
+	case String, Number, Boolean, Null:
+		return exportToValue(inner, dst, ex)
+	default:
+		return exportErrorf(
+			ErrTypeNotMatch, "invalid use of ,string struct tag, trying to export %q into %v",
+			v.valueStr, dst.Type(),
+		)
+	}
+}
+
+func exportBoolValue(v *V, dst reflect.Value, _ ext) error {
+	if v.valueType != Boolean {
+		return exportTypeError(v, Boolean)
+	}
+	dst.SetBool(v.valueBool)
+	return nil
+}
+
+func exportIntValue(v *V, dst reflect.Value, _ ext) error {
+	if v.valueType != Number {
+		return exportTypeError(v, Number)
+	}
+	i, err := strconv.ParseInt(unsafeBtoS(v.srcByte), 10, 64)
+	if err != nil || dst.OverflowInt(i) {
+		return exportErrorf(ErrOutOfRange, "cannot export number %v into Go type %v", v, dst.Type())
+	}
+	dst.SetInt(i)
+	return nil
+}
+
+func exportUintValue(v *V, dst reflect.Value, _ ext) error {
+	if v.valueType != Number {
+		return exportTypeError(v, Number)
+	}
+	u, err := strconv.ParseUint(unsafeBtoS(v.srcByte), 10, 64)
+	if err != nil || dst.OverflowUint(u) {
+		return exportErrorf(ErrOutOfRange, "cannot export number %v into Go type %v", v, dst.Type())
+	}
+	dst.SetUint(u)
+	return nil
+}
+
+func exportFloatValue(v *V, dst reflect.Value, _ ext) error {
+	if v.valueType != Number {
+		return exportTypeError(v, Number)
+	}
+	if len(v.srcByte) == 0 { // NaN, +Inf or -Inf
+		dst.SetFloat(v.num.f64)
+		return nil
+	}
+	f, err := strconv.ParseFloat(unsafeBtoS(v.srcByte), dst.Type().Bits())
+	if err != nil || dst.OverflowFloat(f) {
+		return exportErrorf(ErrOutOfRange, "cannot export number %v into Go type %v", v, dst.Type())
+	}
+	dst.SetFloat(f)
+	return nil
+}
+
+func exportStringValue(v *V, dst reflect.Value, _ ext) error {
+	if v.valueType != String {
+		return exportTypeError(v, String)
+	}
+	dst.SetString(v.valueStr)
+	return nil
+}
+
+func exportJSONNumberValue(v *V, dst reflect.Value, _ ext) error {
+	switch v.valueType {
+	default:
+		return exportTypeError(v, Number)
+	case Number:
+		dst.SetString(v.String())
+		return nil
+	case String:
+		n, err := UnmarshalString(v.valueStr)
+		if err != nil || n.valueType != Number {
+			return exportErrorf(ErrTypeNotMatch, "invalid number literal %q for %v", v.valueStr, dst.Type())
+		}
+		dst.SetString(v.valueStr)
+		return nil
+	}
+}
+
+func exportArrayValue(v *V, dst reflect.Value, ex ext) error {
+	if v.valueType != Array {
+		return exportTypeError(v, Array)
+	}
+
+	le := dst.Len()
+	if len(v.children.arr) > le && ex.exportState.isStrict() {
+		return exportErrorf(ErrOutOfRange, "expected at most %d elements, got %d", le, len(v.children.arr))
+	}
+
+	for i := 0; i < le; i++ {
+		if i >= len(v.children.arr) {
+			dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
+			continue
+		}
+		ex.exportState.pushKey(intKey(i))
+		if err := exportToValue(v.children.arr[i], dst.Index(i), ex.inherited()); err != nil {
+			return err
+		}
+		ex.exportState.popKey()
+	}
+	return nil
+}
+
+func exportSliceValue(v *V, dst reflect.Value, ex ext) error {
+	switch v.valueType {
+	default:
+		return exportTypeError(v, Array)
+
+	case String:
+		if dst.Type().Elem().Kind() != reflect.Uint8 {
+			return exportTypeError(v, Array)
+		}
+		b, err := base64.StdEncoding.DecodeString(v.valueStr)
+		if err != nil {
+			return exportErrorf(ErrTypeNotMatch, "invalid base64 string: %v", err)
+		}
+		dst.SetBytes(b)
+		return nil
+
+	case Array:
+		le := len(v.children.arr)
+		if le > dst.Cap() {
+			s := reflect.MakeSlice(dst.Type(), le, le)
+			reflect.Copy(s, dst)
+			dst.Set(s)
+		} else {
+			prevLen := dst.Len()
+			dst.SetLen(le)
+			for i := prevLen; i < le; i++ {
+				dst.Index(i).Set(reflect.Zero(dst.Type().Elem()))
+			}
+		}
+		for i, child := range v.children.arr {
+			ex.exportState.pushKey(intKey(i))
+			if err := exportToValue(child, dst.Index(i), ex.inherited()); err != nil {
+				return err
+			}
+			ex.exportState.popKey()
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeSlice(dst.Type(), 0, 0))
+		}
+		return nil
+	}
+}
+
+func exportPtrValue(v *V, dst reflect.Value, ex ext) error {
+	if dst.IsNil() {
+		dst.Set(reflect.New(dst.Type().Elem()))
+	}
+	return exportToValue(v, dst.Elem(), ex)
+}
+
+func exportInterfaceValue(v *V, dst reflect.Value, ex ext) error {
+	if !dst.IsNil() {
+		if e := dst.Elem(); e.Kind() == reflect.Ptr && !e.IsNil() {
+			return exportToValue(v, e, ex)
+		}
+	}
+	if dst.NumMethod() != 0 {
+		return exportErrorf(ErrTypeNotMatch, "cannot export %v value into non-empty interface %v", v.valueType, dst.Type())
+	}
+
+	if i := v.toInterface(ex.interfaceNumber); i == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+	} else {
+		dst.Set(reflect.ValueOf(i))
+	}
+	return nil
+}
+
+func exportMapValue(v *V, dst reflect.Value, ex ext) error {
+	if v.valueType != Object {
+		return exportTypeError(v, Object)
+	}
+
+	t := dst.Type()
+	keyFunc, err := validateMapKeyAndReturnExporter(t.Key())
+	if err != nil {
+		return err
+	}
+
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(t, len(v.children.object)))
+	}
+
+	for k, child := range v.children.object {
+		ex.exportState.pushKey(stringKey(k))
+		key, err := keyFunc(k)
+		if err != nil {
+			return err
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := exportToValue(child.v, elem, ex.inherited()); err != nil {
+			return err
+		}
+		ex.exportState.popKey()
+		dst.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+func validateMapKeyAndReturnExporter(kt reflect.Type) (func(string) (reflect.Value, error), error) {
+	if reflect.PtrTo(kt).Implements(textUnmarshalerType) {
+		return func(k string) (reflect.Value, error) {
+			kv := reflect.New(kt)
+			err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k))
+			return kv.Elem(), err
+		}, nil
+	}
+
+	switch kt.Kind() {
+	default:
+		return nil, fmt.Errorf("unsupported key type for a map: %v", kt)
+
+	case reflect.String:
+		return func(k string) (reflect.Value, error) {
+			return reflect.ValueOf(k).Convert(kt), nil
+		}, nil
+
+	case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
+		return func(k string) (reflect.Value, error) {
+			kv := reflect.New(kt).Elem()
+			i, err := strconv.ParseInt(k, 10, 64)
+			if err != nil || kv.OverflowInt(i) {
+				return kv, exportErrorf(ErrTypeNotMatch, "cannot export key %q into Go type %v", k, kt)
+			}
+			kv.SetInt(i)
+			return kv, nil
+		}, nil
+
+	case reflect.Uintptr, reflect.Uint, reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8:
+		return func(k string) (reflect.Value, error) {
+			kv := reflect.New(kt).Elem()
+			u, err := strconv.ParseUint(k, 10, 64)
+			if err != nil || kv.OverflowUint(u) {
+				return kv, exportErrorf(ErrTypeNotMatch, "cannot export key %q into Go type %v", k, kt)
+			}
+			kv.SetUint(u)
+			return kv, nil
+		}, nil
+	}
+}
+
+func exportStructValue(v *V, dst reflect.Value, ex ext) error {
+	if v.valueType != Object {
+		return exportTypeError(v, Object)
+	}
+
+	fields := cachedExportFields(dst.Type(), ex)
+
+	for k, child := range v.children.object {
+		f, exist := fields.find(k)
+		if !exist {
+			if ex.exportState.isStrict() {
+				return exportErrorf(ErrNotFound, "unknown field %q for %v", k, dst.Type())
+			}
+			continue
+		}
+		ex.exportState.pushKey(stringKey(k))
+		fv, err := fieldByIndexForExport(dst, f.index)
+		if err != nil {
+			return err
+		}
+		fieldEx := ex.inherited()
+		fieldEx.toString = f.toString
+		if err := exportToValue(child.v, fv, fieldEx); err != nil {
+			return err
+		}
+		ex.exportState.popKey()
+	}
+	return nil
+}
+
+// fieldByIndexForExport is like reflect.Value.FieldByIndex, but allocates nil embedded pointers.
+func fieldByIndexForExport(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return v, fmt.Errorf(
+						"jsonvalue: cannot set embedded pointer to unexported struct: %v", v.Type().Elem(),
+					)
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v, nil
+}
+
+// ---------------- struct fields for exporting ----------------
+
+type exportField struct {
+	name     string
+	tagged   bool
+	index    []int
+	toString bool
+}
+
+type exportFields struct {
+	list   []exportField
+	byName map[string]int
+}
+
+func (fs *exportFields) find(key string) (exportField, bool) {
+	if i, exist := fs.byName[key]; exist {
+		return fs.list[i], true
+	}
+	// encoding/json also accepts case-insensitive matching
+	for _, f := range fs.list {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return exportField{}, false
+}
+
+type exportFieldsCacheKey struct {
+	t      reflect.Type
+	tag    string
+	naming FieldNaming
+}
+
+var exportFieldsCache sync.Map // map[exportFieldsCacheKey]*exportFields
+
+func cachedExportFields(t reflect.Type, ex ext) *exportFields {
+	key := exportFieldsCacheKey{t: t, tag: ex.tagName(), naming: ex.naming}
+	if fs, ok := exportFieldsCache.Load(key); ok {
+		return fs.(*exportFields)
+	}
+	fs, _ := exportFieldsCache.LoadOrStore(key, typeExportFields(t, ex))
+	return fs.(*exportFields)
+}
+
+// typeExportFields returns fields of a struct type which should be recognized when exporting, following
+// the rules of encoding/json: fields of embedded structs are promoted, shallower fields hide deeper ones,
+// and tagged fields dominate untagged ones with same depth.
+func typeExportFields(t reflect.Type, parentEx ext) *exportFields {
+	tagName := parentEx.tagName()
+
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+
+	var candidates []exportField
+	depthOfName := map[string]int{}
+	visited := map[reflect.Type]bool{}
+	next := []embedded{{t: t}}
+
+	for depth := 0; len(next) > 0; depth++ {
+		current := next
+		next = nil
+
+		var fieldsInDepth []exportField
+
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+
+			for i := 0; i < e.t.NumField(); i++ {
+				sf := e.t.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				if sf.Anonymous {
+					if sf.PkgPath != "" && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					continue
+				}
+
+				tag := sf.Tag.Get(tagName)
+				if tag == "-" {
+					continue
+				}
+				name, ex := readFieldTag(sf, tagName, parentEx)
+				tagged := strings.Split(tag, ",")[0] != ""
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				if sf.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{t: ft, index: index})
+					continue
+				}
+
+				toString := false
+				if ex.toString {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						toString = true
+					}
+				}
+
+				fieldsInDepth = append(fieldsInDepth, exportField{
+					name:     name,
+					tagged:   tagged,
+					index:    index,
+					toString: toString,
+				})
+			}
+		}
+
+		for _, f := range fieldsInDepth {
+			if _, exist := depthOfName[f.name]; !exist {
+				depthOfName[f.name] = depth
+			}
+		}
+		for _, f := range fieldsInDepth {
+			if depthOfName[f.name] == depth {
+				candidates = append(candidates, f)
+			}
+		}
+	}
+
+	// resolve fields with same name in the same depth
+	res := &exportFields{byName: map[string]int{}}
+	grouped := map[string][]exportField{}
+	var names []string
+	for _, f := range candidates {
+		if _, exist := grouped[f.name]; !exist {
+			names = append(names, f.name)
+		}
+		grouped[f.name] = append(grouped[f.name], f)
+	}
+
+	for _, name := range names {
+		f, ok := dominantExportField(grouped[name])
+		if !ok {
+			continue
+		}
+		res.byName[name] = len(res.list)
+		res.list = append(res.list, f)
+	}
+	return res
+}
+
+func dominantExportField(fields []exportField) (exportField, bool) {
+	if len(fields) == 1 {
+		return fields[0], true
+	}
+
+	var tagged []exportField
+	for _, f := range fields {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return exportField{}, false
+}
@@ -0,0 +1,447 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func testExport(t *testing.T) {
+	cv("basic struct", func() { testExportBasicStruct(t) })
+	cv("large integers", func() { testExportLargeIntegers(t) })
+	cv("string tag option", func() { testExportStringTagOption(t) })
+	cv("embedded structs", func() { testExportEmbedded(t) })
+	cv("unmarshalers", func() { testExportUnmarshalers(t) })
+	cv("collections", func() { testExportCollections(t) })
+	cv("interfaces", func() { testExportInterfaces(t) })
+	cv("errors", func() { testExportErrors(t) })
+	cv("error key paths", func() { testExportErrorKeyPath(t) })
+	cv("ExportAt", func() { testExportAt(t) })
+	cv("strict mode", func() { testExportStrict(t) })
+}
+
+type exportTestStruct struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age,omitempty"`
+	Score   float32           `json:"score"`
+	OK      bool              `json:"ok"`
+	Tags    []string          `json:"tags"`
+	Attrs   map[string]int    `json:"attrs"`
+	Ptr     *int              `json:"ptr"`
+	Raw     json.RawMessage   `json:"raw"`
+	Bytes   []byte            `json:"bytes"`
+	Number  json.Number       `json:"number"`
+	Skip    string            `json:"-"`
+	NoTag   string            //
+	Nested  *exportTestStruct `json:"nested,omitempty"`
+	private string
+}
+
+func testExportBasicStruct(t *testing.T) {
+	raw := `{"name":"Alice","age":20,"score":98.5,"ok":true,"tags":["a","b"],"attrs":{"x":1,"y":2},` +
+		`"ptr":100,"raw":{"k":[1,2]},"bytes":"aGVsbG8=","number":12.50,"Skip":"skip","notag":"no tag",` +
+		`"nested":{"name":"Bob"},"private":"private"}`
+	v := MustUnmarshalString(raw)
+
+	var got, expected exportTestStruct
+	err := v.Export(&got)
+	so(err, isNil)
+	err = json.Unmarshal([]byte(raw), &expected)
+	so(err, isNil)
+
+	so(got.Name, eq, "Alice")
+	so(got.Age, eq, 20)
+	so(got.Score, eq, float32(98.5))
+	so(got.OK, isTrue)
+	so(len(got.Tags), eq, 2)
+	so(got.Tags[1], eq, "b")
+	so(got.Attrs["y"], eq, 2)
+	so(*got.Ptr, eq, 100)
+	so(string(got.Raw), eq, `{"k":[1,2]}`)
+	so(string(got.Bytes), eq, "hello")
+	so(got.Number, eq, json.Number("12.50"))
+	so(got.Skip, eq, "")
+	so(got.NoTag, eq, "no tag")
+	so(got.private, eq, "")
+	so(got.Nested.Name, eq, "Bob")
+
+	b1, _ := json.Marshal(&got)
+	b2, _ := json.Marshal(&expected)
+	so(string(b1), eq, string(b2))
+
+	// null resets pointers, maps and slices, while leaves other values unchanged
+	v = MustUnmarshalString(`{"name":null,"tags":null,"attrs":null,"ptr":null}`)
+	err = v.Export(&got)
+	so(err, isNil)
+	so(got.Name, eq, "Alice")
+	so(got.Tags, isNil)
+	so(got.Attrs, isNil)
+	so(got.Ptr, isNil)
+}
+
+func testExportLargeIntegers(t *testing.T) {
+	v := MustUnmarshalString(`{"i":-9223372036854775808,"u":18446744073709551615,"i8":-128}`)
+	st := struct {
+		I  int64  `json:"i"`
+		U  uint64 `json:"u"`
+		I8 int8   `json:"i8"`
+	}{}
+	err := v.Export(&st)
+	so(err, isNil)
+	so(st.I, eq, int64(math.MinInt64))
+	so(st.U, eq, uint64(math.MaxUint64))
+	so(st.I8, eq, int8(-128))
+
+	var i8 int8
+	err = NewInt(128).Export(&i8)
+	so(err, isErr)
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+
+	var u uint
+	err = NewInt(-1).Export(&u)
+	so(err, isErr)
+
+	var i int
+	err = NewFloat64(1.5).Export(&i)
+	so(err, isErr)
+
+	var f32 float32
+	err = MustUnmarshalString("1e100").Export(&f32)
+	so(err, isErr)
+}
+
+func testExportStringTagOption(t *testing.T) {
+	type st struct {
+		Int   int64    `json:"int,string"`
+		Str   string   `json:"str,string"`
+		Bool  bool     `json:"bool,string"`
+		Float *float64 `json:"float,string"`
+		Arr   []int    `json:"arr,string"` // no effect
+	}
+	raw := `{"int":"9007199254740993","str":"\"hello\"","bool":"true","float":"1.25","arr":[1]}`
+
+	var got, expected st
+	err := MustUnmarshalString(raw).Export(&got)
+	so(err, isNil)
+	err = json.Unmarshal([]byte(raw), &expected)
+	so(err, isNil)
+
+	so(got.Int, eq, int64(9007199254740993))
+	so(got.Str, eq, "hello")
+	so(got.Bool, isTrue)
+	so(*got.Float, eq, 1.25)
+	so(len(got.Arr), eq, 1)
+
+	b1, _ := json.Marshal(&got)
+	b2, _ := json.Marshal(&expected)
+	so(string(b1), eq, string(b2))
+
+	err = MustUnmarshalString(`{"int":1}`).Export(&got)
+	so(err, isErr)
+	err = MustUnmarshalString(`{"int":"abc"}`).Export(&got)
+	so(err, isErr)
+	err = MustUnmarshalString(`{"str":"hello"}`).Export(&got)
+	so(err, isErr)
+}
+
+type exportTestInner struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ExportTestInner2 is exported for embedded pointer allocating
+type ExportTestInner2 struct {
+	Name string
+	Desc string `json:"desc"`
+}
+
+type exportTestOuter struct {
+	exportTestInner
+	*ExportTestInner2
+	Named exportTestInner `json:"named"`
+	Desc  string          `json:"desc"`
+}
+
+func testExportEmbedded(t *testing.T) {
+	raw := `{"id":1,"name":"ambiguous","Name":"ambiguous too","desc":"outer","named":{"id":2}}`
+
+	var got, expected exportTestOuter
+	err := MustUnmarshalString(raw).Export(&got)
+	so(err, isNil)
+	err = json.Unmarshal([]byte(raw), &expected)
+	so(err, isNil)
+
+	so(got.ID, eq, 1)
+	so(got.exportTestInner.Name, eq, expected.exportTestInner.Name)
+	so(got.ExportTestInner2.Name, eq, "ambiguous too")
+	so(got.ExportTestInner2.Desc, eq, "")
+	so(got.Desc, eq, "outer")
+	so(got.Named.ID, eq, 2)
+
+	// case-insensitive matching
+	inner := exportTestInner{}
+	err = MustUnmarshalString(`{"ID":3,"NAME":"upper"}`).Export(&inner)
+	so(err, isNil)
+	so(inner.ID, eq, 3)
+	so(inner.Name, eq, "upper")
+}
+
+type exportTestText string
+
+func (s *exportTestText) UnmarshalText(b []byte) error {
+	*s = exportTestText("text:" + string(b))
+	return nil
+}
+
+func testExportUnmarshalers(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	v := NewObject()
+	v.SetString(now.Format(time.RFC3339)).At("time")
+	v.SetString("hello").At("text")
+	v.SetString("world").At("map", "key")
+
+	st := struct {
+		Time  time.Time                 `json:"time"`
+		TimeP *time.Time                `json:"time_p"`
+		Text  exportTestText            `json:"text"`
+		Map   map[exportTestText]string `json:"map"`
+	}{}
+	err := v.Export(&st)
+	so(err, isNil)
+	so(st.Time.Equal(now), isTrue)
+	so(st.TimeP, isNil)
+	so(st.Text, eq, exportTestText("text:hello"))
+	so(st.Map["text:key"], eq, "world")
+
+	v.SetString(now.Format(time.RFC3339)).At("time_p")
+	err = v.Export(&st)
+	so(err, isNil)
+	so(st.TimeP.Equal(now), isTrue)
+
+	err = NewInt(1).Export(&st.Text)
+	so(err, isErr)
+
+	err = NewString("not a time").Export(&st.Time)
+	so(err, isErr)
+}
+
+func testExportCollections(t *testing.T) {
+	v := MustUnmarshalString(`{"1":"a","-2":"b"}`)
+	m := map[int]string{}
+	err := v.Export(&m)
+	so(err, isNil)
+	so(m[1], eq, "a")
+	so(m[-2], eq, "b")
+
+	um := map[uint8]string{}
+	err = v.Export(&um)
+	so(err, isErr)
+
+	v = MustUnmarshalString(`[1,2,3]`)
+	arr := [2]int{}
+	err = v.Export(&arr)
+	so(err, isNil)
+	so(arr[1], eq, 2)
+
+	arr5 := [5]int{9, 9, 9, 9, 9}
+	err = v.Export(&arr5)
+	so(err, isNil)
+	so(arr5[2], eq, 3)
+	so(arr5[3], eq, 0)
+
+	sli := make([]int, 5, 10)
+	err = v.Export(&sli)
+	so(err, isNil)
+	so(len(sli), eq, 3)
+
+	var empty []int
+	err = NewArray().Export(&empty)
+	so(err, isNil)
+	so(empty, notNil)
+	so(len(empty), eq, 0)
+
+	var jv *V
+	err = v.Export(&jv)
+	so(err, isNil)
+	so(jv, eq, v)
+}
+
+func testExportInterfaces(t *testing.T) {
+	raw := `{"arr":[1,"2",true,null,{}],"obj":{"a":1.5}}`
+	v := MustUnmarshalString(raw)
+
+	var got, expected any
+	err := v.Export(&got)
+	so(err, isNil)
+	err = json.Unmarshal([]byte(raw), &expected)
+	so(err, isNil)
+
+	b1, _ := json.Marshal(got)
+	b2, _ := json.Marshal(expected)
+	so(string(b1), eq, string(b2))
+
+	// non-nil pointer in interface is exported in-place
+	i := 0
+	var intf any = &i
+	err = NewInt(10).Export(&intf)
+	so(err, isNil)
+	so(i, eq, 10)
+
+	var stringer interface{ String() string }
+	err = NewInt(10).Export(&stringer)
+	so(err, isErr)
+}
+
+func testExportErrors(t *testing.T) {
+	var i int
+	err := (&V{}).Export(&i)
+	so(err, isErr)
+	so(errors.Is(err, ErrValueUninitialized), isTrue)
+
+	var ch chan int
+	err = NewInt(1).Export(&ch)
+	so(err, isErr)
+
+	st := exportTestStruct{}
+	err = MustUnmarshalString(`{"name":1}`).Export(&st)
+	so(err, isErr)
+	so(errors.Is(err, ErrTypeNotMatch), isTrue)
+
+	err = MustUnmarshalString(`[1]`).Export(&st)
+	so(err, isErr)
+
+	err = MustUnmarshalString(`{"bytes":"!!"}`).Export(&st)
+	so(err, isErr)
+
+	var n json.Number
+	err = NewString("12").Export(&n)
+	so(err, isNil)
+	so(n, eq, json.Number("12"))
+	err = NewString("abc").Export(&n)
+	so(err, isErr)
+	err = NewBool(true).Export(&n)
+	so(err, isErr)
+}
+
+type exportTestItem struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+type exportTestOrder struct {
+	Items []exportTestItem           `json:"items"`
+	Index map[string][2]int          `json:"index"`
+	Refs  map[string]*exportTestItem `json:"refs"`
+}
+
+func testExportErrorKeyPath(t *testing.T) {
+	v := MustUnmarshalString(`{"data":{"items":[{"price":1},{"price":2},{"price":3},{"price":"4"}]}}`)
+
+	st := struct {
+		Data exportTestOrder `json:"data"`
+	}{}
+	err := v.Export(&st)
+	so(err, isErr)
+	so(err.Error(), eq, "data.items[3].price: expected number, got string")
+	so(errors.Is(err, ErrTypeNotMatch), isTrue)
+
+	var e *ExportError
+	so(errors.As(err, &e), isTrue)
+	so(e.KeyPath.DotString(), eq, "data.items[3].price")
+	so(e.Reason, eq, "expected number, got string")
+
+	err = MustUnmarshalString(`{"index":{"a.b":[1,1e10]}}`).Export(&st.Data)
+	so(err, isErr)
+	so(err.Error(), hasSubStr, `index["a.b"][1]: `)
+
+	var i8 int8
+	err = NewInt(1000).Export(&i8)
+	so(err, isErr)
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+	so(err.Error(), hasSubStr, "(root): ")
+}
+
+func testExportAt(t *testing.T) {
+	v := MustUnmarshalString(`{"data":{"items":[{"name":"a","price":1.5},{"name":"b","price":"2"}]}}`)
+
+	item := exportTestItem{}
+	err := v.ExportAt(&item, "data", "items", 0)
+	so(err, isNil)
+	so(item.Name, eq, "a")
+	so(item.Price, eq, 1.5)
+
+	items := []exportTestItem{}
+	err = v.ExportAt(&items, "data", "items")
+	so(err, isErr)
+	so(err.Error(), eq, "data.items[1].price: expected number, got string")
+
+	err = v.ExportAt(&item, "data", "items", 1, OptExportStrict())
+	so(err, isErr)
+	so(err.Error(), eq, "data.items[1].price: expected number, got string")
+
+	// not found
+	err = v.ExportAt(&item, "data", "items", 5)
+	so(err, isErr)
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+	so(err.Error(), hasSubStr, "data.items[5]: ")
+
+	err = v.ExportAt(&item, "data", 1.5)
+	so(err, isErr)
+
+	// no path at all
+	m := map[string]any{}
+	err = v.ExportAt(&m)
+	so(err, isNil)
+	so(len(m), eq, 1)
+}
+
+func testExportStrict(t *testing.T) {
+	item := exportTestItem{}
+
+	// unknown fields
+	v := MustUnmarshalString(`{"name":"a","price":1,"color":"red"}`)
+	err := v.Export(&item)
+	so(err, isNil)
+	err = v.Export(&item, OptExportStrict())
+	so(err, isErr)
+	so(err.Error(), eq, `(root): unknown field "color" for jsonvalue.exportTestItem`)
+
+	// case-insensitive matching is still allowed
+	err = MustUnmarshalString(`{"NAME":"b"}`).Export(&item, OptExportStrict())
+	so(err, isNil)
+	so(item.Name, eq, "b")
+
+	// maps and interfaces accept any keys
+	order := exportTestOrder{}
+	err = MustUnmarshalString(`{"refs":{"x":{"name":"x"}}}`).Export(&order, OptExportStrict())
+	so(err, isNil)
+	so(order.Refs["x"].Name, eq, "x")
+	err = MustUnmarshalString(`{"refs":{"x":{"size":1}}}`).Export(&order, OptExportStrict())
+	so(err, isErr)
+	so(err.Error(), eq, `refs.x: unknown field "size" for jsonvalue.exportTestItem`)
+
+	// nulls
+	v = MustUnmarshalString(`{"name":null,"price":null}`)
+	err = v.Export(&item)
+	so(err, isNil)
+	err = v.Export(&item, OptExportStrict())
+	so(err, isErr)
+	so(errors.Is(err, ErrTypeNotMatch), isTrue)
+	so(err.Error(), hasSubStr, "expected ")
+	so(err.Error(), hasSubStr, ", got null")
+	err = MustUnmarshalString(`{"refs":{"x":null}}`).Export(&order, OptExportStrict())
+	so(err, isNil)
+	so(order.Refs["x"], isNil)
+
+	// array length
+	v = MustUnmarshalString(`{"index":{"k":[1,2,3]}}`)
+	err = v.Export(&order)
+	so(err, isNil)
+	so(order.Index["k"][1], eq, 2)
+	err = v.Export(&order, OptExportStrict())
+	so(err, isErr)
+	so(errors.Is(err, ErrOutOfRange), isTrue)
+	so(err.Error(), eq, "index.k: expected at most 2 elements, got 3")
+}
@@ -0,0 +1,375 @@
+package jsonvalue
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FlattenArrayNotation defines how array indexes are represented in keys of Flatten() and Unflatten().
+//
+// FlattenArrayNotation 定义 Flatten() 和 Unflatten() 的 key 中如何表示数组下标。
+type FlattenArrayNotation uint8
+
+const (
+	// FlattenArrayBracket is the default notation, where indexes are represented in brackets, such as "a.b[0].c". A
+	// root array is flattened into keys like "[0]".
+	//
+	// FlattenArrayBracket 为默认表示方式，下标以方括号表示，如 "a.b[0].c"。根数组展开后的 key 形如 "[0]"。
+	FlattenArrayBracket FlattenArrayNotation = 0
+	// FlattenArrayDot indicates that indexes are represented as keys, such as "a.b.0.c". Object keys which consist of
+	// digits only are escaped to be distinguished from indexes, such as "a.\0".
+	//
+	// FlattenArrayDot 表示下标与 key 的形式相同，如 "a.b.0.c"。仅由数字组成的 object key 会被转义以区别于下标，如 "a.\0"。
+	FlattenArrayDot FlattenArrayNotation = 1
+)
+
+// Flatten flattens nested objects and arrays into a one-level object, such as {"a": {"b": [{"c": 1}]}} ==>
+// {"a.b[0].c": 1}. Separator and array notation could be configured by OptFlattenSeparator() and
+// OptFlattenArrayNotation(), and OptFlattenMaxDepth() stops flattening at given depth.
+//
+// Empty objects and arrays are kept as values. Backslashes, brackets and separators in keys are escaped by
+// backslash, so that Unflatten() could rebuild the original value with the same options. Keys are flattened in set
+// sequence by default, unless OptKeySequence() or OptKeySequenceWithLessFunc() is given. Values other than non-empty
+// objects and arrays are returned as copies.
+//
+// Flatten 将嵌套的 object 和数组展开为只有一层的 object, 如 {"a": {"b": [{"c": 1}]}} ==> {"a.b[0].c": 1}。分隔符和数组的表示方式
+// 可以通过 OptFlattenSeparator() 和 OptFlattenArrayNotation() 配置，OptFlattenMaxDepth() 则可以在指定深度停止展开。
+//
+// 空 object 和空数组作为值保留。key 中的反斜杠、方括号和分隔符会以反斜杠转义，从而使 Unflatten() 能够使用相同的选项重建原来的值。默认
+// 情况下，key 按照 set 顺序展开，除非指定了 OptKeySequence() 或 OptKeySequenceWithLessFunc()。非空 object 和非空数组以外的值将
+// 返回其副本。
+func (v *V) Flatten(opts ...Option) *V {
+	if v == nil || v.valueType == NotExist {
+		return &V{}
+	}
+	if v.isLeafForFlatten() {
+		return v.deepCopy()
+	}
+
+	opt := combineOptions(opts)
+	if opt.MarshalLessFunc == nil && len(opt.MarshalKeySequence) == 0 {
+		opt.marshalBySetSequence = true
+	}
+	f := newFlattener(opt)
+	res := newObject()
+	f.flatten(res, nil, "", 0, v)
+	return res
+}
+
+// Unflatten rebuilds nested objects and arrays from an object flattened by Flatten(), with the same separator and
+// array notation options. Values are set by Set().At() with auto-creation of objects and arrays, in set sequence of
+// keys, and missing array elements are filled with null.
+//
+// An error is returned if keys conflict with each other, such as "a" and "a.b", or "a[0]" and "a.b". Values other
+// than objects are returned as copies.
+//
+// Unflatten 使用相同的分隔符和数组表示方式选项，从 Flatten() 展开的 object 中重建嵌套的 object 和数组。各值按照 key 的 set 顺序通过
+// Set().At() 设置，自动创建 object 和数组，缺失的数组成员以 null 填充。
+//
+// 如果 key 之间存在冲突，如 "a" 与 "a.b", 或 "a[0]" 与 "a.b", 则返回错误。object 以外的值将返回其副本。
+func (v *V) Unflatten(opts ...Option) (*V, error) {
+	if v == nil || v.valueType == NotExist {
+		return &V{}, ErrValueUninitialized
+	}
+	if v.valueType != Object {
+		return v.deepCopy(), nil
+	}
+
+	f := newFlattener(combineOptions(opts))
+	root := &flatNode{}
+	var err error
+	v.RangeObjectsBySetSequence(func(k string, child *V) bool {
+		var path []any
+		if path, err = f.parseKey(k); err != nil {
+			return false
+		}
+		node := root
+		for _, p := range path {
+			node = node.child(p, k)
+		}
+		if node.value != nil {
+			err = fmt.Errorf("%w: key %q conflicts with %q", ErrTypeNotMatch, k, node.origin)
+			return false
+		}
+		node.value = child
+		return true
+	})
+	if err != nil {
+		return &V{}, err
+	}
+	if len(root.keys) == 0 {
+		return newObject(), nil
+	}
+
+	var res *V
+	if _, isIndex := root.keys[0].(int); isIndex {
+		res = newArray()
+	} else {
+		res = newObject()
+	}
+	b := flatBuilder{res: res, maxIndex: len(v.children.object)}
+	if err := b.build(root, nil); err != nil {
+		return &V{}, err
+	}
+	return res, nil
+}
+
+func (v *V) isLeafForFlatten() bool {
+	switch v.valueType {
+	case Object:
+		return len(v.children.object) == 0
+	case Array:
+		return len(v.children.arr) == 0
+	default:
+		return true
+	}
+}
+
+func (v *V) deepCopy() *V {
+	switch v.valueType {
+	default:
+		res := *v
+		return &res
+
+	case Array:
+		res := newArray()
+		for _, child := range v.children.arr {
+			res.appendToArr(child.deepCopy())
+		}
+		return res
+
+	case Object:
+		res := newObject()
+		v.RangeObjectsBySetSequence(func(k string, child *V) bool {
+			res.setToObjectChildren(k, child.deepCopy())
+			return true
+		})
+		return res
+	}
+}
+
+type flattener struct {
+	opt     *Opt
+	sep     string
+	sepHead string // first character of sep, which is escaped in keys
+	bracket bool
+}
+
+func newFlattener(opt *Opt) *flattener {
+	f := flattener{
+		opt:     opt,
+		sep:     opt.flattenSeparator,
+		bracket: opt.flattenArrayNotation != FlattenArrayDot,
+	}
+	if f.sep == "" {
+		f.sep = "."
+	}
+	_, size := utf8.DecodeRuneInString(f.sep)
+	f.sepHead = f.sep[:size]
+	return &f
+}
+
+func (f *flattener) flatten(res *V, parentInfo *ParentInfo, key string, depth int, v *V) {
+	if v.isLeafForFlatten() || (f.opt.flattenMaxDepth > 0 && depth >= f.opt.flattenMaxDepth) {
+		res.setToObjectChildren(key, v.deepCopy())
+		return
+	}
+
+	if v.valueType == Array {
+		for i, child := range v.children.arr {
+			var par *ParentInfo
+			if f.opt.MarshalLessFunc != nil {
+				par = v.newParentInfo(parentInfo, intKey(i))
+			}
+			k := strconv.Itoa(i)
+			switch {
+			case f.bracket:
+				k = key + "[" + k + "]"
+			case depth > 0:
+				k = key + f.sep + k
+			}
+			f.flatten(res, par, k, depth+1, child)
+		}
+		return
+	}
+
+	keys, values := v.sortedObjectChildren(parentInfo, f.opt)
+	for i, k := range keys {
+		child := values[i]
+		var par *ParentInfo
+		if f.opt.MarshalLessFunc != nil {
+			par = child.newParentInfo(parentInfo, stringKey(k))
+		}
+		k = f.escape(k)
+		if depth > 0 {
+			k = key + f.sep + k
+		}
+		f.flatten(res, par, k, depth+1, child)
+	}
+}
+
+func (f *flattener) escape(k string) string {
+	buf := strings.Builder{}
+	if !f.bracket && isDecimalDigits(k) {
+		buf.WriteByte('\\')
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if c == '\\' || (f.bracket && c == '[') || strings.HasPrefix(k[i:], f.sepHead) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+// parseKey splits a flattened key into object keys and array indexes.
+func (f *flattener) parseKey(k string) ([]any, error) {
+	var path []any
+	name := strings.Builder{}
+	hasName := true
+	escaped := false
+
+	endName := func() {
+		if !hasName {
+			return
+		}
+		s := name.String()
+		if n, ok := flattenIndex(s); ok && !f.bracket && !escaped {
+			path = append(path, n)
+		} else {
+			path = append(path, s)
+		}
+		name.Reset()
+		hasName, escaped = false, false
+	}
+
+	for i := 0; i < len(k); {
+		c := k[i]
+		switch {
+		case c == '\\':
+			if i+1 >= len(k) {
+				return nil, fmt.Errorf("%w: dangling escape character in key %q", ErrIllegalString, k)
+			}
+			_, size := utf8.DecodeRuneInString(k[i+1:])
+			name.WriteString(k[i+1 : i+1+size])
+			hasName, escaped = true, true
+			i += 1 + size
+			continue
+
+		case strings.HasPrefix(k[i:], f.sep):
+			endName()
+			hasName = true
+			i += len(f.sep)
+			continue
+
+		case f.bracket && c == '[':
+			if end := strings.IndexByte(k[i:], ']'); end > 0 {
+				if n, ok := flattenIndex(k[i+1 : i+end]); ok {
+					if i == 0 {
+						hasName = false // index of root array
+					}
+					endName()
+					path = append(path, n)
+					i += end + 1
+					continue
+				}
+			}
+		}
+
+		name.WriteByte(c)
+		hasName = true
+		i++
+	}
+	endName()
+	return path, nil
+}
+
+// flattenIndex parses an array index without leading zeros.
+func flattenIndex(s string) (int, bool) {
+	if s == "" || !isDecimalDigits(s) || (len(s) > 1 && s[0] == '0') {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+type flatNode struct {
+	origin   string // the first flattened key passing this node, for error messages
+	value    *V
+	keys     []any
+	children map[any]*flatNode
+}
+
+func (n *flatNode) child(k any, origin string) *flatNode {
+	if c, exist := n.children[k]; exist {
+		return c
+	}
+	if n.children == nil {
+		n.children = map[any]*flatNode{}
+	}
+	c := &flatNode{origin: origin}
+	n.children[k] = c
+	n.keys = append(n.keys, k)
+	return c
+}
+
+type flatBuilder struct {
+	res      *V
+	maxIndex int
+}
+
+func (b *flatBuilder) build(n *flatNode, path []any) error {
+	if n.value != nil {
+		if len(n.keys) > 0 {
+			return fmt.Errorf("%w: key %q conflicts with %q", ErrTypeNotMatch, n.origin, n.children[n.keys[0]].origin)
+		}
+		_, err := b.res.Set(n.value.deepCopy()).At(path[0], path[1:]...)
+		return err
+	}
+
+	_, isArray := n.keys[0].(int)
+	for _, k := range n.keys[1:] {
+		if _, isIndex := k.(int); isIndex != isArray {
+			return fmt.Errorf("%w: key %q conflicts with %q", ErrTypeNotMatch, n.children[k].origin, n.children[n.keys[0]].origin)
+		}
+	}
+	subPath := func(k any) []any {
+		return append(append(make([]any, 0, len(path)+1), path...), k)
+	}
+
+	if !isArray {
+		for _, k := range n.keys {
+			if err := b.build(n.children[k], subPath(k)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	indexes := make([]int, 0, len(n.keys))
+	for _, k := range n.keys {
+		indexes = append(indexes, k.(int))
+	}
+	sort.Ints(indexes)
+	next := 0
+	for _, i := range indexes {
+		if i >= b.maxIndex {
+			return fmt.Errorf("%w: index %d in key %q is too large", ErrOutOfRange, i, n.children[i].origin)
+		}
+		for ; next < i; next++ {
+			p := subPath(next)
+			if _, err := b.res.Set(NewNull()).At(p[0], p[1:]...); err != nil {
+				return err
+			}
+		}
+		if err := b.build(n.children[i], subPath(i)); err != nil {
+			return err
+		}
+		next = i + 1
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package jsonvalue
+
+import (
+	"errors"
+	"testing"
+)
+
+func testFlatten(t *testing.T) {
+	cv("Flatten", func() { testFlattenFlatten(t) })
+	cv("options", func() { testFlattenOptions(t) })
+	cv("escaping", func() { testFlattenEscaping(t) })
+	cv("Unflatten", func() { testFlattenUnflatten(t) })
+	cv("errors", func() { testFlattenErrors(t) })
+}
+
+func flattenString(s string, opts ...Option) string {
+	return MustUnmarshalString(s).Flatten(opts...).MustMarshalString(OptSetSequence(), OptEscapeSlash(false), OptUTF8())
+}
+
+func unflattenString(s string, opts ...Option) string {
+	v, err := MustUnmarshalString(s).Unflatten(opts...)
+	so(err, isNil)
+	return v.MustMarshalString(OptSetSequence(), OptEscapeSlash(false), OptUTF8())
+}
+
+func testFlattenFlatten(t *testing.T) {
+	s := `{"a":{"b":[{"c":1},2,[3,{}]]},"d":null,"e":{},"f":[],"g":"str"}`
+	so(flattenString(s), eq, `{"a.b[0].c":1,"a.b[1]":2,"a.b[2][0]":3,"a.b[2][1]":{},"d":null,"e":{},"f":[],"g":"str"}`)
+	so(unflattenString(flattenString(s)), eq, s)
+
+	so(flattenString(`[{"a":1},[2]]`), eq, `{"[0].a":1,"[1][0]":2}`)
+	so(unflattenString(`{"[0].a":1,"[1][0]":2}`), eq, `[{"a":1},[2]]`)
+
+	// scalars and empty containers are copied
+	for _, s := range []string{`1.50`, `"s"`, `null`, `true`, `{}`, `[]`} {
+		so(flattenString(s), eq, s)
+		so(unflattenString(s), eq, s)
+	}
+
+	// values are copied
+	v := MustUnmarshalString(`{"a":{"b":{"c":1}}}`)
+	f := v.Flatten(OptFlattenMaxDepth(2))
+	f.MustGet("a.b").SetInt(2).At("c")
+	so(v.MustMarshalString(), eq, `{"a":{"b":{"c":1}}}`)
+
+	so((&V{}).Flatten().ValueType(), eq, NotExist)
+	so((*V)(nil).Flatten().ValueType(), eq, NotExist)
+}
+
+func testFlattenOptions(t *testing.T) {
+	s := `{"a":{"b":[{"c":1},2]},"x":{"y":{"z":true}}}`
+
+	opts := []Option{OptFlattenSeparator("/"), OptFlattenArrayNotation(FlattenArrayDot)}
+	got := flattenString(s, opts...)
+	so(got, eq, `{"a/b/0/c":1,"a/b/1":2,"x/y/z":true}`)
+	so(unflattenString(got, opts...), eq, s)
+	so(flattenString(`[[1]]`, opts...), eq, `{"0/0":1}`)
+	so(unflattenString(`{"0/0":1}`, opts...), eq, `[[1]]`)
+
+	got = flattenString(s, OptFlattenSeparator("::"))
+	so(got, eq, `{"a::b[0]::c":1,"a::b[1]":2,"x::y::z":true}`)
+	so(unflattenString(got, OptFlattenSeparator("::")), eq, s)
+
+	so(flattenString(s, OptFlattenMaxDepth(1)), eq, s)
+	got = flattenString(s, OptFlattenMaxDepth(2))
+	so(got, eq, `{"a.b":[{"c":1},2],"x.y":{"z":true}}`)
+	so(unflattenString(got), eq, s)
+	so(flattenString(s, OptFlattenMaxDepth(3)), eq, `{"a.b[0]":{"c":1},"a.b[1]":2,"x.y.z":true}`)
+	so(flattenString(s, OptFlattenMaxDepth(-1)), eq, flattenString(s))
+
+	so(flattenString(`{"b":{"y":1,"x":2},"a":3}`, OptDefaultStringSequence()), eq, `{"a":3,"b.x":2,"b.y":1}`)
+}
+
+func testFlattenEscaping(t *testing.T) {
+	s := `{"a.b":{"c[0]":1,"d\\e":2,"":{"":3},"0":4,"f]":[5]},"[0]":6,"ü.":7}`
+	got := flattenString(s)
+	so(got, eq, `{"a\\.b.c\\[0]":1,"a\\.b.d\\\\e":2,"a\\.b..":3,"a\\.b.0":4,"a\\.b.f][0]":5,"\\[0]":6,"ü\\.":7}`)
+	so(unflattenString(got), eq, s)
+
+	opts := []Option{OptFlattenArrayNotation(FlattenArrayDot)}
+	got = flattenString(s, opts...)
+	so(got, eq, `{"a\\.b.c[0]":1,"a\\.b.d\\\\e":2,"a\\.b..":3,"a\\.b.\\0":4,"a\\.b.f].0":5,"[0]":6,"ü\\.":7}`)
+	so(unflattenString(got, opts...), eq, s)
+
+	opts = []Option{OptFlattenSeparator("→")}
+	got = flattenString(`{"a→b":{"c":1}}`, opts...)
+	so(got, eq, `{"a\\→b→c":1}`)
+	so(unflattenString(got, opts...), eq, `{"a→b":{"c":1}}`)
+
+	// leading zeros and invalid indexes are keys
+	so(unflattenString(`{"a[01]":1,"b[x]":2,"c[":3}`), eq, `{"a[01]":1,"b[x]":2,"c[":3}`)
+	so(unflattenString(`{"a.01":1}`, OptFlattenArrayNotation(FlattenArrayDot)), eq, `{"a":{"01":1}}`)
+}
+
+func testFlattenUnflatten(t *testing.T) {
+	// out of order and missing indexes
+	so(unflattenString(`{"a[2]":3,"a[0]":1,"b.c":true,"b.d[1].e":"x"}`), eq,
+		`{"a":[1,null,3],"b":{"c":true,"d":[null,{"e":"x"}]}}`)
+
+	// unflattened values are copied
+	v := MustUnmarshalString(`{"a.b":{"c":1}}`)
+	u, err := v.Unflatten()
+	so(err, isNil)
+	u.SetInt(2).At("a", "b", "c")
+	so(v.MustMarshalString(), eq, `{"a.b":{"c":1}}`)
+
+	// plain objects are kept
+	so(unflattenString(`{"a":1,"b":{"c.d":2}}`), eq, `{"a":1,"b":{"c.d":2}}`)
+}
+
+func testFlattenErrors(t *testing.T) {
+	_, err := (&V{}).Unflatten()
+	so(errors.Is(err, ErrValueUninitialized), isTrue)
+
+	for s, target := range map[string]error{
+		`{"a":1,"a.b":2}`:    ErrTypeNotMatch,
+		`{"a.b":1,"a":2}`:    ErrTypeNotMatch,
+		`{"a[0]":1,"a.b":2}`: ErrTypeNotMatch,
+		`{"[0]":1,"b":2}`:    ErrTypeNotMatch,
+		`{"ab":1,"a\\b":2}`:  ErrTypeNotMatch,
+		`{"a\\":1}`:          ErrIllegalString,
+		`{"a[5]":1}`:         ErrOutOfRange,
+	} {
+		v, err := MustUnmarshalString(s).Unflatten()
+		so(err, isErr)
+		so(errors.Is(err, target), isTrue)
+		so(v.ValueType(), eq, NotExist)
+	}
+}
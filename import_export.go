@@ -1,6 +1,7 @@
 package jsonvalue
 
 import (
+	"encoding"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -9,24 +10,103 @@ import (
 )
 
 // Export convert jsonvalue to another type of parameter. The target parameter type should match the type of *V.
+// Export works with reflection directly without encoding/json, while following the same rules of encoding/json,
+// including json tags with ",string" option, json.Unmarshaler, encoding.TextUnmarshaler and json.Number.
+// Integers are exported without float64 conversion, thus no precision lost. Options like OptTypeConverter()
+// are supported.
 //
-// Export 将 *V 转到符合原生 encoding/json 的一个 struct 中。
-func (v *V) Export(dst any) error {
-	b, err := v.Marshal()
-	if err != nil {
-		return err
+// Export 将 *V 转到符合原生 encoding/json 的一个 struct 中。Export 直接使用反射实现而不经过 encoding/json，但遵循与
+// encoding/json 相同的规则，包括带 ",string" 选项的 json 标签、json.Unmarshaler、encoding.TextUnmarshaler 以及 json.Number。
+// 整型数据不经过 float64 转换，因此不会丢失精度。支持 OptTypeConverter() 等选项。
+func (v *V) Export(dst any, opts ...Option) error {
+	return v.exportWithPath(dst, nil, opts)
+}
+
+// ExportAt exports the value at given path into dst, just like v.Get(path...) and then Export(). Options could be
+// mixed in path parameters. Errors returned by ExportAt() and Export() are *ExportError, which carries the full
+// key path where exporting fails, e.g. "data.items[3].price: expected number, got string".
+//
+// ExportAt 将指定路径下的值导出到 dst 中，相当于先调用 v.Get(path...) 再调用 Export()。Option 参数可以混合在路径参数中。
+// ExportAt() 和 Export() 返回的错误类型为 *ExportError, 包含导出失败的完整路径，如
+// "data.items[3].price: expected number, got string"。
+func (v *V) ExportAt(dst any, path ...any) error {
+	var opts []Option
+	var keys []any
+	var keyPath KeyPath
+
+	for _, p := range path {
+		if o, ok := p.(Option); ok {
+			opts = append(opts, o)
+			continue
+		}
+		if s, err := intfToString(p); err == nil {
+			keyPath = appendKeyPath(keyPath, stringKey(s))
+		} else if n, err := intfToInt(p); err == nil {
+			keyPath = appendKeyPath(keyPath, intKey(n))
+		} else {
+			e := exportErrorf(ErrTypeNotMatch, "invalid path parameter %v", p)
+			e.KeyPath = keyPath
+			return e
+		}
+		keys = append(keys, p)
 	}
 
-	return json.Unmarshal(b, dst)
+	if len(keys) > 0 {
+		child, err := v.Get(keys[0], keys[1:]...)
+		if err != nil {
+			e := exportErrorf(err, "%v", err)
+			e.KeyPath = keyPath
+			return e
+		}
+		v = child
+	}
+	return v.exportWithPath(dst, keyPath, opts)
+}
+
+func (v *V) exportWithPath(dst any, path KeyPath, opts []Option) error {
+	if v.valueType == NotExist {
+		return ErrValueUninitialized
+	}
+
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr {
+		return fmt.Errorf("jsonvalue: Export(non-pointer %T)", dst)
+	}
+	if rv.IsNil() {
+		return fmt.Errorf("jsonvalue: Export(nil %T)", dst)
+	}
+
+	opt := combineOptions(opts)
+	ex := newExtFromOptions(opt)
+	ex.exportState = &exportState{
+		path:   path,
+		strict: opt.exportStrict,
+	}
+	return exportToValue(v, rv.Elem(), ex)
 }
 
 // Import convert json value from a marsalable parameter to *V. This a experimental function.
 //
+// Values of *V type in src, including src itself, are not copied but referenced in the result, just like what
+// Set() and Append() do. Therefore modifying them also modifies the result.
+//
 // Import 将符合 encoding/json 的 struct 转为 *V 类型。不经过 encoding/json，并且支持 Option.
+//
+// src 中的 *V 类型值，包括 src 本身，不会被复制，而是在结果中直接引用，与 Set() 和 Append() 的行为一致。因此修改这些值也会修改结果。
 func Import(src any, opts ...Option) (*V, error) {
 	opt := combineOptions(opts)
-	ext := ext{}
-	ext.ignoreOmitempty = opt.ignoreJsonOmitempty
+	ext := newExtFromOptions(opt)
+	ext.state = &importState{
+		maxDepth:    opt.importMaxDepth,
+		cycleAsNull: opt.importCycleAsNull,
+	}
+	if m, ok := src.(map[string]any); ok && ext.canImportPlainValues() {
+		v, err := importPlainMap(m, ext)
+		if err != nil {
+			return &V{}, err
+		}
+		return v, nil
+	}
 	v, fu, err := validateValAndReturnParser(reflect.ValueOf(src), ext)
 	if err != nil {
 		return &V{}, err
@@ -51,6 +131,167 @@ type ext struct {
 
 	// extended jsonvalue options
 	ignoreOmitempty bool
+
+	// fields of embedded struct are promoted, thus json.Marshaler should be ignored
+	ignoreMarshaler bool
+
+	// per-call type converters from OptTypeConverter()
+	converters map[reflect.Type]typeConverter
+
+	// struct tag name from OptImportTag(), "json" if not specified
+	tag string
+
+	// naming strategy for untagged fields from OptFieldNaming()
+	naming FieldNaming
+
+	// shared states in one Import() call
+	state *importState
+
+	// shared states in one Export() or ExportAt() call
+	exportState *exportState
+
+	// number representation in interface{} values from OptInterfaceNumber()
+	interfaceNumber InterfaceNumber
+}
+
+func newExtFromOptions(opt *Opt) ext {
+	return ext{
+		ignoreOmitempty: opt.ignoreJsonOmitempty,
+		converters:      opt.typeConverters,
+		tag:             opt.importTag,
+		naming:          opt.fieldNaming,
+		interfaceNumber: opt.interfaceNumber,
+	}
+}
+
+// inherited returns an ext with per-call options only, which should be passed to children values.
+func (e ext) inherited() ext {
+	return ext{
+		ignoreOmitempty: e.ignoreOmitempty,
+		converters:      e.converters,
+		tag:             e.tag,
+		naming:          e.naming,
+		state:           e.state,
+		exportState:     e.exportState,
+		interfaceNumber: e.interfaceNumber,
+	}
+}
+
+func (e ext) tagName() string {
+	if e.tag == "" {
+		return "json"
+	}
+	return e.tag
+}
+
+// importState holds states shared in one Import() call, which is used to detect reference cycles and to limit
+// nesting depth.
+type importState struct {
+	visiting map[importVisitKey]struct{}
+	path     []Key
+	depth    int
+	refDepth int
+
+	maxDepth    int
+	cycleAsNull bool
+}
+
+// importStartDetectingCyclesAfter is the count of nested references after which they are tracked for cycles, like
+// encoding/json does, so that ordinary acyclic values are imported without hashing every reference. References are
+// always tracked with OptImportCycleAsNull(), as null should be emitted exactly where a cycle starts.
+const importStartDetectingCyclesAfter = 1000
+
+type importVisitKey struct {
+	ptr uintptr
+	t   reflect.Type
+	len int
+}
+
+func (s *importState) pushKey(k Key) {
+	if s != nil {
+		s.path = append(s.path, k)
+	}
+}
+
+func (s *importState) popKey() {
+	if s != nil {
+		s.path = s.path[:len(s.path)-1]
+	}
+}
+
+// keyPath builds KeyPath of current value only when needed, as keys are pushed and popped frequently.
+func (s *importState) keyPath() KeyPath {
+	p := make(KeyPath, len(s.path))
+	for i := range s.path {
+		p[i] = &s.path[i]
+	}
+	return p
+}
+
+func (s *importState) enterContainer() error {
+	if s == nil {
+		return nil
+	}
+	s.depth++
+	if s.maxDepth > 0 && s.depth > s.maxDepth {
+		return fmt.Errorf("%w: depth %d at %s", ErrMaxDepthExceeded, s.maxDepth, s.keyPath().DotString())
+	}
+	return nil
+}
+
+func (s *importState) leaveContainer() {
+	if s != nil {
+		s.depth--
+	}
+}
+
+// enterReference marks a non-nil pointer, map or slice as being visited. If it is already being visited by
+// its ancestors, cycled will be true, with an error if null should not be emitted.
+func (s *importState) enterReference(v reflect.Value) (cycled bool, err error) {
+	if s == nil {
+		return false, nil
+	}
+	s.refDepth++
+	if !s.tracksReference() {
+		return false, nil
+	}
+
+	key := newImportVisitKey(v)
+	if _, exist := s.visiting[key]; exist {
+		s.refDepth--
+		if s.cycleAsNull {
+			return true, nil
+		}
+		return true, fmt.Errorf("%w: type %v at %s", ErrCycleDetected, v.Type(), s.keyPath().DotString())
+	}
+
+	if s.visiting == nil {
+		s.visiting = map[importVisitKey]struct{}{}
+	}
+	s.visiting[key] = struct{}{}
+	return false, nil
+}
+
+func (s *importState) leaveReference(v reflect.Value) {
+	if s == nil {
+		return
+	}
+	if s.tracksReference() {
+		delete(s.visiting, newImportVisitKey(v))
+	}
+	s.refDepth--
+}
+
+func (s *importState) tracksReference() bool {
+	return s.cycleAsNull || s.refDepth > importStartDetectingCyclesAfter
+}
+
+func newImportVisitKey(v reflect.Value) importVisitKey {
+	key := importVisitKey{ptr: v.Pointer(), t: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	return key
 }
 
 func (e ext) shouldOmitEmpty() bool {
@@ -64,6 +305,18 @@ func (e ext) shouldOmitEmpty() bool {
 func validateValAndReturnParser(v reflect.Value, ex ext) (out reflect.Value, fu parserFunc, err error) {
 	out = v
 
+	if v.IsValid() && v.Type() == typeOfJSONValue {
+		fu = parseJSONValue
+		return
+	}
+
+	if fu = validateConverterAndReturnParser(v, ex); fu != nil {
+		return
+	}
+	if fu = validateMarshalerAndReturnParser(v, ex); fu != nil {
+		return
+	}
+
 	switch v.Kind() {
 	default:
 		// 	fallthrough
@@ -97,9 +350,15 @@ func validateValAndReturnParser(v reflect.Value, ex ext) (out reflect.Value, fu
 		return validateValAndReturnParser(v.Elem(), ex)
 
 	case reflect.Map:
-		switch v.Type().Key().Kind() {
+		// like encoding/json, string keys are used directly even if they implement encoding.TextMarshaler
+		kt := v.Type().Key()
+		if kt.Kind() != reflect.String && kt.Implements(textMarshalerType) {
+			fu = parseTextMarshalerMapValue
+			break
+		}
+		switch kt.Kind() {
 		default:
-			err = fmt.Errorf("unsupported key type for a map: %v", v.Type().Key())
+			err = fmt.Errorf("unsupported key type for a map: %v", kt)
 		case reflect.String:
 			fu = parseStringMapValue
 		case reflect.Int, reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8:
@@ -130,6 +389,102 @@ func validateValAndReturnParser(v reflect.Value, ex ext) (out reflect.Value, fu
 	return
 }
 
+var typeOfJSONValue = reflect.TypeOf((*V)(nil))
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// validateMarshalerAndReturnParser returns parser for json.Marshaler or encoding.TextMarshaler types, with the
+// same priority as encoding/json does. Nil will be returned if the value implements neither of them.
+func validateMarshalerAndReturnParser(v reflect.Value, ex ext) parserFunc {
+	if ex.ignoreMarshaler || !v.IsValid() || !v.CanInterface() || v.Kind() == reflect.Interface {
+		return nil
+	}
+
+	t := v.Type()
+	addressable := t.Kind() != reflect.Ptr && v.CanAddr()
+
+	switch {
+	case t.Implements(jsonMarshalerType):
+		return parseJSONMarshalerValue
+	case addressable && reflect.PtrTo(t).Implements(jsonMarshalerType):
+		return func(v reflect.Value, ex ext) (*V, error) {
+			return parseJSONMarshalerValue(v.Addr(), ex)
+		}
+	case t.Implements(textMarshalerType):
+		return parseTextMarshalerValue
+	case addressable && reflect.PtrTo(t).Implements(textMarshalerType):
+		return func(v reflect.Value, ex ext) (*V, error) {
+			return parseTextMarshalerValue(v.Addr(), ex)
+		}
+	default:
+		return nil
+	}
+}
+
+func parseJSONMarshalerValue(v reflect.Value, ex ext) (*V, error) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return parseNullValue(v, ex)
+	}
+	if ex.shouldOmitEmpty() && isEmptyValue(v) {
+		return nil, nil
+	}
+
+	b, err := v.Interface().(json.Marshaler).MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("jsonvalue: error calling MarshalJSON for type %v: %w", v.Type(), err)
+	}
+	res, err := Unmarshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("jsonvalue: error calling MarshalJSON for type %v: %w", v.Type(), err)
+	}
+	return res, nil
+}
+
+func parseTextMarshalerValue(v reflect.Value, ex ext) (*V, error) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return parseNullValue(v, ex)
+	}
+	if ex.shouldOmitEmpty() && isEmptyValue(v) {
+		return nil, nil
+	}
+
+	b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("jsonvalue: error calling MarshalText for type %v: %w", v.Type(), err)
+	}
+	return NewString(string(b)), nil
+}
+
+// isEmptyValue is identical to that of encoding/json, used in omitempty checking of marshalers.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// parseJSONValue returns *V itself without copying, just like what Set() and Append() do.
+func parseJSONValue(v reflect.Value, ex ext) (*V, error) {
+	if v.IsNil() {
+		return parseNullValue(v, ex)
+	}
+	return v.Interface().(*V), nil
+}
+
 func parseInvalidValue(_ reflect.Value, ex ext) (*V, error) {
 	if ex.shouldOmitEmpty() {
 		return nil, nil
@@ -199,8 +554,14 @@ func parseArrayValue(v reflect.Value, ex ext) (*V, error) {
 	res := NewArray()
 	le := v.Len()
 
+	if err := ex.state.enterContainer(); err != nil {
+		return nil, err
+	}
+	defer ex.state.leaveContainer()
+
 	for i := 0; i < le; i++ {
 		vv := v.Index(i)
+		ex.state.pushKey(intKey(i))
 		vv, fu, err := validateValAndReturnParser(vv, ex)
 		if err != nil {
 			return nil, err
@@ -209,13 +570,14 @@ func parseArrayValue(v reflect.Value, ex ext) (*V, error) {
 		if err != nil {
 			return nil, err
 		}
+		ex.state.popKey()
 		res.Append(child).InTheEnd()
 	}
 
 	return res, nil
 }
 
-func parseMapValue(v reflect.Value, ex ext, keyFunc func(key reflect.Value) string) (*V, error) {
+func parseMapValue(v reflect.Value, ex ext, keyFunc func(key reflect.Value) (string, error)) (*V, error) {
 	if v.IsNil() {
 		return parseNullValue(v, ex)
 	}
@@ -228,10 +590,29 @@ func parseMapValue(v reflect.Value, ex ext, keyFunc func(key reflect.Value) stri
 		return NewObject(), nil
 	}
 
+	cycled, err := ex.state.enterReference(v)
+	if err != nil {
+		return nil, err
+	}
+	if cycled {
+		return parseNullValue(v, ex)
+	}
+	defer ex.state.leaveReference(v)
+
+	if err := ex.state.enterContainer(); err != nil {
+		return nil, err
+	}
+	defer ex.state.leaveContainer()
+
 	res := NewObject()
 
 	for _, kk := range keys {
+		k, err := keyFunc(kk)
+		if err != nil {
+			return res, err
+		}
 		vv := v.MapIndex(kk)
+		ex.state.pushKey(stringKey(k))
 		vv, fu, err := validateValAndReturnParser(vv, ex)
 		if err != nil {
 			return res, err
@@ -240,27 +621,41 @@ func parseMapValue(v reflect.Value, ex ext, keyFunc func(key reflect.Value) stri
 		if err != nil {
 			return res, err
 		}
-		res.Set(child).At(keyFunc(kk))
+		ex.state.popKey()
+		res.Set(child).At(k)
 	}
 
 	return res, nil
 }
 
 func parseStringMapValue(v reflect.Value, ex ext) (*V, error) {
-	return parseMapValue(v, ex, func(k reflect.Value) string {
-		return k.String()
+	return parseMapValue(v, ex, func(k reflect.Value) (string, error) {
+		return k.String(), nil
 	})
 }
 
 func parseIntMapValue(v reflect.Value, ex ext) (*V, error) {
-	return parseMapValue(v, ex, func(k reflect.Value) string {
-		return strconv.FormatInt(k.Int(), 10)
+	return parseMapValue(v, ex, func(k reflect.Value) (string, error) {
+		return strconv.FormatInt(k.Int(), 10), nil
 	})
 }
 
 func parseUintMapValue(v reflect.Value, ex ext) (*V, error) {
-	return parseMapValue(v, ex, func(k reflect.Value) string {
-		return strconv.FormatUint(k.Uint(), 10)
+	return parseMapValue(v, ex, func(k reflect.Value) (string, error) {
+		return strconv.FormatUint(k.Uint(), 10), nil
+	})
+}
+
+func parseTextMarshalerMapValue(v reflect.Value, ex ext) (*V, error) {
+	return parseMapValue(v, ex, func(k reflect.Value) (string, error) {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		b, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("jsonvalue: error calling MarshalText for map key type %v: %w", k.Type(), err)
+		}
+		return string(b), nil
 	})
 }
 
@@ -269,6 +664,15 @@ func parsePtrValue(v reflect.Value, ex ext) (*V, error) {
 		return parseNullValue(v, ex)
 	}
 
+	cycled, err := ex.state.enterReference(v)
+	if err != nil {
+		return nil, err
+	}
+	if cycled {
+		return parseNullValue(v, ex)
+	}
+	defer ex.state.leaveReference(v)
+
 	v, fu, err := validateValAndReturnParser(v.Elem(), ex)
 	if err != nil {
 		return nil, err
@@ -285,6 +689,15 @@ func parseSliceValue(v reflect.Value, ex ext) (*V, error) {
 		return NewArray(), nil
 	}
 
+	cycled, err := ex.state.enterReference(v)
+	if err != nil {
+		return nil, err
+	}
+	if cycled {
+		return parseNullValue(v, ex)
+	}
+	defer ex.state.leaveReference(v)
+
 	return parseArrayValue(v, ex)
 }
 
@@ -319,6 +732,11 @@ func parseStructValue(v reflect.Value, ex ext) (*V, error) {
 	t := v.Type()
 	numField := t.NumField()
 
+	if err := ex.state.enterContainer(); err != nil {
+		return nil, err
+	}
+	defer ex.state.leaveContainer()
+
 	res := NewObject()
 
 	for i := 0; i < numField; i++ {
@@ -358,11 +776,14 @@ func parseStructFieldValue(
 		return
 	}
 
-	fieldName, ex := readFieldTag(ft, "json", parentEx)
+	fieldName, ex := readFieldTag(ft, parentEx.tagName(), parentEx)
 	if fieldName == "-" {
 		return
 	}
 
+	ex.state.pushKey(stringKey(fieldName))
+	defer ex.state.popKey()
+
 	fv, fu, err := validateValAndReturnParser(fv, ex)
 	if err != nil {
 		err = fmt.Errorf("parsing field '%s' error: %w", fieldName, err)
@@ -385,10 +806,13 @@ func parseStructAnonymousFieldValue(
 	fv reflect.Value, ft reflect.StructField, parentEx ext,
 ) (keys []string, children []*V, err error) {
 
-	fieldName, ex := readAnonymousFieldTag(ft, "json", parentEx)
+	fieldName, ex := readAnonymousFieldTag(ft, parentEx.tagName(), parentEx)
 	if fieldName == "-" {
 		return nil, nil, nil
 	}
+	if isPromotedStructField(ft, parentEx.tagName()) {
+		ex.ignoreMarshaler = true
+	}
 	if fv.Kind() == reflect.Ptr && fv.IsNil() {
 		if ex.shouldOmitEmpty() {
 			return nil, nil, nil
@@ -434,10 +858,9 @@ func parseStructAnonymousFieldValue(
 func readFieldTag(ft reflect.StructField, name string, parentEx ext) (field string, ex ext) {
 	tg := ft.Tag.Get(name)
 
+	ex = parentEx.inherited()
 	if tg == "" {
-		return ft.Name, ext{
-			ignoreOmitempty: parentEx.ignoreOmitempty,
-		}
+		return parentEx.naming.convert(ft.Name), ex
 	}
 
 	parts := strings.Split(tg, ",")
@@ -454,12 +877,24 @@ func readFieldTag(ft reflect.StructField, name string, parentEx ext) (field stri
 
 	field = parts[0]
 	if field == "" {
-		field = ft.Name
+		field = parentEx.naming.convert(ft.Name)
 	}
-	ex.ignoreOmitempty = parentEx.ignoreOmitempty
 	return
 }
 
+// isPromotedStructField tells whether fields of an anonymous field should be promoted, that is, an embedded struct
+// or struct pointer without name in tag.
+func isPromotedStructField(ft reflect.StructField, name string) bool {
+	if strings.Split(ft.Tag.Get(name), ",")[0] != "" {
+		return false
+	}
+	t := ft.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
 func readAnonymousFieldTag(ft reflect.StructField, name string, parentEx ext) (field string, ex ext) {
 	field, ex = readFieldTag(ft, name, parentEx)
 
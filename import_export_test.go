@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 	"unsafe"
+
+	"github.com/shopspring/decimal"
 )
 
 func testStructConv(t *testing.T) {
@@ -22,6 +26,10 @@ func testStructConv(t *testing.T) {
 	cv("test Issue 22", func() { testImportBugIssue22(t) })
 
 	cv("test miscellaneous anonymous situations", func() { testImportMiscAnonymous(t) })
+
+	cv("test json.Marshaler and encoding.TextMarshaler", func() { testImportMarshalers(t) })
+
+	cv("test reference cycles and depth limit", func() { testImportCycles(t) })
 }
 
 func testExportString(t *testing.T) {
@@ -203,6 +211,18 @@ func testImport(t *testing.T) {
 		so(v, notNil)
 		so(v.ValueType(), eq, NotExist)
 	})
+
+	cv("*V referenced", func() {
+		child := NewObject()
+		v, err := Import(M{"child": child})
+		so(err, isNil)
+		child.SetInt(1).At("a")
+		so(v.MustMarshalString(), eq, `{"child":{"a":1}}`)
+
+		v, err = Import(child)
+		so(err, isNil)
+		so(v == child, isTrue)
+	})
 }
 
 func testStructConv_Import(t *testing.T) {
@@ -980,3 +1000,203 @@ func testImportMiscAnonymousInvalidTypes(t *testing.T) {
 	_, err = Import(data)
 	so(err, isErr)
 }
+
+type importTestEnum int
+
+func (e importTestEnum) MarshalJSON() ([]byte, error) {
+	if e < 0 {
+		return nil, errors.New("negative enum")
+	}
+	return []byte(fmt.Sprintf(`{"enum": %d}`, int(e))), nil
+}
+
+type importTestText struct {
+	A, B string
+}
+
+func (t *importTestText) MarshalText() ([]byte, error) {
+	if t.A == "" {
+		return nil, errors.New("empty text")
+	}
+	return []byte(t.A + "-" + t.B), nil
+}
+
+type importTestKey string
+
+func (k importTestKey) MarshalText() ([]byte, error) {
+	return []byte("key_" + string(k)), nil
+}
+
+type importTestTextKey struct {
+	ID int
+}
+
+func (k importTestTextKey) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("id_%d", k.ID)), nil
+}
+
+type importTestEmbeddedTime struct {
+	time.Time
+}
+
+type importTestMarshalers struct {
+	Time     time.Time                 `json:"time"`
+	TimePtr  *time.Time                `json:"time_ptr"`
+	NilTime  *time.Time                `json:"nil_time"`
+	Decimal  decimal.Decimal           `json:"decimal"`
+	Enum     importTestEnum            `json:"enum"`
+	Omitted  importTestEnum            `json:"omitted,omitempty"`
+	Text     importTestText            `json:"text"`
+	TextKeys map[importTestTextKey]int `json:"text_keys"`
+	Embedded importTestEmbeddedTime    `json:"embedded"`
+	Raw      json.RawMessage           `json:"raw"`
+}
+
+func testImportMarshalers(t *testing.T) {
+	now := time.Now()
+	st := &importTestMarshalers{
+		Time:     now,
+		TimePtr:  &now,
+		Decimal:  decimal.RequireFromString("12345678901234567890.123456789"),
+		Enum:     3,
+		Text:     importTestText{"a", "b"},
+		TextKeys: map[importTestTextKey]int{{ID: 1}: 1, {ID: 2}: 2},
+		Embedded: importTestEmbeddedTime{now},
+		Raw:      json.RawMessage(`[1, 2, 3]`),
+	}
+
+	b, err := json.Marshal(st)
+	so(err, isNil)
+	expected := MustUnmarshal(b)
+
+	v, err := Import(st)
+	so(err, isNil)
+	so(v.Equal(expected), isTrue)
+
+	so(v.MustGet("time").String(), eq, now.Format(time.RFC3339Nano))
+	so(v.MustGet("nil_time").IsNull(), isTrue)
+	so(v.MustGet("decimal").String(), eq, "12345678901234567890.123456789")
+	so(v.MustGet("enum", "enum").Int(), eq, 3)
+	so(v.MustGet("omitted").ValueType(), eq, NotExist)
+	so(v.MustGet("text").String(), eq, "a-b")
+	so(v.MustGet("text_keys", "id_2").Int(), eq, 2)
+	so(v.MustGet("embedded").IsString(), isTrue)
+
+	// values which are not addressable cannot use pointer receivers
+	v = New(importTestText{"a", "b"})
+	so(v.IsObject(), isTrue)
+	v = New(&importTestText{"a", "b"})
+	so(v.String(), eq, "a-b")
+
+	// like encoding/json, keys of string kinds are used directly even if they implement encoding.TextMarshaler
+	v, err = Import(map[importTestKey]int{"a": 1})
+	so(err, isNil)
+	so(v.MustMarshalString(), eq, `{"a":1}`)
+	v, err = Import(struct {
+		M map[importTestKey]string `json:"m"`
+	}{M: map[importTestKey]string{"b": "B"}})
+	so(err, isNil)
+	so(v.MustGet("m", "b").String(), eq, "B")
+	so(v.MustGet("m", "key_b").ValueType(), eq, NotExist)
+
+	// errors
+	_, err = Import(importTestEnum(-1))
+	so(err, isErr)
+	_, err = Import(&importTestText{})
+	so(err, isErr)
+	_, err = Import(map[*importTestText]int{{}: 1})
+	so(err, isErr)
+}
+
+type importTestNode struct {
+	Name     string            `json:"name"`
+	Parent   *importTestNode   `json:"parent,omitempty"`
+	Children []*importTestNode `json:"children,omitempty"`
+}
+
+func testImportCycles(t *testing.T) {
+	cv("pointer cycle", func() {
+		root := &importTestNode{Name: "root"}
+		child := &importTestNode{Name: "child", Parent: root}
+		root.Children = []*importTestNode{child}
+
+		_, err := Import(root)
+		so(err, isErr)
+		so(errors.Is(err, ErrCycleDetected), isTrue)
+		so(err.Error(), hasSubStr, "children[0].parent")
+		t.Log("expected error:", err)
+
+		v, err := Import(root, OptImportCycleAsNull())
+		so(err, isNil)
+		so(v.MustMarshalString(OptSetSequence()), eq,
+			`{"name":"root","children":[{"name":"child"}]}`)
+
+		// self reference
+		self := &importTestNode{Name: "self"}
+		self.Parent = self
+		_, err = Import(self)
+		so(errors.Is(err, ErrCycleDetected), isTrue)
+		so(err.Error(), hasSubStr, "at parent")
+
+		_, err = Import(map[string]any{"data": self})
+		so(errors.Is(err, ErrCycleDetected), isTrue)
+		so(err.Error(), hasSubStr, "at data.parent")
+	})
+
+	cv("shared pointers are not cycles", func() {
+		shared := &importTestNode{Name: "shared"}
+		arr := []*importTestNode{shared, shared}
+		v, err := Import(arr)
+		so(err, isNil)
+		so(v.MustMarshalString(), eq, `[{"name":"shared"},{"name":"shared"}]`)
+
+		// references are tracked only in deep levels, acyclic values deeper than that should be fine
+		var node *importTestNode
+		for i := 0; i < importStartDetectingCyclesAfter*2; i++ {
+			node = &importTestNode{Name: strconv.Itoa(i), Parent: node}
+		}
+		v, err = Import(node)
+		so(err, isNil)
+		so(v.MustGet("name").String(), eq, strconv.Itoa(importStartDetectingCyclesAfter*2-1))
+	})
+
+	cv("map and slice cycles", func() {
+		m := map[string]any{}
+		m["self"] = m
+		_, err := Import(m)
+		so(errors.Is(err, ErrCycleDetected), isTrue)
+		so(err.Error(), hasSubStr, "at self")
+
+		v, err := Import(m, OptImportCycleAsNull())
+		so(err, isNil)
+		so(v.MustMarshalString(), eq, `{"self":null}`)
+
+		s := make([]any, 1)
+		s[0] = s
+		_, err = Import(s)
+		so(errors.Is(err, ErrCycleDetected), isTrue)
+		so(err.Error(), hasSubStr, "at [0]")
+	})
+
+	cv("depth limit", func() {
+		var node *importTestNode
+		for i := 0; i < 5; i++ {
+			node = &importTestNode{Name: strconv.Itoa(i), Parent: node}
+		}
+
+		_, err := Import(node, OptImportMaxDepth(5))
+		so(err, isNil)
+
+		_, err = Import(node, OptImportMaxDepth(4))
+		so(err, isErr)
+		so(errors.Is(err, ErrMaxDepthExceeded), isTrue)
+		so(err.Error(), hasSubStr, "at parent.parent.parent.parent")
+
+		_, err = Import([][]int{{1}}, OptImportMaxDepth(1))
+		so(errors.Is(err, ErrMaxDepthExceeded), isTrue)
+		so(err.Error(), hasSubStr, "at [0]")
+
+		_, err = Import(node, OptImportMaxDepth(-1))
+		so(err, isNil)
+	})
+}
@@ -0,0 +1,225 @@
+package jsonvalue
+
+// JSONSchemaDraft202012 is the "$schema" URI of JSON Schema draft 2020-12, which is used in InferSchema().
+//
+// JSONSchemaDraft202012 是 JSON Schema draft 2020-12 的 "$schema" URI, 用于 InferSchema()。
+const JSONSchemaDraft202012 = "https://json-schema.org/draft/2020-12/schema"
+
+const (
+	// strings with no more than this count of distinct values are treated as enums
+	inferSchemaMaxEnum = 5
+)
+
+// InferSchema infers a JSON Schema (draft 2020-12) which describes the structure of given samples. It covers types,
+// properties of objects with keys presenting in all samples as required, items of arrays, ranges of numbers, and
+// enums for strings with low cardinality, that is, at most 5 distinct values with at least one of them repeated.
+// Nil or non-existing samples are ignored.
+//
+// The result is a draft, which is expected to be reviewed and edited before being used in validation.
+//
+// InferSchema 推断一个描述给定样本结构的 JSON Schema (draft 2020-12)。内容涵盖类型、object 的属性（在所有样本中均出现的键视为必需）、
+// 数组元素、数字的范围，以及低基数字符串的枚举值，即最多 5 个不同的值并且至少有一个值重复出现。nil 或不存在的样本将被忽略。
+//
+// 返回结果仅是一份草稿，在用于校验之前，应当经过人工检查和编辑。
+func InferSchema(samples ...*V) *V {
+	root := &schemaInfo{}
+	for _, v := range samples {
+		if v == nil || v.valueType == NotExist || v.valueType == Unknown {
+			continue
+		}
+		root.merge(v)
+	}
+
+	res := NewObject()
+	res.SetString(JSONSchemaDraft202012).At("$schema")
+	root.fillSchema(res)
+	return res
+}
+
+// schemaInfo collects every kind of values seen at the same position among samples.
+type schemaInfo struct {
+	null    bool
+	boolean bool
+
+	number   bool
+	floated  bool
+	min, max *V
+
+	str       bool
+	strCount  int
+	strValues []string // distinct values in sequence of first appearance, at most inferSchemaMaxEnum + 1 values
+
+	object  bool
+	objects int
+	props   map[string]*schemaProp
+	keys    []string
+
+	array bool
+	items *schemaInfo
+}
+
+type schemaProp struct {
+	schemaInfo
+	present int
+}
+
+func (s *schemaInfo) merge(v *V) {
+	switch v.valueType {
+	default:
+		s.null = true
+
+	case Boolean:
+		s.boolean = true
+
+	case Number:
+		s.number = true
+		if v.isFloatNumber() {
+			s.floated = true
+		}
+		if len(v.srcByte) == 0 {
+			// NaN or Inf
+			break
+		}
+		if s.min == nil || numberCompare(v, s.min) < 0 {
+			s.min = v
+		}
+		if s.max == nil || numberCompare(v, s.max) > 0 {
+			s.max = v
+		}
+
+	case String:
+		s.str = true
+		s.strCount++
+		if len(s.strValues) > inferSchemaMaxEnum {
+			break
+		}
+		for _, str := range s.strValues {
+			if str == v.valueStr {
+				return
+			}
+		}
+		s.strValues = append(s.strValues, v.valueStr)
+
+	case Object:
+		s.object = true
+		s.objects++
+		if s.props == nil {
+			s.props = map[string]*schemaProp{}
+		}
+		v.RangeObjectsBySetSequence(func(k string, child *V) bool {
+			p, exist := s.props[k]
+			if !exist {
+				p = &schemaProp{}
+				s.props[k] = p
+				s.keys = append(s.keys, k)
+			}
+			p.present++
+			p.merge(child)
+			return true
+		})
+
+	case Array:
+		s.array = true
+		if s.items == nil {
+			s.items = &schemaInfo{}
+		}
+		for _, child := range v.children.arr {
+			s.items.merge(child)
+		}
+	}
+}
+
+func (s *schemaInfo) types() []string {
+	var types []string
+	if s.object {
+		types = append(types, "object")
+	}
+	if s.array {
+		types = append(types, "array")
+	}
+	if s.str {
+		types = append(types, "string")
+	}
+	if s.number {
+		if s.floated {
+			types = append(types, "number")
+		} else {
+			types = append(types, "integer")
+		}
+	}
+	if s.boolean {
+		types = append(types, "boolean")
+	}
+	if s.null {
+		types = append(types, "null")
+	}
+	return types
+}
+
+func (s *schemaInfo) schema() *V {
+	res := NewObject()
+	s.fillSchema(res)
+	return res
+}
+
+// fillSchema sets schema keywords into given object.
+func (s *schemaInfo) fillSchema(res *V) {
+	types := s.types()
+	switch len(types) {
+	case 0:
+		// nothing observed, any value is acceptable
+		return
+	case 1:
+		res.SetString(types[0]).At("type")
+	default:
+		arr := NewArray()
+		for _, t := range types {
+			arr.AppendString(t).InTheEnd()
+		}
+		res.Set(arr).At("type")
+	}
+
+	if s.object {
+		props := NewObject()
+		required := NewArray()
+		for _, k := range s.keys {
+			p := s.props[k]
+			props.Set(p.schema()).At(k)
+			if p.present == s.objects {
+				required.AppendString(k).InTheEnd()
+			}
+		}
+		res.Set(props).At("properties")
+		if required.Len() > 0 {
+			res.Set(required).At("required")
+		}
+	}
+
+	if s.array && s.items != nil && len(s.items.types()) > 0 {
+		res.Set(s.items.schema()).At("items")
+	}
+
+	onlyStrings := len(types) == 1 || (len(types) == 2 && s.null)
+	if s.str && onlyStrings && len(s.strValues) <= inferSchemaMaxEnum && s.strCount > len(s.strValues) {
+		enum := NewArray()
+		for _, str := range s.strValues {
+			enum.AppendString(str).InTheEnd()
+		}
+		if s.null {
+			enum.AppendNull().InTheEnd()
+		}
+		res.Set(enum).At("enum")
+	}
+
+	if s.min != nil {
+		res.Set(copyNumber(s.min)).At("minimum")
+		res.Set(copyNumber(s.max)).At("maximum")
+	}
+}
+
+func copyNumber(v *V) *V {
+	res := new(Number)
+	res.num = v.num
+	res.srcByte = append([]byte{}, v.srcByte...)
+	return res
+}
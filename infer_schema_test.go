@@ -0,0 +1,75 @@
+package jsonvalue
+
+import (
+	"testing"
+)
+
+func testInferSchema(t *testing.T) {
+	cv("objects and arrays", func() { testInferSchemaObjects(t) })
+	cv("scalars", func() { testInferSchemaScalars(t) })
+	cv("edge cases", func() { testInferSchemaEdgeCases(t) })
+}
+
+func testInferSchemaObjects(t *testing.T) {
+	s1 := MustUnmarshalString(`{"id":1,"status":"paid","items":[{"sku":"a","price":1.5}],"note":"x"}`)
+	s2 := MustUnmarshalString(`{"id":20,"status":"paid","items":[{"sku":"b","price":3}],"coupon":null}`)
+
+	schema := InferSchema(s1, s2)
+	so(schema.MustMarshalString(OptSetSequence(), OptEscapeSlash(false)), eq, `{"$schema":"https://json-schema.org/draft/2020-12/schema",`+
+		`"type":"object","properties":{`+
+		`"id":{"type":"integer","minimum":1,"maximum":20},`+
+		`"status":{"type":"string","enum":["paid"]},`+
+		`"items":{"type":"array","items":{"type":"object","properties":{`+
+		`"sku":{"type":"string"},`+
+		`"price":{"type":"number","minimum":1.5,"maximum":3}},`+
+		`"required":["sku","price"]}},`+
+		`"note":{"type":"string"},`+
+		`"coupon":{"type":"null"}},`+
+		`"required":["id","status","items"]}`)
+}
+
+func testInferSchemaScalars(t *testing.T) {
+	// mixed types
+	schema := InferSchema(
+		MustUnmarshalString(`["a",1,true,null,"a"]`),
+		MustUnmarshalString(`[-1234567890123,{}]`),
+	)
+	so(schema.MustGet("items", "type").MustMarshalString(), eq, `["object","string","integer","boolean","null"]`)
+	so(schema.MustGet("items", "minimum").String(), eq, "-1234567890123")
+	so(schema.MustGet("items", "maximum").String(), eq, "1")
+	so(schema.MustGet("items", "enum").ValueType(), eq, NotExist)
+
+	// numbers created by NewFloat64() are floats unless integral
+	arr := NewArray()
+	arr.AppendFloat64(1.5).InTheEnd()
+	so(InferSchema(arr).MustGet("items", "type").String(), eq, "number")
+	arr = NewArray()
+	arr.AppendFloat64(2).InTheEnd()
+	so(InferSchema(arr).MustGet("items", "type").String(), eq, "integer")
+
+	// enums
+	schema = InferSchema(MustUnmarshalString(`["x","y","x",null]`))
+	so(schema.MustGet("items", "enum").MustMarshalString(), eq, `["x","y",null]`)
+
+	schema = InferSchema(MustUnmarshalString(`["a","b","c","d","e","f","a"]`))
+	so(schema.MustGet("items", "enum").ValueType(), eq, NotExist)
+
+	schema = InferSchema(MustUnmarshalString(`["a","b"]`))
+	so(schema.MustGet("items", "enum").ValueType(), eq, NotExist)
+}
+
+func testInferSchemaEdgeCases(t *testing.T) {
+	schema := InferSchema()
+	so(schema.MustMarshalString(OptEscapeSlash(false)), eq, `{"$schema":"https://json-schema.org/draft/2020-12/schema"}`)
+
+	schema = InferSchema(nil, &V{})
+	so(schema.Len(), eq, 1)
+
+	schema = InferSchema(MustUnmarshalString(`{"arr":[]}`))
+	so(schema.MustGet("properties", "arr").MustMarshalString(), eq, `{"type":"array"}`)
+
+	schema = InferSchema(MustUnmarshalString(`[]`), MustUnmarshalString(`{}`))
+	so(schema.MustGet("type").MustMarshalString(), eq, `["object","array"]`)
+	so(schema.MustGet("properties").Len(), eq, 0)
+	so(schema.MustGet("required").ValueType(), eq, NotExist)
+}
@@ -0,0 +1,224 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+
+	"github.com/shopspring/decimal"
+)
+
+// InterfaceNumber defines how numbers are represented when converting *V into plain Go values, used in
+// OptInterfaceNumber().
+//
+// InterfaceNumber 定义了将 *V 转为原生 Go 值时数字的表示方式，用于 OptInterfaceNumber()。
+type InterfaceNumber int
+
+const (
+	// NumberAsFloat64 represents numbers as float64, which is the default behavior just like encoding/json.
+	//
+	// NumberAsFloat64 将数字表示为 float64, 这也是与 encoding/json 一致的默认行为。
+	NumberAsFloat64 InterfaceNumber = iota
+	// NumberAsJSONNumber represents numbers as json.Number with their original text.
+	//
+	// NumberAsJSONNumber 将数字表示为 json.Number, 并保留其原始文本。
+	NumberAsJSONNumber
+	// NumberAsInt64 represents integral values in int64 range as int64, including those in float notations like
+	// 1.0 or 1e3, and others as float64. Positive integers exceeding int64 are represented as uint64.
+	//
+	// NumberAsInt64 将 int64 范围内的整数值表示为 int64 (包括 1.0 或 1e3 这样以浮点形式书写的整数值), 其他数字表示为
+	// float64。超出 int64 范围的正整数则表示为 uint64。
+	NumberAsInt64
+	// NumberAsDecimal represents numbers as decimal.Decimal from github.com/shopspring/decimal, without any precision
+	// lost. NaN and Inf values are still represented as float64.
+	//
+	// NumberAsDecimal 将数字表示为 github.com/shopspring/decimal 中的 decimal.Decimal 类型，不会丢失精度。NaN 和 Inf 值依然
+	// 表示为 float64。
+	NumberAsDecimal
+)
+
+// Interface converts *V into plain Go values natively, without encoding/json. Objects are converted into
+// map[string]any, arrays into []any, strings into string, booleans into bool and null into nil. Numbers are
+// float64 by default, which could be configured by OptInterfaceNumber(). Nil will be returned if v does not exist.
+//
+// Interface 不经过 encoding/json, 直接将 *V 转为原生 Go 值。object 转为 map[string]any, 数组转为 []any, 字符串转为 string,
+// 布尔值转为 bool, null 转为 nil。数字默认转为 float64, 可以通过 OptInterfaceNumber() 进行配置。如果 v 不存在，则返回 nil。
+func (v *V) Interface(opts ...Option) any {
+	if v == nil {
+		return nil
+	}
+	opt := combineOptions(opts)
+	return v.toInterface(opt.interfaceNumber)
+}
+
+func (v *V) toInterface(n InterfaceNumber) any {
+	switch v.valueType {
+	default:
+		return nil
+	case String:
+		return v.valueStr
+	case Number:
+		return v.numberToInterface(n)
+	case Boolean:
+		return v.valueBool
+	case Object:
+		m := make(map[string]any, len(v.children.object))
+		for k, child := range v.children.object {
+			m[k] = child.v.toInterface(n)
+		}
+		return m
+	case Array:
+		arr := make([]any, 0, len(v.children.arr))
+		for _, child := range v.children.arr {
+			arr = append(arr, child.toInterface(n))
+		}
+		return arr
+	}
+}
+
+func (v *V) numberToInterface(n InterfaceNumber) any {
+	switch n {
+	default:
+		return v.Float64()
+	case NumberAsJSONNumber:
+		return json.Number(v.String())
+	case NumberAsInt64:
+		if v.isFloatNumber() {
+			// math.MaxInt64 is rounded to 2^63 in float64
+			if f := v.Float64(); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+				return int64(f)
+			}
+			return v.Float64()
+		}
+		if !v.num.negative && v.num.u64 > math.MaxInt64 {
+			return v.num.u64
+		}
+		return v.num.i64
+	case NumberAsDecimal:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return f
+		}
+		if d, err := decimal.NewFromString(v.String()); err == nil {
+			return d
+		}
+		return decimal.NewFromFloat(f)
+	}
+}
+
+// importPlainMap is the fast path of Import() for map[string]any, which skips general reflection for values
+// commonly seen in map[string]any, such as values from encoding/json.
+func importPlainMap(m map[string]any, ex ext) (*V, error) {
+	if m == nil {
+		return NewNull(), nil
+	}
+	if len(m) == 0 {
+		return NewObject(), nil
+	}
+
+	rv := reflect.ValueOf(m)
+	cycled, err := ex.state.enterReference(rv)
+	if err != nil {
+		return nil, err
+	}
+	if cycled {
+		return NewNull(), nil
+	}
+	defer ex.state.leaveReference(rv)
+
+	if err := ex.state.enterContainer(); err != nil {
+		return nil, err
+	}
+	defer ex.state.leaveContainer()
+
+	res := NewObject()
+	for k, val := range m {
+		ex.state.pushKey(stringKey(k))
+		child, err := importPlainValue(val, ex)
+		if err != nil {
+			return nil, err
+		}
+		ex.state.popKey()
+		if child != nil {
+			res.Set(child).At(k)
+		}
+	}
+	return res, nil
+}
+
+func importPlainSlice(arr []any, ex ext) (*V, error) {
+	if len(arr) == 0 {
+		return NewArray(), nil
+	}
+
+	rv := reflect.ValueOf(arr)
+	cycled, err := ex.state.enterReference(rv)
+	if err != nil {
+		return nil, err
+	}
+	if cycled {
+		return NewNull(), nil
+	}
+	defer ex.state.leaveReference(rv)
+
+	if err := ex.state.enterContainer(); err != nil {
+		return nil, err
+	}
+	defer ex.state.leaveContainer()
+
+	res := NewArray()
+	for i, val := range arr {
+		ex.state.pushKey(intKey(i))
+		child, err := importPlainValue(val, ex)
+		if err != nil {
+			return nil, err
+		}
+		ex.state.popKey()
+		if child != nil {
+			res.Append(child).InTheEnd()
+		}
+	}
+	return res, nil
+}
+
+func importPlainValue(val any, ex ext) (*V, error) {
+	switch val := val.(type) {
+	case nil:
+		return NewNull(), nil
+	case string:
+		return NewString(val), nil
+	case bool:
+		return NewBool(val), nil
+	case float64:
+		return NewFloat64(val), nil
+	case int:
+		return NewInt(val), nil
+	case int64:
+		return NewInt64(val), nil
+	case map[string]any:
+		return importPlainMap(val, ex)
+	case []any:
+		return importPlainSlice(val, ex)
+	case *V:
+		if val == nil {
+			return NewNull(), nil
+		}
+		return val, nil
+	}
+
+	// otherwise, fallback to general reflection
+	rv, fu, err := validateValAndReturnParser(reflect.ValueOf(val), ex)
+	if err != nil {
+		return nil, err
+	}
+	return fu(rv, ex)
+}
+
+// canImportPlainValues tells whether the fast path of Import() could be applied. Since values in the fast path
+// are not passed to type converters, it is disabled once any converter exists.
+func (e ext) canImportPlainValues() bool {
+	if len(e.converters) > 0 {
+		return false
+	}
+	return len(typeConverterRegistry.Load().(map[reflect.Type]typeConverter)) == 0
+}
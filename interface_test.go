@@ -0,0 +1,160 @@
+package jsonvalue
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func testInterface(t *testing.T) {
+	cv("plain values", func() { testInterfacePlainValues(t) })
+	cv("number options", func() { testInterfaceNumbers(t) })
+	cv("export into interface", func() { testInterfaceExport(t) })
+	cv("import fast path", func() { testInterfaceImportFastPath(t) })
+}
+
+func testInterfacePlainValues(t *testing.T) {
+	v := MustUnmarshalString(`{"s":"str","b":true,"n":null,"arr":[1,"2",[]],"obj":{"f":1.5}}`)
+
+	res := v.Interface()
+	expected := map[string]any{
+		"s":   "str",
+		"b":   true,
+		"n":   nil,
+		"arr": []any{float64(1), "2", []any{}},
+		"obj": map[string]any{"f": 1.5},
+	}
+	so(reflect.DeepEqual(res, expected), isTrue)
+
+	var nilV *V
+	so(nilV.Interface(), isNil)
+	so((&V{}).Interface(), isNil)
+	so(NewNull().Interface(), isNil)
+	so(NewString("s").Interface(), eq, "s")
+}
+
+func testInterfaceNumbers(t *testing.T) {
+	v := MustUnmarshalString(`[1,-2,1.50,1e2,18446744073709551615,12345678901234567890.123]`)
+
+	res := v.Interface().([]any)
+	so(res[0], eq, float64(1))
+	so(res[2], eq, 1.5)
+
+	res = v.Interface(OptInterfaceNumber(NumberAsJSONNumber)).([]any)
+	so(res[0], eq, json.Number("1"))
+	so(res[2], eq, json.Number("1.50"))
+	so(res[5], eq, json.Number("12345678901234567890.123"))
+
+	res = v.Interface(OptInterfaceNumber(NumberAsInt64)).([]any)
+	so(res[0], eq, int64(1))
+	so(res[1], eq, int64(-2))
+	so(res[2], eq, 1.5)
+	so(res[3], eq, int64(100))
+	so(res[4], eq, uint64(math.MaxUint64))
+	so(res[5], eq, 12345678901234567890.123)
+
+	// integral values are int64 regardless of notations
+	integral := MustUnmarshalString(`[1.0,-1e3,-0.0,1e19]`).Interface(OptInterfaceNumber(NumberAsInt64)).([]any)
+	so(integral[0], eq, int64(1))
+	so(integral[1], eq, int64(-1000))
+	so(integral[2], eq, int64(0))
+	so(integral[3], eq, float64(1e19))
+	so(NewFloat64(3).Interface(OptInterfaceNumber(NumberAsInt64)), eq, int64(3))
+	so(NewFloat64(-2.5).Interface(OptInterfaceNumber(NumberAsInt64)), eq, -2.5)
+	so(math.IsInf(NewFloat64(math.Inf(1)).Interface(OptInterfaceNumber(NumberAsInt64)).(float64), 1), isTrue)
+
+	res = v.Interface(OptInterfaceNumber(NumberAsDecimal)).([]any)
+	d := res[5].(decimal.Decimal)
+	so(d.String(), eq, "12345678901234567890.123")
+	so(res[1].(decimal.Decimal).IntPart(), eq, -2)
+
+	f := NewFloat64(math.NaN()).Interface(OptInterfaceNumber(NumberAsDecimal))
+	so(math.IsNaN(f.(float64)), isTrue)
+	so(NewInt(3).Interface(OptInterfaceNumber(NumberAsDecimal)).(decimal.Decimal).IntPart(), eq, 3)
+}
+
+func testInterfaceExport(t *testing.T) {
+	v := MustUnmarshalString(`{"id":12345678901234567,"price":9.99}`)
+
+	var m map[string]any
+	err := v.Export(&m)
+	so(err, isNil)
+	so(m["id"], eq, float64(12345678901234567))
+
+	err = v.Export(&m, OptInterfaceNumber(NumberAsInt64))
+	so(err, isNil)
+	so(m["id"], eq, int64(12345678901234567))
+	so(m["price"], eq, 9.99)
+
+	var i any
+	err = v.Export(&i, OptInterfaceNumber(NumberAsJSONNumber))
+	so(err, isNil)
+	so(i.(map[string]any)["price"], eq, json.Number("9.99"))
+}
+
+func testInterfaceImportFastPath(t *testing.T) {
+	m := map[string]any{
+		"s":    "str",
+		"b":    false,
+		"n":    nil,
+		"i":    1,
+		"i64":  int64(-2),
+		"f":    1.5,
+		"arr":  []any{1, "2", map[string]any{}, []any{}},
+		"obj":  map[string]any{"k": "v"},
+		"v":    NewString("jsonvalue"),
+		"u8":   uint8(8),
+		"st":   struct{ A int }{1},
+		"nilv": (*V)(nil),
+	}
+
+	v, err := Import(m)
+	so(err, isNil)
+	so(v.MustMarshalString(OptDefaultStringSequence()), eq,
+		`{"arr":[1,"2",{},[]],"b":false,"f":1.5,"i":1,"i64":-2,"n":null,"nilv":null,"obj":{"k":"v"},`+
+			`"s":"str","st":{"A":1},"u8":8,"v":"jsonvalue"}`)
+
+	// should be identical to general reflection
+	v2, err := Import(struct {
+		M map[string]any
+	}{m})
+	so(err, isNil)
+	so(v2.MustGet("M").Equal(v), isTrue)
+
+	// nil and empty maps
+	v, err = Import(map[string]any(nil))
+	so(err, isNil)
+	so(v.IsNull(), isTrue)
+	v, err = Import(map[string]any{})
+	so(err, isNil)
+	so(v.IsObject(), isTrue)
+
+	// cycles and depth
+	cycle := map[string]any{}
+	cycle["self"] = []any{cycle}
+	_, err = Import(cycle)
+	so(err, isErr)
+	so(errors.Is(err, ErrCycleDetected), isTrue)
+	v, err = Import(cycle, OptImportCycleAsNull())
+	so(err, isNil)
+	so(v.MustMarshalString(), eq, `{"self":[null]}`)
+
+	_, err = Import(map[string]any{"a": map[string]any{"b": []any{1}}}, OptImportMaxDepth(2))
+	so(err, isErr)
+	so(errors.Is(err, ErrMaxDepthExceeded), isTrue)
+
+	// unsupported values
+	_, err = Import(map[string]any{"c": make(chan int)})
+	so(err, isErr)
+
+	// type converters are still effective
+	v, err = Import(map[string]any{"s": "str"}, OptTypeConverter(reflect.TypeOf(""), func(reflect.Value) (*V, error) {
+		return NewString("converted"), nil
+	}, nil))
+	so(err, isNil)
+	so(v.MustGet("s").String(), eq, "converted")
+}
@@ -74,6 +74,25 @@ func TestJsonvalue(t *testing.T) {
 	test(t, "test insert, append, delete", testInsertAppendDelete)
 	test(t, "test structconv", testStructConv)
 	test(t, "test Equal functions", testEqual)
+	test(t, "test canonical marshaling", testCanonical)
+	test(t, "test Compare functions", testCompare)
+	test(t, "test Contains functions", testContains)
+	test(t, "test Diff function", testDiff)
+	test(t, "test native Export", testExport)
+	test(t, "test type converters", testTypeConverter)
+	test(t, "test field naming", testFieldNaming)
+	test(t, "test Interface", testInterface)
+	test(t, "test InferSchema", testInferSchema)
+	test(t, "test MessagePack", testMsgPack)
+	test(t, "test CBOR", testCBOR)
+	test(t, "test BSON", testBSON)
+	test(t, "test YAML", testYAML)
+	test(t, "test XML", testXML)
+	test(t, "test CSV", testCSV)
+	test(t, "test Flatten", testFlatten)
+	test(t, "test URL values", testURLValues)
+	test(t, "test binary", testBinary)
+	test(t, "test conversion helpers", testConv)
 }
 
 func testBasicFunction(t *testing.T) {
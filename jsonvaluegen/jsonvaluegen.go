@@ -0,0 +1,354 @@
+// Package jsonvaluegen generates Go struct definitions from sample JSON documents. Optional fields are merged
+// across samples, and integers, floats and string-encoded numbers are detected.
+//
+// 本包根据 JSON 样本文档生成 Go 结构体定义。多个样本中的可选字段会被合并，并且会识别整数、浮点数以及以字符串编码的数字。
+package jsonvaluegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+// GenerateStructs infers Go types from one or more sample values, and returns gofmt-ed Go type declarations with
+// json tags, without package clause. The root type is named by typeName, while nested struct types are named by
+// their parents and field names. Fields missing in some samples are tagged with omitempty.
+//
+// GenerateStructs 根据一个或多个样本值推断 Go 类型，返回经过 gofmt 格式化、带有 json 标签的 Go 类型声明，不包含 package 语句。
+// 根类型以 typeName 命名，嵌套的结构体类型则以其父类型和字段名命名。在部分样本中缺失的字段会带上 omitempty 标签。
+func GenerateStructs(typeName string, samples ...*jsonvalue.V) ([]byte, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("jsonvaluegen: no samples given")
+	}
+	if strings.TrimSpace(typeName) == "" {
+		return nil, errors.New("jsonvaluegen: invalid type name")
+	}
+	typeName = exportedName(typeName)
+
+	root := &typeInfo{}
+	for i, v := range samples {
+		if v == nil || v.ValueType() == jsonvalue.NotExist || v.ValueType() == jsonvalue.Unknown {
+			return nil, fmt.Errorf("jsonvaluegen: sample %d is not a valid JSON value", i)
+		}
+		root.merge(v)
+	}
+
+	g := &generator{
+		typeNames: map[string]bool{typeName: true},
+	}
+	g.declare(typeName, root)
+
+	src := append(bytes.TrimRight(g.buff.Bytes(), "\n"), '\n')
+	b, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("jsonvaluegen: formatting generated code error: %w", err)
+	}
+	return b, nil
+}
+
+// typeInfo collects every kind of values seen at the same position among samples.
+type typeInfo struct {
+	null    bool
+	boolean bool
+	integer bool
+	float   bool
+
+	str           bool
+	strInteger    bool
+	strFloat      bool
+	strNonNumeric bool
+
+	object  bool
+	objects int // count of objects merged, used to tell whether fields are optional
+	fields  map[string]*fieldInfo
+	keys    []string // keys in sequence of first appearance
+
+	array bool
+	elem  *typeInfo
+}
+
+type fieldInfo struct {
+	typeInfo
+	present int
+}
+
+func (t *typeInfo) merge(v *jsonvalue.V) {
+	switch v.ValueType() {
+	default:
+		t.null = true
+
+	case jsonvalue.Boolean:
+		t.boolean = true
+
+	case jsonvalue.Number:
+		if v.IsFloat() || !isInt64Literal(v.String()) {
+			t.float = true
+		} else {
+			t.integer = true
+		}
+
+	case jsonvalue.String:
+		t.str = true
+		if s := v.String(); !jsonvalue.IsNumberText(s) {
+			t.strNonNumeric = true
+		} else if isInt64Literal(s) {
+			t.strInteger = true
+		} else {
+			t.strFloat = true
+		}
+
+	case jsonvalue.Object:
+		t.object = true
+		t.objects++
+		if t.fields == nil {
+			t.fields = map[string]*fieldInfo{}
+		}
+		v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+			f, exist := t.fields[k]
+			if !exist {
+				f = &fieldInfo{}
+				t.fields[k] = f
+				t.keys = append(t.keys, k)
+			}
+			f.present++
+			f.merge(child)
+			return true
+		})
+
+	case jsonvalue.Array:
+		t.array = true
+		if t.elem == nil {
+			t.elem = &typeInfo{}
+		}
+		v.RangeArray(func(_ int, child *jsonvalue.V) bool {
+			t.elem.merge(child)
+			return true
+		})
+	}
+}
+
+// kinds returns count of different JSON kinds, null excluded.
+func (t *typeInfo) kinds() int {
+	cnt := 0
+	for _, b := range []bool{t.boolean, t.integer || t.float, t.str, t.object, t.array} {
+		if b {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// isStringEncodedNumber tells whether all strings are numbers and could be tagged with ",string" option.
+func (t *typeInfo) isStringEncodedNumber() bool {
+	return t.str && !t.strNonNumeric && !t.null && t.kinds() == 1
+}
+
+func isInt64Literal(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+type pendingStruct struct {
+	name string
+	info *typeInfo
+}
+
+type generator struct {
+	buff      bytes.Buffer
+	typeNames map[string]bool
+	pending   []pendingStruct
+}
+
+// declare writes the root type declaration and all nested struct declarations.
+func (g *generator) declare(name string, root *typeInfo) {
+	if root.kinds() == 1 && root.object && len(root.fields) > 0 {
+		g.pending = append(g.pending, pendingStruct{name, root})
+	} else {
+		fmt.Fprintf(&g.buff, "type %s %s\n\n", name, g.goType(root, name))
+	}
+
+	for len(g.pending) > 0 {
+		p := g.pending[0]
+		g.pending = g.pending[1:]
+		g.writeStruct(p.name, p.info)
+	}
+}
+
+func (g *generator) writeStruct(name string, t *typeInfo) {
+	fmt.Fprintf(&g.buff, "type %s struct {\n", name)
+
+	fieldNames := map[string]bool{}
+	for _, k := range t.keys {
+		f := t.fields[k]
+		if !isValidTag(k) {
+			fmt.Fprintf(&g.buff, "\t// key %q is skipped as it could not be used in json tag\n", k)
+			continue
+		}
+
+		fieldName := uniqueName(exportedName(k), fieldNames)
+		optional := f.present < t.objects
+
+		typ, tag := "", k
+		if f.isStringEncodedNumber() {
+			typ = "int64"
+			if f.strFloat {
+				typ = "float64"
+			}
+			if optional {
+				tag += ",omitempty"
+			}
+			tag += ",string"
+		} else {
+			typ = g.goType(&f.typeInfo, name+fieldName)
+			if optional {
+				tag += ",omitempty"
+				if !strings.HasPrefix(typ, "*") && g.isStructName(typ) {
+					typ = "*" + typ
+				}
+			}
+		}
+		if tag == "-" {
+			// a single "-" tells encoding/json to ignore the field
+			tag = "-,"
+		}
+		fmt.Fprintf(&g.buff, "\t%s %s `json:\"%s\"`\n", fieldName, typ, tag)
+	}
+
+	g.buff.WriteString("}\n\n")
+}
+
+func (g *generator) isStructName(typ string) bool {
+	return g.typeNames[typ]
+}
+
+// goType returns Go type expression of t. Struct declarations are queued with names derived from hint.
+func (g *generator) goType(t *typeInfo, hint string) string {
+	if t.kinds() != 1 {
+		return "interface{}"
+	}
+
+	typ := ""
+	switch {
+	case t.boolean:
+		typ = "bool"
+	case t.float:
+		typ = "float64"
+	case t.integer:
+		typ = "int64"
+	case t.str:
+		typ = "string"
+	case t.array:
+		return "[]" + g.goType(t.elem, singular(hint))
+	default:
+		if len(t.fields) == 0 {
+			return "map[string]interface{}"
+		}
+		typ = uniqueName(hint, g.typeNames)
+		g.pending = append(g.pending, pendingStruct{typ, t})
+	}
+
+	if t.null {
+		return "*" + typ
+	}
+	return typ
+}
+
+// singular returns a naive singular form of a type name, which is used in naming array elements.
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	default:
+		return name + "Item"
+	}
+}
+
+// uniqueName appends a sequence number to name if it is already used, and marks the result as used.
+func uniqueName(name string, used map[string]bool) string {
+	res := name
+	for i := 2; used[res]; i++ {
+		res = name + strconv.Itoa(i)
+	}
+	used[res] = true
+	return res
+}
+
+// commonInitialisms are written in upper case in Go identifiers, as golint suggests.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true, "GUID": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true, "QPS": true,
+	"RAM": true, "RHS": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// exportedName converts a JSON key into an exported Go identifier, e.g. "user_id" -> "UserID".
+func exportedName(key string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(key)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	buff := strings.Builder{}
+	for _, w := range words {
+		if upper := strings.ToUpper(w); commonInitialisms[upper] {
+			buff.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		buff.WriteString(string(r))
+	}
+
+	res := buff.String()
+	if res == "" {
+		return "Field"
+	}
+	if r := []rune(res)[0]; !unicode.IsLetter(r) {
+		return "X" + res
+	}
+	return res
+}
+
+// isValidTag is identical to that of encoding/json.
+func isValidTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but otherwise any punctuation chars are allowed in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
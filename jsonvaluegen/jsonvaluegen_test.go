@@ -0,0 +1,138 @@
+package jsonvaluegen
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	cv = convey.Convey
+	so = convey.So
+
+	eq = convey.ShouldEqual
+
+	isNil     = convey.ShouldBeNil
+	isErr     = convey.ShouldBeError
+	hasSubStr = convey.ShouldContainSubstring
+)
+
+func TestJsonvaluegen(t *testing.T) {
+	cv("test GenerateStructs()", t, func() { testGenerateStructs(t) })
+	cv("test type inference", t, func() { testTypeInference(t) })
+	cv("test naming", t, func() { testNaming(t) })
+	cv("test errors", t, func() { testErrors(t) })
+}
+
+func generate(t *testing.T, typeName string, samples ...string) string {
+	var vs []*jsonvalue.V
+	for _, s := range samples {
+		vs = append(vs, jsonvalue.MustUnmarshalString(s))
+	}
+	b, err := GenerateStructs(typeName, vs...)
+	so(err, isNil)
+	return string(b)
+}
+
+func testGenerateStructs(t *testing.T) {
+	code := generate(t, "order",
+		`{"order_id":1,"user":{"name":"Andrew"},"items":[{"sku":"A1","price":1.5}]}`,
+		`{"order_id":2,"items":[{"sku":"B2","price":2,"count":3}],"remark":null}`,
+	)
+	so(code, eq, "type Order struct {\n"+
+		"\tOrderID int64       `json:\"order_id\"`\n"+
+		"\tUser    *OrderUser  `json:\"user,omitempty\"`\n"+
+		"\tItems   []OrderItem `json:\"items\"`\n"+
+		"\tRemark  interface{} `json:\"remark,omitempty\"`\n"+
+		"}\n\n"+
+		"type OrderUser struct {\n"+
+		"\tName string `json:\"name\"`\n"+
+		"}\n\n"+
+		"type OrderItem struct {\n"+
+		"\tSku   string  `json:\"sku\"`\n"+
+		"\tPrice float64 `json:\"price\"`\n"+
+		"\tCount int64   `json:\"count,omitempty\"`\n"+
+		"}\n",
+	)
+
+	// non-object roots
+	so(generate(t, "List", `[1,2]`), eq, "type List []int64\n")
+	so(generate(t, "Any", `{}`), eq, "type Any map[string]interface{}\n")
+	so(generate(t, "Users", `[{"id":1}]`), hasSubStr, "type Users []User\n\ntype User struct {")
+}
+
+func testTypeInference(t *testing.T) {
+	code := generate(t, "T",
+		`{"i":1,"f":1.5,"if":1,"big":18446744073709551615,"si":"12","sf":"1.5","s":"abc","ms":"12",`+
+			`"b":true,"np":1,"mixed":1,"arr":[],"nested":[[1]],"ns":"1"}`,
+		`{"i":2,"f":2.0,"if":2.5,"big":1,"si":"-3","sf":"2","s":"","ms":"x",`+
+			`"b":false,"np":null,"mixed":"1","arr":[],"nested":[[2.5]],"ns":null}`,
+	)
+	so(code, hasSubStr, "I      int64         `json:\"i\"`")
+	so(code, hasSubStr, "F      float64       `json:\"f\"`")
+	so(code, hasSubStr, "If     float64       `json:\"if\"`")
+	so(code, hasSubStr, "Big    float64       `json:\"big\"`")
+	so(code, hasSubStr, "Si     int64         `json:\"si,string\"`")
+	so(code, hasSubStr, "Sf     float64       `json:\"sf,string\"`")
+	so(code, hasSubStr, "S      string        `json:\"s\"`")
+	so(code, hasSubStr, "Ms     string        `json:\"ms\"`")
+	so(code, hasSubStr, "B      bool          `json:\"b\"`")
+	so(code, hasSubStr, "Np     *int64        `json:\"np\"`")
+	so(code, hasSubStr, "Mixed  interface{}   `json:\"mixed\"`")
+	so(code, hasSubStr, "Arr    []interface{} `json:\"arr\"`")
+	so(code, hasSubStr, "Nested [][]float64   `json:\"nested\"`")
+	so(code, hasSubStr, "Ns     *string       `json:\"ns\"`")
+
+	// texts which are not JSON numbers stay strings
+	code = generate(t, "T",
+		`{"zip":"01234","phone":"+8613800000000","hex":"0x1f","dot":"1.","inf":"Inf","exp":"1e3","neg":"-0.5"}`,
+	)
+	so(code, hasSubStr, "Zip   string  `json:\"zip\"`")
+	so(code, hasSubStr, "Phone string  `json:\"phone\"`")
+	so(code, hasSubStr, "Hex   string  `json:\"hex\"`")
+	so(code, hasSubStr, "Dot   string  `json:\"dot\"`")
+	so(code, hasSubStr, "Inf   string  `json:\"inf\"`")
+	so(code, hasSubStr, "Exp   float64 `json:\"exp,string\"`")
+	so(code, hasSubStr, "Neg   float64 `json:\"neg,string\"`")
+}
+
+func testNaming(t *testing.T) {
+	so(exportedName("user_id"), eq, "UserID")
+	so(exportedName("createdAt"), eq, "CreatedAt")
+	so(exportedName("home-url"), eq, "HomeURL")
+	so(exportedName("HTTPServer"), eq, "HTTPServer")
+	so(exportedName("2fa"), eq, "X2fa")
+	so(exportedName("$"), eq, "Field")
+
+	so(singular("OrderItems"), eq, "OrderItem")
+	so(singular("Categories"), eq, "Category")
+	so(singular("Address"), eq, "AddressItem")
+	so(singular("Data"), eq, "DataItem")
+
+	code := generate(t, "T", `{"a_b":1,"aB":2,"a,b":3,"T":{"x":1}}`)
+	so(code, hasSubStr, "AB  int64 `json:\"a_b\"`")
+	so(code, hasSubStr, "AB2 int64 `json:\"aB\"`")
+	so(code, hasSubStr, `// key "a,b" is skipped`)
+	so(code, hasSubStr, "T TT `json:\"T\"`")
+
+	// key "-" should not be ignored by encoding/json
+	code = generate(t, "T", `{"-":1,"-x":2}`, `{"-x":3}`)
+	so(code, hasSubStr, "Field int64 `json:\"-,omitempty\"`")
+	code = generate(t, "T", `{"-":1}`)
+	so(code, hasSubStr, "Field int64 `json:\"-,\"`")
+}
+
+func testErrors(t *testing.T) {
+	_, err := GenerateStructs("T")
+	so(err, isErr)
+
+	_, err = GenerateStructs("T", nil)
+	so(err, isErr)
+
+	_, err = GenerateStructs("T", &jsonvalue.V{})
+	so(err, isErr)
+
+	_, err = GenerateStructs("", jsonvalue.NewObject())
+	so(err.Error(), hasSubStr, "invalid type name")
+}
@@ -0,0 +1,213 @@
+package jsonvaluetest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// AssertEqual asserts that got equals to want, in the semantic of (*jsonvalue.V).Equal(). Both parameters
+// can be *jsonvalue.V or any type accepted by jsonvalue.Import(). If not equal, a diff will be reported via
+// t.Errorf(), where lines starting with "-" show wanted values and those starting with "+" show got values.
+//
+// AssertEqual 断言 got 与 want 相等，判断逻辑与 (*jsonvalue.V).Equal() 相同。两个参数可以是 *jsonvalue.V，或者是
+// jsonvalue.Import() 支持的任意类型。如果不相等，则通过 t.Errorf() 报告差异，其中以 "-" 开头的行表示期望值，以 "+" 开头的行表示实际值。
+func AssertEqual(t T, want, got interface{}, opts ...Option) bool {
+	t.Helper()
+	opt := combineOptions(opts)
+
+	wantV, gotV, ok := importBoth(t, want, got)
+	if !ok {
+		return false
+	}
+
+	diffs := filterDifferences(jsonvalue.Diff(wantV, gotV), opt)
+	if len(diffs) == 0 {
+		return true
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString("jsonvalue: values are not equal\n")
+	for _, d := range diffs {
+		writeDifference(&buf, d.KeyPath, d.Left, d.Right, "", opt)
+	}
+	t.Errorf("%s", buf.String())
+	return false
+}
+
+// AssertContains asserts that got contains subset sub in path inPath, in the semantic of jsonvalue.CheckContains().
+// Matchers generated by jsonvalue.MatchXxx() functions can be used in sub. If not contained, the first mismatch
+// will be reported via t.Errorf().
+//
+// AssertContains 断言 got 在 inPath 路径下包含子集 sub，判断逻辑与 jsonvalue.CheckContains() 相同，sub 中可以使用由
+// jsonvalue.MatchXxx() 函数生成的匹配器。如果不包含，则通过 t.Errorf() 报告第一个不匹配的位置。
+func AssertContains(t T, got *jsonvalue.V, sub interface{}, inPath ...interface{}) bool {
+	t.Helper()
+	opt := combineOptions(nil)
+
+	err := jsonvalue.CheckContains(got, sub, inPath...)
+	if err == nil {
+		return true
+	}
+
+	var mismatch *jsonvalue.MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Errorf("jsonvalue: %v", err)
+		return false
+	}
+
+	subV, ok := sub.(*jsonvalue.V)
+	if !ok {
+		subV, _ = jsonvalue.Import(sub)
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString("jsonvalue: value does not contain expected subset\n")
+	writeDifference(
+		&buf, mismatch.KeyPath, getByKeyPath(subV, mismatch.SubKeyPath), getByKeyPath(got, mismatch.KeyPath),
+		mismatch.Reason, opt,
+	)
+	t.Errorf("%s", buf.String())
+	return false
+}
+
+// AssertPath asserts that the value in given path of v equals to expected. The path parameters are in the same
+// format as (*jsonvalue.V).Get(), and expected can be *jsonvalue.V or any type accepted by jsonvalue.Import(). v
+// itself is compared if no path is given.
+//
+// AssertPath 断言 v 中给定路径下的值与 expected 相等。path 参数格式与 (*jsonvalue.V).Get() 相同，expected 可以是
+// *jsonvalue.V，或者是 jsonvalue.Import() 支持的任意类型。如果未指定路径，则比较 v 本身。
+func AssertPath(t T, v *jsonvalue.V, expected interface{}, path ...interface{}) bool {
+	t.Helper()
+
+	got := v
+	if len(path) > 0 {
+		var err error
+		got, err = v.Get(path[0], path[1:]...)
+		if err != nil {
+			t.Errorf("jsonvalue: cannot get value at %v: %v", path, err)
+			return false
+		}
+	}
+	return AssertEqual(t, expected, got)
+}
+
+func importBoth(t T, want, got interface{}) (wantV, gotV *jsonvalue.V, ok bool) {
+	t.Helper()
+
+	wantV, err := toV(want)
+	if err != nil {
+		t.Errorf("jsonvalue: illegal wanted value: %v", err)
+		return nil, nil, false
+	}
+	gotV, err = toV(got)
+	if err != nil {
+		t.Errorf("jsonvalue: illegal got value: %v", err)
+		return nil, nil, false
+	}
+	return wantV, gotV, true
+}
+
+func toV(v interface{}) (*jsonvalue.V, error) {
+	if j, ok := v.(*jsonvalue.V); ok {
+		return j, nil
+	}
+	return jsonvalue.Import(v)
+}
+
+func filterDifferences(diffs []jsonvalue.Difference, opt *options) []jsonvalue.Difference {
+	if len(opt.ignored) == 0 {
+		return diffs
+	}
+
+	res := make([]jsonvalue.Difference, 0, len(diffs))
+	for _, d := range diffs {
+		if !isIgnored(d.KeyPath, opt) {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func isIgnored(path jsonvalue.KeyPath, opt *options) bool {
+	for _, ignored := range opt.ignored {
+		if hasPrefix(path, ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefix(path jsonvalue.KeyPath, prefix []interface{}) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, p := range prefix {
+		k := path[i]
+		if k.IsInt() {
+			if n, ok := p.(int); !ok || n != k.Int() {
+				return false
+			}
+		} else if s, ok := p.(string); !ok || s != k.String() {
+			return false
+		}
+	}
+	return true
+}
+
+func getByKeyPath(v *jsonvalue.V, path jsonvalue.KeyPath) *jsonvalue.V {
+	if v == nil {
+		return &jsonvalue.V{}
+	}
+	for _, k := range path {
+		if k.IsInt() {
+			v = v.MustGet(k.Int())
+		} else {
+			v = v.MustGet(k.String())
+		}
+	}
+	return v
+}
+
+func writeDifference(
+	buf *bytes.Buffer, path jsonvalue.KeyPath, want, got *jsonvalue.V, reason string, opt *options,
+) {
+	p := path.String()
+	if reason != "" {
+		p = fmt.Sprintf("%s %s", p, reason)
+	}
+	writeColored(buf, "  @ "+p, colorYellow, opt)
+	writeColored(buf, "  - "+describe(want), colorRed, opt)
+	writeColored(buf, "  + "+describe(got), colorGreen, opt)
+}
+
+func writeColored(buf *bytes.Buffer, s, color string, opt *options) {
+	if opt.color {
+		buf.WriteString(color)
+		buf.WriteString(s)
+		buf.WriteString(colorReset)
+	} else {
+		buf.WriteString(s)
+	}
+	buf.WriteByte('\n')
+}
+
+func describe(v *jsonvalue.V) string {
+	if v == nil || v.ValueType() == jsonvalue.NotExist {
+		return "(not exist)"
+	}
+	s, err := v.MarshalString(jsonvalue.OptSetSequence(), jsonvalue.OptUTF8(), jsonvalue.OptEscapeHTML(false))
+	if err != nil {
+		return fmt.Sprintf("(%v)", err)
+	}
+	return s
+}
@@ -0,0 +1,104 @@
+package jsonvaluetest
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+func testAssertEqual(t *testing.T) {
+	cv("equal", func() {
+		mt := &mockT{}
+		want := jsonvalue.MustUnmarshalString(`{"a":1,"b":[true,null]}`)
+		got := jsonvalue.MustUnmarshalString(`{"b":[true,null],"a":1.0}`)
+		so(AssertEqual(mt, want, got), isTrue)
+		so(len(mt.errs), eq, 0)
+
+		so(AssertEqual(mt, map[string]interface{}{"a": 1}, jsonvalue.MustUnmarshalString(`{"a":1}`)), isTrue)
+	})
+
+	cv("not equal", func() {
+		mt := &mockT{}
+		want := jsonvalue.MustUnmarshalString(`{"data":{"items":[{"price":1},{"price":2}]},"msg":"OK"}`)
+		got := jsonvalue.MustUnmarshalString(`{"data":{"items":[{"price":1},{"price":"2"}]}}`)
+		so(AssertEqual(mt, want, got, OptColor(false)), isFalse)
+
+		out := mt.output()
+		t.Log(out)
+		so(out, hasSubStr, "@ [\"data\" \"items\" 1 \"price\"]\n  - 2\n  + \"2\"\n")
+		so(out, hasSubStr, "@ [\"msg\"]\n  - \"OK\"\n  + (not exist)\n")
+		so(out, notSubStr, "\x1b[")
+	})
+
+	cv("colored", func() {
+		mt := &mockT{}
+		so(AssertEqual(mt, 1, 2, OptColor(true)), isFalse)
+		so(mt.output(), hasSubStr, colorRed+"  - 1"+colorReset)
+		so(mt.output(), hasSubStr, colorGreen+"  + 2"+colorReset)
+	})
+
+	cv("ignore", func() {
+		mt := &mockT{}
+		want := jsonvalue.MustUnmarshalString(`{"id":1,"meta":{"time":100},"list":[1,2]}`)
+		got := jsonvalue.MustUnmarshalString(`{"id":1,"meta":{"time":200,"host":"a"},"list":[1,3]}`)
+		so(AssertEqual(mt, want, got, OptIgnore("meta"), OptIgnore("list", 1)), isTrue)
+		so(AssertEqual(mt, want, got, OptIgnore("meta")), isFalse)
+	})
+
+	cv("illegal parameters", func() {
+		mt := &mockT{}
+		so(AssertEqual(mt, make(chan int), 1), isFalse)
+		so(AssertEqual(mt, 1, complex(1, 1)), isFalse)
+		so(len(mt.errs), eq, 2)
+	})
+}
+
+func testAssertContains(t *testing.T) {
+	v := jsonvalue.MustUnmarshalString(`{"code":0,"data":{"id":"abc","items":[1,2,3]}}`)
+
+	cv("contains", func() {
+		mt := &mockT{}
+		so(AssertContains(mt, v, jsonvalue.M{"code": 0}), isTrue)
+		so(AssertContains(mt, v, []int{2, 3}, "data", "items"), isTrue)
+
+		sub := jsonvalue.M{"data": jsonvalue.M{"id": jsonvalue.MatchRegexp(`^[a-z]+$`)}}
+		so(AssertContains(mt, v, sub), isTrue)
+		so(len(mt.errs), eq, 0)
+	})
+
+	cv("not contains", func() {
+		mt := &mockT{}
+		sub := jsonvalue.M{"data": jsonvalue.M{"id": jsonvalue.MatchType(jsonvalue.Number)}}
+		so(AssertContains(mt, v, sub), isFalse)
+
+		out := mt.output()
+		t.Log(out)
+		so(out, hasSubStr, `["data" "id"] expected any number, got string`)
+		so(out, hasSubStr, `- "$any number"`)
+		so(out, hasSubStr, `+ "abc"`)
+
+		mt = &mockT{}
+		so(AssertContains(mt, v, jsonvalue.M{"id": "abd"}, "data"), isFalse)
+		so(mt.output(), hasSubStr, `- "abd"`)
+		so(mt.output(), hasSubStr, `+ "abc"`)
+
+		mt = &mockT{}
+		so(AssertContains(mt, v, jsonvalue.M{"items": []int{1, 2, 4}}, "data"), isFalse)
+		so(mt.output(), hasSubStr, `["data" "items" 2] expected 4, got 3`)
+		so(mt.output(), hasSubStr, `- 4`)
+		so(mt.output(), hasSubStr, `+ 3`)
+	})
+}
+
+func testAssertPath(t *testing.T) {
+	v := jsonvalue.MustUnmarshalString(`{"data":{"items":[{"price":1.50}]}}`)
+
+	mt := &mockT{}
+	so(AssertPath(mt, v, 1.5, "data", "items", 0, "price"), isTrue)
+	so(AssertPath(mt, v, v), isTrue)
+	so(len(mt.errs), eq, 0)
+
+	so(AssertPath(mt, v, 2, "data", "items", 0, "price"), isFalse)
+	so(AssertPath(mt, v, 2, "data", "items", 1), isFalse)
+	so(mt.output(), hasSubStr, "cannot get value")
+}
@@ -0,0 +1,105 @@
+package jsonvaluetest
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+const (
+	defaultGoldenDir    = "testdata"
+	goldenFileExtension = ".golden.json"
+
+	// UpdateGoldenEnv is the environment variable which enables updating golden files in Golden(), such as
+	// "JSONVALUE_UPDATE_GOLDEN=1 go test ./...".
+	//
+	// UpdateGoldenEnv 是在 Golden() 中启用更新黄金文件的环境变量，如 "JSONVALUE_UPDATE_GOLDEN=1 go test ./..."。
+	UpdateGoldenEnv = "JSONVALUE_UPDATE_GOLDEN"
+)
+
+// shouldUpdateGolden checks the environment variable, and the "-update" flag if it is defined by the test package.
+// This package does not define any flag itself.
+func shouldUpdateGolden() bool {
+	if b, err := strconv.ParseBool(os.Getenv(UpdateGoldenEnv)); err == nil && b {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	b, _ := getter.Get().(bool)
+	return b
+}
+
+// Golden compares v with the content of golden file "testdata/<name>.golden.json" semantically, in the same
+// way as AssertEqual() does. When environment variable JSONVALUE_UPDATE_GOLDEN is set to true, or tests run with
+// "-update" flag defined by the test package itself, the golden file will be rewritten by v instead, with keys
+// sorted in dictionary sequence and indented by two spaces, so that golden files are diff-friendly in version
+// control.
+//
+// Golden 将 v 与黄金文件 "testdata/<name>.golden.json" 的内容进行语义比较，比较方式与 AssertEqual() 相同。当环境变量
+// JSONVALUE_UPDATE_GOLDEN 设为 true, 或测试带有由测试包自行定义的 "-update" 参数运行时，则改为用 v 重写黄金文件。文件中的键按照
+// 字典序排列，并使用两个空格缩进，便于在版本管理中进行对比。
+func Golden(t T, name string, v *jsonvalue.V, opts ...Option) bool {
+	t.Helper()
+	opt := combineOptions(opts)
+	file := filepath.Join(opt.goldenDir, name+goldenFileExtension)
+
+	if shouldUpdateGolden() {
+		if err := writeGoldenFile(file, v); err != nil {
+			t.Errorf("jsonvalue: failed to update golden file %s: %v", file, err)
+			return false
+		}
+		return true
+	}
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Errorf("jsonvalue: failed to read golden file %s: %v (run tests with JSONVALUE_UPDATE_GOLDEN=1 to create it)", file, err)
+		return false
+	}
+	want, err := jsonvalue.Unmarshal(b)
+	if err != nil {
+		t.Errorf("jsonvalue: failed to parse golden file %s: %v", file, err)
+		return false
+	}
+
+	return AssertEqual(t, want, v, opts...)
+}
+
+func writeGoldenFile(file string, v *jsonvalue.V) error {
+	b, err := MarshalGolden(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, b, 0644)
+}
+
+// MarshalGolden marshals v in the format of golden files, that is, keys sorted in dictionary sequence,
+// indented by two spaces, non-ASCII and HTML characters unescaped, and ends with a newline.
+//
+// MarshalGolden 按照黄金文件的格式序列化 v，也就是键按照字典序排列、使用两个空格缩进、不转义非 ASCII 以及 HTML 字符，并以换行结尾。
+func MarshalGolden(v *jsonvalue.V) ([]byte, error) {
+	b, err := v.Marshal(
+		jsonvalue.OptDefaultStringSequence(), jsonvalue.OptIndent("", "  "),
+		jsonvalue.OptUTF8(), jsonvalue.OptEscapeHTML(false), jsonvalue.OptEscapeSlash(false),
+	)
+	if err != nil {
+		return nil, err
+	}
+	buf := bytes.NewBuffer(b)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,75 @@
+package jsonvaluetest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+// update is defined by the test package, just as users do, which should not conflict with jsonvaluetest.
+var update = flag.Bool("update", false, "update golden files")
+
+func testGolden(t *testing.T) {
+	cv("stored golden file", func() { testGoldenStored(t) })
+	cv("update golden file", func() { testGoldenUpdate(t) })
+}
+
+func testGoldenStored(t *testing.T) {
+	v := jsonvalue.NewObject()
+	v.SetString("Andrew").At("name")
+	v.SetInt(3).At("tags", "count")
+	v.SetString("<b>").At("tags", "html")
+	v.SetString("世界").At("tags", "list", 0)
+
+	mt := &mockT{}
+	so(Golden(mt, "sample", v), isTrue)
+	so(len(mt.errs), eq, 0)
+
+	v.SetInt(4).At("tags", "count")
+	so(Golden(mt, "sample", v, OptColor(false)), isFalse)
+	so(mt.output(), hasSubStr, "@ [\"tags\" \"count\"]\n  - 3\n  + 4\n")
+
+	so(Golden(mt, "not_exist", v), isFalse)
+	so(mt.output(), hasSubStr, UpdateGoldenEnv)
+}
+
+func testGoldenUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonvaluetest")
+	so(err, eq, nil)
+	defer os.RemoveAll(dir)
+
+	so(flag.Set("update", "true"), eq, nil)
+	defer flag.Set("update", "false")
+
+	v := jsonvalue.MustUnmarshalString(`{"b":[1,{"d":null,"c":true}],"a":"/"}`)
+
+	mt := &mockT{}
+	so(Golden(mt, "sub/updated", v, OptGoldenDir(dir)), isTrue)
+	so(len(mt.errs), eq, 0)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "sub", "updated.golden.json"))
+	so(err, eq, nil)
+	so(string(b), eq, "{\n  \"a\": \"/\",\n  \"b\": [\n    1,\n    {\n      \"c\": true,\n      \"d\": null\n    }\n  ]\n}\n")
+
+	so(flag.Set("update", "false"), eq, nil)
+	so(*update, isFalse)
+	so(Golden(mt, "sub/updated", v, OptGoldenDir(dir)), isTrue)
+	so(len(mt.errs), eq, 0)
+
+	// by environment variable
+	v = jsonvalue.MustUnmarshalString(`{"x":1}`)
+	os.Setenv(UpdateGoldenEnv, "1")
+	so(Golden(mt, "env", v, OptGoldenDir(dir)), isTrue)
+	os.Setenv(UpdateGoldenEnv, "false")
+	defer os.Unsetenv(UpdateGoldenEnv)
+	so(Golden(mt, "env", v, OptGoldenDir(dir)), isTrue)
+	b, err = ioutil.ReadFile(filepath.Join(dir, "env.golden.json"))
+	so(err, eq, nil)
+	so(string(b), eq, "{\n  \"x\": 1\n}\n")
+
+	so(Golden(mt, "env_not_exist", v, OptGoldenDir(dir)), isFalse)
+}
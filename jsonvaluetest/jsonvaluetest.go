@@ -0,0 +1,100 @@
+// Package jsonvaluetest provides assertion helpers for testing with jsonvalue. On failure, a colored
+// and KeyPath-annotated diff of the two JSON values will be printed.
+//
+// As this package imports jsonvalue, it could not be used by in-package tests of jsonvalue itself, which is an
+// import cycle. Those tests keep using (*jsonvalue.V).Equal().
+//
+// The path parameters of AssertPath() are placed last on purpose, i.e. AssertPath(t, v, expected, path...), so
+// that they are variadic in the same format as (*jsonvalue.V).Get() and the inPath parameters of AssertContains().
+//
+// 本包提供基于 jsonvalue 的测试断言工具。断言失败时，会输出两个 JSON 值之间带颜色以及 KeyPath 标注的差异。
+//
+// 由于本包导入了 jsonvalue, 因此 jsonvalue 自身的包内测试无法使用本包 (会导致循环导入), 这些测试仍然使用
+// (*jsonvalue.V).Equal()。
+//
+// AssertPath() 的路径参数有意放在最后，即 AssertPath(t, v, expected, path...)，从而与 (*jsonvalue.V).Get() 以及
+// AssertContains() 的 inPath 参数一样，使用相同格式的可变参数。
+package jsonvaluetest
+
+import (
+	"os"
+)
+
+// T is the subset of testing.TB used in this package.
+//
+// T 是本包使用到的 testing.TB 的子集。
+type T interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Option is used in assertion functions of this package.
+//
+// Option 用于本包中的断言函数。
+type Option interface {
+	mergeTo(*options)
+}
+
+type options struct {
+	color     bool
+	ignored   [][]interface{}
+	goldenDir string
+}
+
+func combineOptions(opts []Option) *options {
+	o := &options{
+		color:     os.Getenv("NO_COLOR") == "",
+		goldenDir: defaultGoldenDir,
+	}
+	for _, opt := range opts {
+		opt.mergeTo(o)
+	}
+	return o
+}
+
+// ==== color ====
+
+// OptColor specifies whether the diff should be colored with ANSI escape codes. By default, the diff is
+// colored unless environment variable NO_COLOR is set.
+//
+// OptColor 指定差异输出是否使用 ANSI 转义码着色。默认情况下会着色，除非设置了 NO_COLOR 环境变量。
+func OptColor(on bool) Option {
+	return optColor(on)
+}
+
+type optColor bool
+
+func (o optColor) mergeTo(opts *options) {
+	opts.color = bool(o)
+}
+
+// ==== ignore ====
+
+// OptIgnore tells that differences at given path, including all its children, should be ignored. Path
+// parameters are in the same format as (*jsonvalue.V).Get().
+//
+// OptIgnore 指定忽略给定路径（包括其所有子成员）下的差异。路径参数格式与 (*jsonvalue.V).Get() 相同。
+func OptIgnore(firstParam interface{}, otherParams ...interface{}) Option {
+	return optIgnore(append([]interface{}{firstParam}, otherParams...))
+}
+
+type optIgnore []interface{}
+
+func (o optIgnore) mergeTo(opts *options) {
+	opts.ignored = append(opts.ignored, []interface{}(o))
+}
+
+// ==== golden directory ====
+
+// OptGoldenDir specifies the directory of golden files used in Golden(). The default directory is "testdata".
+//
+// OptGoldenDir 指定 Golden() 所使用的黄金文件目录，默认为 "testdata"。
+func OptGoldenDir(dir string) Option {
+	return optGoldenDir(dir)
+}
+
+type optGoldenDir string
+
+func (o optGoldenDir) mergeTo(opts *options) {
+	opts.goldenDir = string(o)
+}
@@ -0,0 +1,45 @@
+package jsonvaluetest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	cv = convey.Convey
+	so = convey.So
+
+	eq = convey.ShouldEqual
+
+	isTrue    = convey.ShouldBeTrue
+	isFalse   = convey.ShouldBeFalse
+	hasSubStr = convey.ShouldContainSubstring
+	notSubStr = convey.ShouldNotContainSubstring
+)
+
+// mockT records failures instead of failing the real test.
+type mockT struct {
+	errs []string
+}
+
+func (t *mockT) Helper() {}
+
+func (t *mockT) Errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func (t *mockT) output() string {
+	if len(t.errs) == 0 {
+		return ""
+	}
+	return t.errs[len(t.errs)-1]
+}
+
+func TestJsonvaluetest(t *testing.T) {
+	cv("test AssertEqual()", t, func() { testAssertEqual(t) })
+	cv("test AssertContains()", t, func() { testAssertContains(t) })
+	cv("test AssertPath()", t, func() { testAssertPath(t) })
+	cv("test Golden()", t, func() { testGolden(t) })
+}
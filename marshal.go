@@ -41,7 +41,11 @@ func (v *V) Marshal(opts ...Option) (b []byte, err error) {
 	buf := bytes.Buffer{}
 	opt := combineOptions(opts)
 
-	err = v.marshalToBuffer(nil, &buf, opt)
+	if opt.canonical {
+		err = v.marshalCanonicalToBuffer(&buf)
+	} else {
+		err = v.marshalToBuffer(nil, &buf, opt)
+	}
 	if err != nil {
 		return []byte{}, err
 	}
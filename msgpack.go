@@ -0,0 +1,417 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MarshalMsgPack encodes *V into MessagePack format. Integers are encoded as int or uint families in the smallest
+// form, while floats are encoded as float 64, thus there is no precision lost. NaN and Inf values are encoded as
+// they are. Key sequence options such as OptSetSequence(), OptKeySequence() and OptKeySequenceWithLessFunc() are
+// respected, so as OptOmitNull().
+//
+// MarshalMsgPack 将 *V 编码为 MessagePack 格式。整数以最小的 int 或 uint 族格式编码，而浮点数则以 float 64 格式编码，因此不会
+// 丢失精度。NaN 和 Inf 值按原样编码。支持 OptSetSequence()、OptKeySequence() 以及 OptKeySequenceWithLessFunc() 等键顺序
+// 选项，以及 OptOmitNull()。
+func (v *V) MarshalMsgPack(opts ...Option) ([]byte, error) {
+	if v == nil || v.valueType == NotExist {
+		return nil, ErrValueUninitialized
+	}
+
+	buf := bytes.Buffer{}
+	opt := combineOptions(opts)
+	if err := v.marshalMsgPackToBuffer(nil, &buf, opt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalMsgPack decodes MessagePack data into *V. Binary data are decoded into base64 strings, which is
+// consistent with NewBytes(). Timestamp extension values are decoded into RFC 3339 strings, while other extension
+// types are not supported. Integer map keys are converted into decimal strings. Key sequence is kept as set
+// sequence, which could be used in OptSetSequence().
+//
+// UnmarshalMsgPack 将 MessagePack 数据解码为 *V。二进制数据被解码为 base64 字符串，与 NewBytes() 保持一致。时间戳扩展类型的值
+// 被解码为 RFC 3339 字符串，不支持其他扩展类型。整型的 map 键会被转为十进制字符串。键的顺序被保存为 set 顺序，可用于
+// OptSetSequence()。
+func UnmarshalMsgPack(b []byte) (*V, error) {
+	d := msgPackDecoder{b: b}
+	v, err := d.decode()
+	if err != nil {
+		return &V{}, err
+	}
+	if d.off != len(b) {
+		return &V{}, fmt.Errorf("%w: %d extra bytes after MessagePack value", ErrRawBytesUnrecignized, len(b)-d.off)
+	}
+	return v, nil
+}
+
+// ---------------- encoding ----------------
+
+func (v *V) marshalMsgPackToBuffer(parentInfo *ParentInfo, buf *bytes.Buffer, opt *Opt) error {
+	switch v.valueType {
+	default:
+		return fmt.Errorf("%w: %v", ErrTypeNotMatch, v.valueType)
+
+	case Null:
+		buf.WriteByte(0xc0)
+
+	case Boolean:
+		if v.valueBool {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+
+	case Number:
+		writeMsgPackNumber(buf, v)
+
+	case String:
+		writeMsgPackString(buf, v.valueStr)
+
+	case Array:
+		writeMsgPackLength(buf, len(v.children.arr), 0x90, 15, 0xdc)
+		for i, child := range v.children.arr {
+			var par *ParentInfo
+			if opt.MarshalLessFunc != nil {
+				par = v.newParentInfo(parentInfo, intKey(i))
+			}
+			if err := child.marshalMsgPackToBuffer(par, buf, opt); err != nil {
+				return err
+			}
+		}
+
+	case Object:
+		keys, values := v.sortedObjectChildren(parentInfo, opt)
+		cnt := len(keys)
+		if opt.OmitNull {
+			for _, child := range values {
+				if child.valueType == Null {
+					cnt--
+				}
+			}
+		}
+
+		writeMsgPackLength(buf, cnt, 0x80, 15, 0xde)
+		for i, k := range keys {
+			child := values[i]
+			if opt.OmitNull && child.valueType == Null {
+				continue
+			}
+			var par *ParentInfo
+			if opt.MarshalLessFunc != nil {
+				par = child.newParentInfo(parentInfo, stringKey(k))
+			}
+			writeMsgPackString(buf, k)
+			if err := child.marshalMsgPackToBuffer(par, buf, opt); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeMsgPackNumber(buf *bytes.Buffer, v *V) {
+	switch {
+	case v.isFloatNumber():
+		buf.WriteByte(0xcb)
+		writeBigEndian(buf, math.Float64bits(v.num.f64), 8)
+
+	case v.num.negative:
+		i := v.num.i64
+		switch {
+		case i >= -32:
+			buf.WriteByte(byte(int8(i)))
+		case i >= math.MinInt8:
+			buf.WriteByte(0xd0)
+			buf.WriteByte(byte(int8(i)))
+		case i >= math.MinInt16:
+			buf.WriteByte(0xd1)
+			writeBigEndian(buf, uint64(uint16(int16(i))), 2)
+		case i >= math.MinInt32:
+			buf.WriteByte(0xd2)
+			writeBigEndian(buf, uint64(uint32(int32(i))), 4)
+		default:
+			buf.WriteByte(0xd3)
+			writeBigEndian(buf, uint64(i), 8)
+		}
+
+	default:
+		u := v.num.u64
+		switch {
+		case u <= 0x7f:
+			buf.WriteByte(byte(u))
+		case u <= math.MaxUint8:
+			buf.WriteByte(0xcc)
+			buf.WriteByte(byte(u))
+		case u <= math.MaxUint16:
+			buf.WriteByte(0xcd)
+			writeBigEndian(buf, u, 2)
+		case u <= math.MaxUint32:
+			buf.WriteByte(0xce)
+			writeBigEndian(buf, u, 4)
+		default:
+			buf.WriteByte(0xcf)
+			writeBigEndian(buf, u, 8)
+		}
+	}
+}
+
+func writeMsgPackString(buf *bytes.Buffer, s string) {
+	le := len(s)
+	switch {
+	case le <= 31:
+		buf.WriteByte(0xa0 | byte(le))
+	case le <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(le))
+	case le <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		writeBigEndian(buf, uint64(le), 2)
+	default:
+		buf.WriteByte(0xdb)
+		writeBigEndian(buf, uint64(le), 4)
+	}
+	buf.WriteString(s)
+}
+
+// writeMsgPackLength writes header of array or map. Code of 32-bit length follows that of 16-bit length.
+func writeMsgPackLength(buf *bytes.Buffer, le int, fixPrefix byte, fixMax int, code16 byte) {
+	switch {
+	case le <= fixMax:
+		buf.WriteByte(fixPrefix | byte(le))
+	case le <= math.MaxUint16:
+		buf.WriteByte(code16)
+		writeBigEndian(buf, uint64(le), 2)
+	default:
+		buf.WriteByte(code16 + 1)
+		writeBigEndian(buf, uint64(le), 4)
+	}
+}
+
+func writeBigEndian(buf *bytes.Buffer, u uint64, size int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], u)
+	buf.Write(b[8-size:])
+}
+
+// ---------------- decoding ----------------
+
+type msgPackDecoder struct {
+	b   []byte
+	off int
+}
+
+func (d *msgPackDecoder) errorf(format string, a ...any) error {
+	return fmt.Errorf("%w: MessagePack at offset %d: %s", ErrRawBytesUnrecignized, d.off, fmt.Sprintf(format, a...))
+}
+
+func (d *msgPackDecoder) readN(n int) ([]byte, error) {
+	if n < 0 || len(d.b)-d.off < n {
+		return nil, d.errorf("unexpected end of data")
+	}
+	b := d.b[d.off : d.off+n]
+	d.off += n
+	return b, nil
+}
+
+func (d *msgPackDecoder) readUint(size int) (uint64, error) {
+	b, err := d.readN(size)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (d *msgPackDecoder) decode() (*V, error) {
+	b, err := d.readN(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return NewUint64(uint64(c)), nil
+	case c >= 0xe0:
+		return NewInt64(int64(int8(c))), nil
+	case c&0xe0 == 0xa0:
+		return d.decodeString(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.decodeArray(int(c & 0x0f))
+	case c&0xf0 == 0x80:
+		return d.decodeMap(int(c & 0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return NewNull(), nil
+	case 0xc2:
+		return NewBool(false), nil
+	case 0xc3:
+		return NewBool(true), nil
+
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.readUint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		return NewUint64(u), nil
+
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		u, err := d.readUint(size)
+		if err != nil {
+			return nil, err
+		}
+		// sign extension
+		shift := uint(64 - size*8)
+		return NewInt64(int64(u<<shift) >> shift), nil
+
+	case 0xca:
+		u, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return newDecodedFloat(NewFloat32(math.Float32frombits(uint32(u)))), nil
+
+	case 0xcb:
+		u, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return newDecodedFloat(NewFloat64(math.Float64frombits(u))), nil
+
+	case 0xd9, 0xda, 0xdb:
+		le, err := d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(le))
+
+	case 0xc4, 0xc5, 0xc6:
+		le, err := d.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.readN(int(le))
+		if err != nil {
+			return nil, err
+		}
+		return NewBytes(data), nil
+
+	case 0xdc, 0xdd:
+		le, err := d.readUint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(le))
+
+	case 0xde, 0xdf:
+		le, err := d.readUint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(le))
+
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (c - 0xd4))
+
+	case 0xc7, 0xc8, 0xc9:
+		le, err := d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(int(le))
+	}
+
+	d.off--
+	return nil, d.errorf("unsupported type code 0x%02x", c)
+}
+
+func (d *msgPackDecoder) decodeString(le int) (*V, error) {
+	b, err := d.readN(le)
+	if err != nil {
+		return nil, err
+	}
+	return NewString(string(b)), nil
+}
+
+func (d *msgPackDecoder) decodeArray(le int) (*V, error) {
+	// each element takes at least one byte
+	if le > len(d.b)-d.off {
+		return nil, d.errorf("array length %d exceeds data", le)
+	}
+	arr := newArray()
+	for i := 0; i < le; i++ {
+		child, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		arr.appendToArr(child)
+	}
+	return arr, nil
+}
+
+func (d *msgPackDecoder) decodeMap(le int) (*V, error) {
+	// each key-value pair takes at least two bytes
+	if le > (len(d.b)-d.off)/2 {
+		return nil, d.errorf("map length %d exceeds data", le)
+	}
+	obj := newObject()
+	for i := 0; i < le; i++ {
+		k, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		if k.valueType != String && !(k.valueType == Number && !k.num.floated) {
+			return nil, d.errorf("unsupported map key type %v", k.valueType)
+		}
+		child, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		obj.setToObjectChildren(k.String(), child)
+	}
+	return obj, nil
+}
+
+func (d *msgPackDecoder) decodeExt(le int) (*V, error) {
+	typ, err := d.readN(1)
+	if err != nil {
+		return nil, err
+	}
+	data, err := d.readN(le)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(typ[0]) != -1 {
+		d.off -= le + 1
+		return nil, d.errorf("unsupported extension type %d", int8(typ[0]))
+	}
+
+	// timestamp extension
+	var t time.Time
+	switch le {
+	default:
+		return nil, d.errorf("invalid timestamp length %d", le)
+	case 4:
+		t = time.Unix(int64(binary.BigEndian.Uint32(data)), 0)
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		t = time.Unix(int64(u&0x3ffffffff), int64(u>>34))
+	case 12:
+		nsec := binary.BigEndian.Uint32(data[:4])
+		sec := int64(binary.BigEndian.Uint64(data[4:]))
+		t = time.Unix(sec, int64(nsec))
+	}
+	return NewString(t.UTC().Format(time.RFC3339Nano)), nil
+}
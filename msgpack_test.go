@@ -0,0 +1,174 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testMsgPack(t *testing.T) {
+	cv("scalars", func() { testMsgPackScalars(t) })
+	cv("containers and sequences", func() { testMsgPackContainers(t) })
+	cv("decoding", func() { testMsgPackDecoding(t) })
+	cv("errors", func() { testMsgPackErrors(t) })
+}
+
+func msgPackHex(v *V, opts ...Option) string {
+	b, err := v.MarshalMsgPack(opts...)
+	so(err, isNil)
+	return hex.EncodeToString(b)
+}
+
+func testMsgPackScalars(t *testing.T) {
+	so(msgPackHex(NewNull()), eq, "c0")
+	so(msgPackHex(NewBool(true)), eq, "c3")
+	so(msgPackHex(NewBool(false)), eq, "c2")
+
+	so(msgPackHex(NewInt(1)), eq, "01")
+	so(msgPackHex(NewInt(-1)), eq, "ff")
+	so(msgPackHex(NewInt(-33)), eq, "d0df")
+	so(msgPackHex(NewInt(200)), eq, "ccc8")
+	so(msgPackHex(NewInt(-200)), eq, "d1ff38")
+	so(msgPackHex(NewInt(65536)), eq, "ce00010000")
+	so(msgPackHex(NewInt64(-1<<40)), eq, "d3ffffff0000000000")
+	so(msgPackHex(NewUint64(math.MaxUint64)), eq, "cfffffffffffffffff")
+	so(msgPackHex(NewFloat64(1.5)), eq, "cb3ff8000000000000")
+	so(msgPackHex(MustUnmarshalString(`2.0`)), eq, "cb4000000000000000")
+	so(msgPackHex(NewFloat64(1e20)), eq, "cb4415af1d78b58c40")
+	so(msgPackHex(NewFloat64(math.Copysign(0, -1))), eq, "cb8000000000000000")
+
+	// constructors are not affected: integral values created by NewFloat64() are integers
+	so(NewFloat64(3).IsInteger(), isTrue)
+	so(NewFloat64(3).IsFloat(), isFalse)
+	so(msgPackHex(NewFloat64(3)), eq, "03")
+	v, err := UnmarshalMsgPack([]byte{0xcb, 0x40, 0, 0, 0, 0, 0, 0, 0})
+	so(err, isNil)
+	so(v.IsFloat(), isTrue)
+	so(msgPackHex(v), eq, "cb4000000000000000")
+
+	so(msgPackHex(NewString("abc")), eq, "a3616263")
+	so(msgPackHex(NewString(strings.Repeat("a", 32)))[:4], eq, "d920")
+	so(msgPackHex(NewString(strings.Repeat("a", 256)))[:6], eq, "da0100")
+
+	// NaN and Inf are supported by MessagePack
+	b, err := NewFloat64(math.Inf(1)).MarshalMsgPack()
+	so(err, isNil)
+	v, err = UnmarshalMsgPack(b)
+	so(err, isNil)
+	so(math.IsInf(v.Float64(), 1), isTrue)
+
+	// round trip
+	for _, s := range []string{
+		`0`, `127`, `128`, `-32`, `-128`, `-129`, `-32768`, `-32769`, `-2147483648`, `-2147483649`,
+		`255`, `256`, `65535`, `65536`, `4294967295`, `4294967296`, `18446744073709551615`, `-9223372036854775808`,
+		`3.1415926`, `-0.5`, `"中文"`, `true`, `null`,
+	} {
+		v := MustUnmarshalString(s)
+		b, err := v.MarshalMsgPack()
+		so(err, isNil)
+		got, err := UnmarshalMsgPack(b)
+		so(err, isNil)
+		so(got.Equal(v), isTrue)
+	}
+}
+
+func testMsgPackContainers(t *testing.T) {
+	v := MustUnmarshalString(`{"b":1,"a":[true,null,"s"],"c":{"z":null,"y":{}}}`)
+
+	b, err := v.MarshalMsgPack(OptSetSequence())
+	so(err, isNil)
+	got, err := UnmarshalMsgPack(b)
+	so(err, isNil)
+	so(got.MustMarshalString(OptSetSequence()), eq, `{"b":1,"a":[true,null,"s"],"c":{"z":null,"y":{}}}`)
+
+	b, err = v.MarshalMsgPack(OptKeySequence([]string{"c", "a"}), OptOmitNull(true))
+	so(err, isNil)
+	got, err = UnmarshalMsgPack(b)
+	so(err, isNil)
+	so(got.MustMarshalString(OptSetSequence()), eq, `{"c":{"y":{}},"a":[true,null,"s"],"b":1}`)
+
+	b, err = v.MarshalMsgPack(OptDefaultStringSequence())
+	so(err, isNil)
+	so(hex.EncodeToString(b)[:6], eq, "83a161")
+
+	// large containers
+	arr := NewArray()
+	obj := NewObject()
+	for i := 0; i < 70000; i++ {
+		arr.AppendInt(i).InTheEnd()
+	}
+	for i := 0; i < 20; i++ {
+		obj.SetInt(i).At(strings.Repeat("k", i+1))
+	}
+	b, err = arr.MarshalMsgPack()
+	so(err, isNil)
+	so(b[0], eq, 0xdd)
+	got, err = UnmarshalMsgPack(b)
+	so(err, isNil)
+	so(got.Len(), eq, 70000)
+	so(got.MustGet(69999).Int(), eq, 69999)
+
+	b, err = obj.MarshalMsgPack()
+	so(err, isNil)
+	so(b[0], eq, 0xde)
+	got, err = UnmarshalMsgPack(b)
+	so(err, isNil)
+	so(got.Equal(obj), isTrue)
+}
+
+func testMsgPackDecoding(t *testing.T) {
+	decode := func(s string) *V {
+		b, err := hex.DecodeString(s)
+		so(err, isNil)
+		v, err := UnmarshalMsgPack(b)
+		so(err, isNil)
+		return v
+	}
+
+	// binary into base64, same as NewBytes
+	so(decode("c403010203").String(), eq, NewBytes([]byte{1, 2, 3}).String())
+	// float 32
+	so(decode("ca3fc00000").Float64(), eq, 1.5)
+	// integer keys
+	so(decode("8101a161").MustGet("1").String(), eq, "a")
+	so(decode("81d0ffa161").MustGet("-1").String(), eq, "a")
+	// timestamps
+	so(decode("d6ff00000001").String(), eq, "1970-01-01T00:00:01Z")
+	so(decode("d7ff0000000400000001").String(), eq, "1970-01-01T00:00:01.000000001Z")
+	so(decode("c70cff000000010000000000000002").String(), eq, "1970-01-01T00:00:02.000000001Z")
+	// array 16 and map 16
+	so(decode("dc000101").MustMarshalString(), eq, `[1]`)
+	so(decode("de0001a16101").MustMarshalString(), eq, `{"a":1}`)
+	// str 8
+	so(decode("d903616263").String(), eq, "abc")
+}
+
+func testMsgPackErrors(t *testing.T) {
+	_, err := (&V{}).MarshalMsgPack()
+	so(errors.Is(err, ErrValueUninitialized), isTrue)
+
+	for _, s := range []string{
+		"",           // empty
+		"c1",         // never used
+		"cd01",       // truncated uint16
+		"a3616",      // truncated string
+		"93010203ff", // extra bytes
+		"dcffff",     // array length exceeds data
+		"df7fffffff", // map length exceeds data
+		"81c0c0",     // nil key
+		"d40100",     // unknown extension
+		"d5ff0000",   // invalid timestamp
+	} {
+		b, _ := hex.DecodeString(s)
+		v, err := UnmarshalMsgPack(b)
+		so(err, isErr)
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+		so(v.ValueType(), eq, NotExist)
+	}
+
+	_, err = UnmarshalMsgPack(bytes.Repeat([]byte{0x91}, 10))
+	so(err, isErr)
+}
@@ -0,0 +1,91 @@
+package jsonvalue
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FieldNaming defines how to name struct fields without names in tags, used in OptFieldNaming().
+//
+// FieldNaming 定义了如何为标签中没有指定名称的结构体字段命名，用于 OptFieldNaming()。
+type FieldNaming int
+
+const (
+	// FieldNameAsIs keeps Go field names, which is the default behavior just like encoding/json. E.g. UserID.
+	//
+	// FieldNameAsIs 保留 Go 字段名，这也是与 encoding/json 一致的默认行为。如 UserID。
+	FieldNameAsIs FieldNaming = iota
+	// SnakeCase converts Go field names into snake_case. E.g. UserID -> user_id.
+	//
+	// SnakeCase 将 Go 字段名转为 snake_case 形式。如 UserID -> user_id。
+	SnakeCase
+	// CamelCase converts Go field names into camelCase. E.g. UserID -> userId.
+	//
+	// CamelCase 将 Go 字段名转为 camelCase 形式。如 UserID -> userId。
+	CamelCase
+	// KebabCase converts Go field names into kebab-case. E.g. UserID -> user-id.
+	//
+	// KebabCase 将 Go 字段名转为 kebab-case 形式。如 UserID -> user-id。
+	KebabCase
+)
+
+func (n FieldNaming) convert(name string) string {
+	switch n {
+	default:
+		return name
+	case SnakeCase:
+		return strings.ToLower(strings.Join(splitFieldNameWords(name), "_"))
+	case KebabCase:
+		return strings.ToLower(strings.Join(splitFieldNameWords(name), "-"))
+	case CamelCase:
+		words := splitFieldNameWords(name)
+		for i, w := range words {
+			w = strings.ToLower(w)
+			if i > 0 {
+				r := []rune(w)
+				r[0] = unicode.ToUpper(r[0])
+				w = string(r)
+			}
+			words[i] = w
+		}
+		return strings.Join(words, "")
+	}
+}
+
+// splitFieldNameWords splits a Go identifier into words, with acronyms and digits kept together.
+// E.g. "HTTPServer2Name" -> ["HTTP", "Server2", "Name"].
+func splitFieldNameWords(name string) []string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, curr := runes[i-1], runes[i]
+		split := false
+
+		switch {
+		case curr == '_':
+			split = true
+		case unicode.IsUpper(curr):
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				// "userID" -> "user" | "ID"
+				split = true
+			} else if unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				// "HTTPServer" -> "HTTP" | "Server"
+				split = true
+			}
+		}
+
+		if split {
+			if w := strings.Trim(string(runes[start:i]), "_"); w != "" {
+				words = append(words, w)
+			}
+			start = i
+		}
+	}
+
+	if w := strings.Trim(string(runes[start:]), "_"); w != "" {
+		words = append(words, w)
+	}
+	return words
+}
@@ -0,0 +1,102 @@
+package jsonvalue
+
+import (
+	"testing"
+)
+
+func testFieldNaming(t *testing.T) {
+	cv("naming conversion", func() { testFieldNamingConversion(t) })
+	cv("import with tag and naming", func() { testImportTagAndNaming(t) })
+	cv("export with tag and naming", func() { testExportTagAndNaming(t) })
+}
+
+func testFieldNamingConversion(t *testing.T) {
+	cases := []struct {
+		name, snake, camel, kebab string
+	}{
+		{"Name", "name", "name", "name"},
+		{"UserID", "user_id", "userId", "user-id"},
+		{"HTTPServer", "http_server", "httpServer", "http-server"},
+		{"Server2Name", "server2_name", "server2Name", "server2-name"},
+		{"Already_Snake", "already_snake", "alreadySnake", "already-snake"},
+		{"ID", "id", "id", "id"},
+		{"A", "a", "a", "a"},
+		{"ÜberName", "über_name", "überName", "über-name"},
+	}
+
+	for _, c := range cases {
+		so(FieldNameAsIs.convert(c.name), eq, c.name)
+		so(SnakeCase.convert(c.name), eq, c.snake)
+		so(CamelCase.convert(c.name), eq, c.camel)
+		so(KebabCase.convert(c.name), eq, c.kebab)
+	}
+}
+
+type namingTestInner struct {
+	InnerID int
+}
+
+type namingTestStruct struct {
+	namingTestInner
+	UserID    int    `yaml:"uid"`
+	FirstName string `yaml:",omitempty"`
+	JSONOnly  string `json:"json_only" yaml:"-"`
+	Tagged    string `json:"json_tagged" yaml:"yaml_tagged"`
+}
+
+func testImportTagAndNaming(t *testing.T) {
+	st := namingTestStruct{
+		namingTestInner: namingTestInner{InnerID: 1},
+		UserID:          2,
+		JSONOnly:        "json",
+		Tagged:          "tagged",
+	}
+
+	v, err := Import(st, OptImportTag("yaml"))
+	so(err, isNil)
+	so(v.MustMarshalString(OptDefaultStringSequence()), eq, `{"InnerID":1,"uid":2,"yaml_tagged":"tagged"}`)
+
+	v, err = Import(st, OptImportTag("yaml"), OptFieldNaming(SnakeCase))
+	so(err, isNil)
+	so(v.MustMarshalString(OptDefaultStringSequence()), eq, `{"inner_id":1,"uid":2,"yaml_tagged":"tagged"}`)
+
+	v, err = Import(st, OptFieldNaming(CamelCase))
+	so(err, isNil)
+	so(v.MustMarshalString(OptDefaultStringSequence()), eq,
+		`{"firstName":"","innerId":1,"json_only":"json","json_tagged":"tagged","userId":2}`)
+
+	v, err = Import([]any{&st}, OptFieldNaming(KebabCase))
+	so(err, isNil)
+	so(v.MustGet(0, "user-id").Int(), eq, 2)
+
+	v, err = Import(st, OptImportTag(""))
+	so(err, isNil)
+	so(v.MustGet("json_only").String(), eq, "json")
+}
+
+func testExportTagAndNaming(t *testing.T) {
+	v := MustUnmarshalString(`{"inner_id":1,"uid":2,"first_name":"Andrew","JSONOnly":"json","yaml_tagged":"tagged"}`)
+
+	st := namingTestStruct{}
+	err := v.Export(&st, OptImportTag("yaml"), OptFieldNaming(SnakeCase))
+	so(err, isNil)
+	so(st.InnerID, eq, 1)
+	so(st.UserID, eq, 2)
+	so(st.FirstName, eq, "Andrew")
+	so(st.JSONOnly, eq, "")
+	so(st.Tagged, eq, "tagged")
+
+	// field cache should be separated by options
+	st = namingTestStruct{}
+	err = v.Export(&st)
+	so(err, isNil)
+	so(st.InnerID, eq, 0)
+	so(st.UserID, eq, 0)
+	so(st.FirstName, eq, "")
+
+	v = MustUnmarshalString(`{"userId":3,"firstName":"M"}`)
+	err = v.Export(&st, OptFieldNaming(CamelCase))
+	so(err, isNil)
+	so(st.UserID, eq, 3)
+	so(st.FirstName, eq, "M")
+}
@@ -1,6 +1,7 @@
 package jsonvalue
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"math"
@@ -10,11 +11,12 @@ import (
 
 // New generate a new jsonvalue type via given type. If given type is not supported,
 // the returned type would equal to NotExist. If you are not sure whether given value
-// type is OK in runtime, use Import() instead.
+// type is OK in runtime, use Import() instead. Values of *V type in the parameter are
+// referenced rather than copied, see Import() for details.
 //
 // New 函数按照给定参数类型创建一个 jsonvalue 类型。如果给定参数不是 JSON 支持的类型, 那么返回的
 // *V 对象的类型为 NotExist。如果在代码中无法确定入参是否是 JSON 支持的类型, 请改用函数
-// Import()。
+// Import()。参数中的 *V 类型值会被直接引用而非复制，详见 Import()。
 func New(value any) *V {
 	v, _ := Import(value)
 	return v
@@ -243,6 +245,34 @@ func newFloat64f(f float64, format byte, prec, bitsize int) *V {
 	return v
 }
 
+// isFloatNumber tells whether a number should be encoded as a float in binary formats. Numbers created by
+// NewFloat64() or NewFloat32() are not marked as floated, thus fractions, NaN, Inf, negative zero, values out of
+// integer ranges and float notations in source text are also regarded as floats.
+func (v *V) isFloatNumber() bool {
+	if v.num.floated {
+		return true
+	}
+	f := v.num.f64
+	switch {
+	case math.IsNaN(f), math.IsInf(f, 0), f != math.Trunc(f):
+		return true
+	case f == 0 && math.Signbit(f):
+		return true
+	case v.num.negative && math.Abs(float64(v.num.i64)) != math.Abs(f):
+		return true // out of int64 range
+	case !v.num.negative && float64(v.num.u64) != f:
+		return true // out of uint64 range
+	}
+	return bytes.ContainsAny(v.srcByte, ".eE")
+}
+
+// newDecodedFloat marks a float number decoded from binary formats as floated, so that it is encoded as a float
+// again.
+func newDecodedFloat(v *V) *V {
+	v.num.floated = true
+	return v
+}
+
 func validateFloat64Format(f byte) error {
 	switch f {
 	case 'f', 'E', 'e', 'G', 'g':
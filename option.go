@@ -2,6 +2,7 @@ package jsonvalue
 
 import (
 	"bytes"
+	"reflect"
 )
 
 const (
@@ -28,6 +29,61 @@ type Opt struct {
 	// would be parsed into *jsonvalue.V
 	ignoreJsonOmitempty bool
 
+	// typeConverters contains per-call converters configured by OptTypeConverter(), which take priority over
+	// those registered by RegisterTypeConverter().
+	typeConverters map[reflect.Type]typeConverter
+
+	// importTag is the struct tag name used in Import() and Export(), "json" by default.
+	importTag string
+
+	// fieldNaming defines names of struct fields without names in tags when importing and exporting.
+	fieldNaming FieldNaming
+
+	// importMaxDepth limits nesting depth in Import(), 0 means no limit.
+	importMaxDepth int
+
+	// importCycleAsNull makes Import() emit null at reference cycles instead of returning an error.
+	importCycleAsNull bool
+
+	// exportStrict makes Export() reject unknown fields, nulls into non-nullable values and overflowed arrays.
+	exportStrict bool
+
+	// interfaceNumber defines number representation in Interface() and in exporting into interface{} values.
+	interfaceNumber InterfaceNumber
+
+	// cborTag, cborByteString and cborUndefined define conversions of CBOR data items in UnmarshalCBOR().
+	cborTag        CBORConversion
+	cborByteString CBORConversion
+	cborUndefined  CBORConversion
+
+	// cborDeterministic makes MarshalCBOR() apply core deterministic encoding requirements.
+	cborDeterministic bool
+
+	// bsonCanonical makes UnmarshalBSON() output canonical Extended JSON instead of relaxed one.
+	bsonCanonical bool
+
+	// xmlConvention, xmlNamespace, xmlForceArray and xmlRoot define conventions of FromXML() and ToXML().
+	xmlConvention XMLConvention
+	xmlNamespace  XMLNamespace
+	xmlForceArray []string
+	xmlRoot       string
+
+	// csvColumns, csvDelimiter, csvNoHeader, csvArrayEncoding and csvArraySeparator define formats of FromCSV() and
+	// ToCSV().
+	csvColumns        []string
+	csvDelimiter      rune
+	csvNoHeader       bool
+	csvArrayEncoding  CSVArrayEncoding
+	csvArraySeparator string
+
+	// flattenSeparator, flattenArrayNotation and flattenMaxDepth define key formats of Flatten() and Unflatten().
+	flattenSeparator     string
+	flattenArrayNotation FlattenArrayNotation
+	flattenMaxDepth      int
+
+	// urlValuesNoInference disables type inference in FromURLValues().
+	urlValuesNoInference bool
+
 	// MarshalLessFunc is used to handle sequences of marshaling. Since object is
 	// implemented by hash map, the sequence of keys is unexpectable. For situations
 	// those need settled JSON key-value sequence, please use MarshalLessFunc.
@@ -63,6 +119,11 @@ type Opt struct {
 	// 按照 key 被设置的顺序处理序列化时的 marshal 顺序
 	marshalBySetSequence bool
 
+	// canonical enables RFC 8785 JSON Canonicalization Scheme when marshaling.
+	//
+	// 按照 RFC 8785 (JCS) 规范序列化
+	canonical bool
+
 	// FloatNaNHandleType tells what to deal with float NaN.
 	//
 	// FloatNaNHandleType 表示当处理 float 的时候，如果遇到了 NaN 的话，要如何处理。
@@ -212,7 +273,10 @@ func ResetDefaultMarshalOptions() {
 }
 
 func emptyOptions() *Opt {
-	return &Opt{}
+	// escaping functions are ready, so that default options could be used directly, such as in KeyPath.String()
+	opt := &Opt{}
+	opt.parseEscapingFuncs()
+	return opt
 }
 
 func getDefaultOptions() *Opt {
@@ -269,6 +333,424 @@ func (optIgnoreOmitempty) mergeTo(opt *Opt) {
 	opt.ignoreJsonOmitempty = true
 }
 
+// ==== typeConverters ====
+
+// OptTypeConverter is used in Import() and Export() functions. It configures type converters for given type
+// in current function call only, which take priority over those registered by RegisterTypeConverter(). Please refer
+// to RegisterTypeConverter() for details of parameters.
+//
+// OptTypeConverter 用在 Import() 和 Export() 函数中，为指定类型配置仅在本次调用中生效的转换函数，优先级高于
+// RegisterTypeConverter() 注册的转换函数。参数的含义请参见 RegisterTypeConverter()。
+func OptTypeConverter(
+	t reflect.Type, toV func(reflect.Value) (*V, error), fromV func(*V, reflect.Value) error,
+) Option {
+	return &optTypeConverter{t: t, c: typeConverter{toV: toV, fromV: fromV}}
+}
+
+type optTypeConverter struct {
+	t reflect.Type
+	c typeConverter
+}
+
+func (o *optTypeConverter) mergeTo(opt *Opt) {
+	if o.t == nil {
+		return
+	}
+	// copy on write, as the map may be shared with default options
+	m := make(map[reflect.Type]typeConverter, len(opt.typeConverters)+1)
+	for t, c := range opt.typeConverters {
+		m[t] = c
+	}
+	m[o.t] = o.c
+	opt.typeConverters = m
+}
+
+// ==== importTag ====
+
+// OptImportTag is used in Import(), Export() and other functions converting with Go values. It specifies the struct
+// tag name instead of "json", such as "yaml". Format of the tag should be the same as "json" tag. Empty tag name
+// means "json".
+//
+// OptImportTag 用在 Import()、Export() 等与 Go 类型互相转换的函数中，指定使用的结构体标签名，以替代 "json"，比如 "yaml"。
+// 标签的格式需与 "json" 标签一致。空字符串表示 "json"。
+func OptImportTag(tag string) Option {
+	return optImportTag(tag)
+}
+
+type optImportTag string
+
+func (o optImportTag) mergeTo(opt *Opt) {
+	opt.importTag = string(o)
+}
+
+// ==== fieldNaming ====
+
+// OptFieldNaming is used in Import(), Export() and other functions converting with Go values. It specifies naming
+// strategy of struct fields without names in tags, such as SnakeCase, CamelCase and KebabCase.
+//
+// OptFieldNaming 用在 Import()、Export() 等与 Go 类型互相转换的函数中，指定标签中没有指定名称的结构体字段的命名方式，
+// 如 SnakeCase、CamelCase 和 KebabCase。
+func OptFieldNaming(naming FieldNaming) Option {
+	return optFieldNaming(naming)
+}
+
+type optFieldNaming FieldNaming
+
+func (o optFieldNaming) mergeTo(opt *Opt) {
+	opt.fieldNaming = FieldNaming(o)
+}
+
+// ==== importMaxDepth ====
+
+// OptImportMaxDepth is used in Import() function. It limits the nesting depth of objects and arrays
+// when importing. An error wrapping ErrMaxDepthExceeded will be returned if the depth exceeds. Zero or negative
+// value means no limit, which is the default.
+//
+// OptImportMaxDepth 用在 Import() 函数中，限制导入时 object 和数组的最大嵌套深度。如果超出限制，则返回一个包含
+// ErrMaxDepthExceeded 的错误。零或负数表示不限制，这也是默认值。
+func OptImportMaxDepth(depth int) Option {
+	return optImportMaxDepth(depth)
+}
+
+type optImportMaxDepth int
+
+func (o optImportMaxDepth) mergeTo(opt *Opt) {
+	opt.importMaxDepth = int(o)
+}
+
+// ==== importCycleAsNull ====
+
+// OptImportCycleAsNull is used in Import() function. By default, Import() returns an error wrapping
+// ErrCycleDetected when a reference cycle of pointers, maps or slices is found. With this option, null will be
+// emitted at the point where the cycle occurs instead.
+//
+// OptImportCycleAsNull 用在 Import() 函数中。默认情况下，当 Import() 遇到指针、map 或切片的循环引用时，会返回一个包含
+// ErrCycleDetected 的错误。使用本选项后，则会在出现循环引用的位置输出 null。
+func OptImportCycleAsNull() Option {
+	return optImportCycleAsNull{}
+}
+
+type optImportCycleAsNull struct{}
+
+func (optImportCycleAsNull) mergeTo(opt *Opt) {
+	opt.importCycleAsNull = true
+}
+
+// ==== exportStrict ====
+
+// OptExportStrict is used in Export() and ExportAt() functions. In strict mode, exporting fails if an object key
+// matches no struct field, a null is exported into a non-nullable value such as int or struct, or an array has
+// more elements than the Go array. Type mismatches always fail, no matter strict mode or not.
+//
+// OptExportStrict 用在 Export() 和 ExportAt() 函数中。在严格模式下，如果 object 的某个键无法匹配任何结构体字段、null 被导出到
+// int 或结构体等不可为空的值中，或者数组元素个数超过 Go 数组长度，则导出失败。无论是否严格模式，类型不匹配均会导致失败。
+func OptExportStrict() Option {
+	return optExportStrict{}
+}
+
+type optExportStrict struct{}
+
+func (optExportStrict) mergeTo(opt *Opt) {
+	opt.exportStrict = true
+}
+
+// ==== interfaceNumber ====
+
+// OptInterfaceNumber is used in Interface(), Export() and ExportAt() functions, defining how numbers are
+// represented in plain Go values such as interface{}, map[string]any and []any. Default is NumberAsFloat64.
+//
+// OptInterfaceNumber 用在 Interface()、Export() 和 ExportAt() 函数中，定义数字在 interface{}、map[string]any 和 []any
+// 等原生 Go 值中的表示方式。默认为 NumberAsFloat64。
+func OptInterfaceNumber(n InterfaceNumber) Option {
+	return optInterfaceNumber(n)
+}
+
+type optInterfaceNumber InterfaceNumber
+
+func (o optInterfaceNumber) mergeTo(opt *Opt) {
+	opt.interfaceNumber = InterfaceNumber(o)
+}
+
+// ==== cbor conversions ====
+
+// OptCBORTag is used in UnmarshalCBOR(), defining how tags are converted. By default, tag numbers are dropped and
+// the tagged contents are decoded, except that bignums are decoded into numbers.
+//
+// OptCBORTag 用在 UnmarshalCBOR() 中，定义如何转换 tag。默认情况下，tag 号将被丢弃并解析被标记的内容，但 bignum 会被解析为数字。
+func OptCBORTag(c CBORConversion) Option {
+	return optCBORTag(c)
+}
+
+type optCBORTag CBORConversion
+
+func (o optCBORTag) mergeTo(opt *Opt) {
+	opt.cborTag = CBORConversion(o)
+}
+
+// OptCBORByteString is used in UnmarshalCBOR(), defining how byte strings are converted. By default, byte strings
+// are decoded into base64 strings.
+//
+// OptCBORByteString 用在 UnmarshalCBOR() 中，定义如何转换字节串。默认情况下，字节串被解析为 base64 字符串。
+func OptCBORByteString(c CBORConversion) Option {
+	return optCBORByteString(c)
+}
+
+type optCBORByteString CBORConversion
+
+func (o optCBORByteString) mergeTo(opt *Opt) {
+	opt.cborByteString = CBORConversion(o)
+}
+
+// OptCBORUndefined is used in UnmarshalCBOR(), defining how the undefined value and other unassigned simple values
+// are converted. By default, they are decoded into null.
+//
+// OptCBORUndefined 用在 UnmarshalCBOR() 中，定义如何转换 undefined 值以及其他未分配的简单值。默认情况下，它们被解析为 null。
+func OptCBORUndefined(c CBORConversion) Option {
+	return optCBORUndefined(c)
+}
+
+type optCBORUndefined CBORConversion
+
+func (o optCBORUndefined) mergeTo(opt *Opt) {
+	opt.cborUndefined = CBORConversion(o)
+}
+
+// ==== cborDeterministic ====
+
+// OptCBORDeterministic is used in MarshalCBOR(), telling that core deterministic encoding requirements (RFC 8949
+// section 4.2.1) should be applied. Map keys are sorted in bytewise lexicographic order of their encodings, and key
+// sequence options are ignored.
+//
+// OptCBORDeterministic 用在 MarshalCBOR() 中，表示按照核心确定性编码要求 (RFC 8949 第 4.2.1 节) 进行编码。map 的键按照其编码
+// 后的字节序进行排序，键顺序选项将被忽略。
+func OptCBORDeterministic() Option {
+	return optCBORDeterministic{}
+}
+
+type optCBORDeterministic struct{}
+
+func (optCBORDeterministic) mergeTo(opt *Opt) {
+	opt.cborDeterministic = true
+}
+
+// ==== bsonCanonical ====
+
+// OptBSONCanonical is used in UnmarshalBSON(), telling that values should be represented in canonical format of
+// MongoDB Extended JSON v2 instead of relaxed one. In canonical format, all numbers are represented by objects like
+// {"$numberInt": "1"}, and dates are always represented as {"$date": {"$numberLong": "..."}}.
+//
+// OptBSONCanonical 用在 UnmarshalBSON() 中，表示使用 MongoDB Extended JSON v2 的规范 (canonical) 格式而不是宽松 (relaxed) 格式
+// 表示值。在规范格式中，所有数字都表示为类似 {"$numberInt": "1"} 的 object, 日期则总是表示为 {"$date": {"$numberLong": "..."}}。
+func OptBSONCanonical() Option {
+	return optBSONCanonical{}
+}
+
+type optBSONCanonical struct{}
+
+func (optBSONCanonical) mergeTo(opt *Opt) {
+	opt.bsonCanonical = true
+}
+
+// ==== xml conventions ====
+
+// OptXMLConvention is used in FromXML() and ToXML(), defining how XML elements, attributes and texts are mapped to
+// JSON values. XMLConventionAttrText is used by default.
+//
+// OptXMLConvention 用在 FromXML() 和 ToXML() 中，定义 XML 元素、属性和文本与 JSON 值之间的映射规则。默认使用
+// XMLConventionAttrText。
+func OptXMLConvention(c XMLConvention) Option {
+	return optXMLConvention(c)
+}
+
+type optXMLConvention XMLConvention
+
+func (o optXMLConvention) mergeTo(opt *Opt) {
+	opt.xmlConvention = XMLConvention(o)
+}
+
+// OptXMLNamespace is used in FromXML() and ToXML(), defining how namespaces of element and attribute names are
+// handled. XMLNamespacePrefix is used by default.
+//
+// OptXMLNamespace 用在 FromXML() 和 ToXML() 中，定义如何处理元素名和属性名的命名空间。默认使用 XMLNamespacePrefix。
+func OptXMLNamespace(n XMLNamespace) Option {
+	return optXMLNamespace(n)
+}
+
+type optXMLNamespace XMLNamespace
+
+func (o optXMLNamespace) mergeTo(opt *Opt) {
+	opt.xmlNamespace = XMLNamespace(o)
+}
+
+// OptXMLForceArray is used in FromXML(), telling that elements on given paths should always be decoded into arrays,
+// even if they appear only once. A path starting with "/" is an absolute path of element names from the root
+// element, such as "/catalog/book". Otherwise it matches the tail of element paths, such as "book" or "shelf/book".
+// Element names in paths are those after namespace handling.
+//
+// OptXMLForceArray 用在 FromXML() 中，表示指定路径上的元素总是被解析为数组，即便它们只出现了一次。以 "/" 开头的路径是从根元素开始
+// 的元素名绝对路径，如 "/catalog/book"; 否则匹配元素路径的末尾部分，如 "book" 或 "shelf/book"。路径中的元素名为经过命名空间处理后
+// 的名称。
+func OptXMLForceArray(paths ...string) Option {
+	return optXMLForceArray(paths)
+}
+
+type optXMLForceArray []string
+
+func (o optXMLForceArray) mergeTo(opt *Opt) {
+	opt.xmlForceArray = append(opt.xmlForceArray, o...)
+}
+
+// OptXMLRoot is used in ToXML(), specifying name of the root element, whose content is the whole *V. Without this
+// option, *V should be an object with exactly one key as the root element, except that "root" is used in
+// XMLConventionParker.
+//
+// OptXMLRoot 用在 ToXML() 中，指定根元素的名称，其内容为整个 *V。如果不指定该选项，*V 应当是一个只有一个 key 的 object, 该 key
+// 即为根元素；但 XMLConventionParker 下默认使用 "root"。
+func OptXMLRoot(name string) Option {
+	return optXMLRoot(name)
+}
+
+type optXMLRoot string
+
+func (o optXMLRoot) mergeTo(opt *Opt) {
+	opt.xmlRoot = string(o)
+}
+
+// ==== csv formats ====
+
+// OptCSVColumns is used in ToCSV() and FromCSV(), specifying columns explicitly. In ToCSV(), only given columns are
+// written in given sequence. In FromCSV(), given columns are used as names of columns instead of the header.
+//
+// OptCSVColumns 用在 ToCSV() 和 FromCSV() 中，显式指定列。在 ToCSV() 中，仅按给定的顺序写入给定的列；在 FromCSV() 中，使用给定的列
+// 作为各列的名称，而不是表头。
+func OptCSVColumns(columns ...string) Option {
+	return optCSVColumns(columns)
+}
+
+type optCSVColumns []string
+
+func (o optCSVColumns) mergeTo(opt *Opt) {
+	opt.csvColumns = append(opt.csvColumns, o...)
+}
+
+// OptCSVDelimiter is used in ToCSV() and FromCSV(), specifying the field delimiter, which is ',' by default. Use
+// OptCSVDelimiter('\t') for TSV.
+//
+// OptCSVDelimiter 用在 ToCSV() 和 FromCSV() 中，指定字段分隔符，默认为 ','。对于 TSV, 请使用 OptCSVDelimiter('\t')。
+func OptCSVDelimiter(r rune) Option {
+	return optCSVDelimiter(r)
+}
+
+type optCSVDelimiter rune
+
+func (o optCSVDelimiter) mergeTo(opt *Opt) {
+	opt.csvDelimiter = rune(o)
+}
+
+// OptCSVNoHeader is used in ToCSV() and FromCSV(), telling that there is no header line. In FromCSV(), columns
+// should be given by OptCSVColumns() in this case.
+//
+// OptCSVNoHeader 用在 ToCSV() 和 FromCSV() 中，表示没有表头行。在这种情况下，FromCSV() 需要通过 OptCSVColumns() 指定列。
+func OptCSVNoHeader() Option {
+	return optCSVNoHeader{}
+}
+
+type optCSVNoHeader struct{}
+
+func (optCSVNoHeader) mergeTo(opt *Opt) {
+	opt.csvNoHeader = true
+}
+
+// OptCSVArrayEncoding is used in ToCSV() and FromCSV(), defining how nested arrays are encoded into cells.
+// CSVArrayJSON is used by default.
+//
+// OptCSVArrayEncoding 用在 ToCSV() 和 FromCSV() 中，定义嵌套的数组如何编码到单元格中。默认使用 CSVArrayJSON。
+func OptCSVArrayEncoding(e CSVArrayEncoding) Option {
+	return optCSVArrayEncoding(e)
+}
+
+type optCSVArrayEncoding CSVArrayEncoding
+
+func (o optCSVArrayEncoding) mergeTo(opt *Opt) {
+	opt.csvArrayEncoding = CSVArrayEncoding(o)
+}
+
+// OptCSVArraySeparator is used in ToCSV() with CSVArrayJoin encoding, specifying separator of array elements, which
+// is ";" by default.
+//
+// OptCSVArraySeparator 用在 ToCSV() 中，配合 CSVArrayJoin 编码方式使用，指定数组成员的分隔符，默认为 ";"。
+func OptCSVArraySeparator(sep string) Option {
+	return optCSVArraySeparator(sep)
+}
+
+type optCSVArraySeparator string
+
+func (o optCSVArraySeparator) mergeTo(opt *Opt) {
+	opt.csvArraySeparator = string(o)
+}
+
+// ==== flatten formats ====
+
+// OptFlattenSeparator is used in Flatten() and Unflatten(), specifying separator between keys, which is "." by
+// default.
+//
+// OptFlattenSeparator 用在 Flatten() 和 Unflatten() 中，指定 key 之间的分隔符，默认为 "."。
+func OptFlattenSeparator(sep string) Option {
+	return optFlattenSeparator(sep)
+}
+
+type optFlattenSeparator string
+
+func (o optFlattenSeparator) mergeTo(opt *Opt) {
+	opt.flattenSeparator = string(o)
+}
+
+// OptFlattenArrayNotation is used in Flatten() and Unflatten(), specifying how array indexes are represented.
+// FlattenArrayBracket is used by default.
+//
+// OptFlattenArrayNotation 用在 Flatten() 和 Unflatten() 中，指定数组下标的表示方式。默认使用 FlattenArrayBracket。
+func OptFlattenArrayNotation(n FlattenArrayNotation) Option {
+	return optFlattenArrayNotation(n)
+}
+
+type optFlattenArrayNotation FlattenArrayNotation
+
+func (o optFlattenArrayNotation) mergeTo(opt *Opt) {
+	opt.flattenArrayNotation = FlattenArrayNotation(o)
+}
+
+// OptFlattenMaxDepth is used in Flatten(), telling that flattening stops at given depth, where values are kept as
+// they are. For example, with depth 2, {"a": {"b": {"c": 1}}} is flattened into {"a.b": {"c": 1}}. Zero or negative
+// depth means no limit.
+//
+// OptFlattenMaxDepth 用在 Flatten() 中，表示展开到指定的深度为止，该深度上的值将保持原样。比如深度为 2 时，{"a": {"b": {"c": 1}}}
+// 展开为 {"a.b": {"c": 1}}。深度为 0 或负数表示不限制。
+func OptFlattenMaxDepth(depth int) Option {
+	return optFlattenMaxDepth(depth)
+}
+
+type optFlattenMaxDepth int
+
+func (o optFlattenMaxDepth) mergeTo(opt *Opt) {
+	opt.flattenMaxDepth = int(o)
+}
+
+// ==== urlValuesNoInference ====
+
+// OptURLValuesInferType is used in FromURLValues(), specifying whether numbers and booleans are inferred from texts
+// of values. If not specified, types are inferred by default.
+//
+// OptURLValuesInferType 用在 FromURLValues() 中，指定是否根据值的文本推断数字和布尔值。如无指定，默认会进行类型推断。
+func OptURLValuesInferType(on bool) Option {
+	return optURLValuesInferType(on)
+}
+
+type optURLValuesInferType bool
+
+func (o optURLValuesInferType) mergeTo(opt *Opt) {
+	opt.urlValuesNoInference = !bool(o)
+}
+
 // ==== MarshalLessFunc ===
 
 // OptKeySequenceWithLessFunc configures MarshalLessFunc field in Opt{}, which defines key sequence when marshaling.
@@ -340,6 +822,26 @@ func (optSetSequence) mergeTo(opt *Opt) {
 	opt.marshalBySetSequence = true
 }
 
+// ==== canonical ====
+
+// OptCanonical tells that the output should follow RFC 8785 JSON Canonicalization Scheme (JCS),
+// which is useful for hashing and signing JSON data. With this option, object keys are sorted by
+// UTF-16 code units, numbers are serialized as ECMAScript does, and strings are minimally escaped.
+// Key sequence, escaping and indent options are ignored, and NaN or +/-Inf always raise an error.
+//
+// OptCanonical 指定按照 RFC 8785 JSON 规范化方案 (JCS) 进行序列化，适用于对 JSON 数据计算摘要或签名的场景。
+// 在此选项下，object 的键按照 UTF-16 码元排序，数字按照 ECMAScript 的规则格式化，字符串仅进行最小化转义。
+// 键顺序、转义以及缩进相关的选项将被忽略，并且遇到 NaN 或 +/-Inf 时总是返回错误。
+func OptCanonical() Option {
+	return optCanonical{}
+}
+
+type optCanonical struct{}
+
+func (optCanonical) mergeTo(opt *Opt) {
+	opt.canonical = true
+}
+
 // ==== FloatNaNConvertToFloat ====
 
 // OptFloatNaNToFloat tells that when marshaling float NaN, replace it as another valid float number.
@@ -0,0 +1,220 @@
+package schema
+
+import (
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+// ApplyDefaults inserts values of "default" keywords into v for missing properties of objects, recursively. Schemas
+// referred by $ref and allOf are followed, while those in anyOf, oneOf and not are ignored since they are ambiguous.
+// Inserted values are copies, appended by Set().At() in sequence of the schema.
+//
+// ApplyDefaults 递归地将 "default" 关键字的值插入到 v 中缺失的 object 属性中。$ref 和 allOf 所引用的 schema 会被遵循，而
+// anyOf、oneOf 和 not 中的 schema 由于存在歧义而被忽略。插入的值均为拷贝，按照 schema 中的顺序通过 Set().At() 添加。
+func ApplyDefaults(v *jsonvalue.V, s *Schema) {
+	if v == nil || s == nil {
+		return
+	}
+	applyDefaults(v, s.root.effective())
+}
+
+// Coerce converts values in v into types declared in the schema, recursively. Supported conversions are: strings
+// of JSON number literals like "42" into numbers, exactly "true" and "false" into booleans, numbers and booleans
+// into strings, and a single non-null value into a one-element array. Values already matching declared types, or could not be converted, are left unchanged.
+// Object properties are replaced by Set().At() with their original sequence kept. Since the root value itself could
+// not be replaced in place, the coerced root is returned, which is v itself unless the root is converted.
+//
+// Coerce 递归地将 v 中的值转换为 schema 中声明的类型。支持的转换有：将 "42" 这样的 JSON 数字字面量字符串转为数字，将
+// "true" 和 "false" 这两个字符串转为布尔值，将数字和布尔值转为字符串，以及将单个非 null 值转为只有一个元素的数组。已经符合声明类型或者无法转换的值保持不变。object 的属性
+// 通过 Set().At() 替换，并且保持其原有顺序。由于根值本身无法原地替换，因此本函数返回转换后的根值，除非根值本身被转换，否则即为 v 本身。
+func Coerce(v *jsonvalue.V, s *Schema) *jsonvalue.V {
+	if v == nil || s == nil {
+		return v
+	}
+	return coerce(v, s.root.effective())
+}
+
+// effective returns the node with all nodes referred by $ref and allOf, in which keywords take effect together.
+func (n *node) effective() []*node {
+	var res []*node
+	visited := map[*node]bool{}
+
+	var collect func(n *node)
+	collect = func(n *node) {
+		if n == nil || visited[n] {
+			return
+		}
+		visited[n] = true
+		res = append(res, n)
+		collect(n.refNode)
+		for _, sub := range n.allOf {
+			collect(sub)
+		}
+	}
+
+	collect(n)
+	return res
+}
+
+// propertyNodes returns effective nodes of a property.
+func propertyNodes(nodes []*node, k string) []*node {
+	var res []*node
+	for _, n := range nodes {
+		matched := false
+		if sub, exist := n.properties[k]; exist {
+			matched = true
+			res = append(res, sub.effective()...)
+		}
+		for _, p := range n.patternProperties {
+			if p.regexp.MatchString(k) {
+				matched = true
+				res = append(res, p.node.effective()...)
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			res = append(res, n.additionalProperties.effective()...)
+		}
+	}
+	return res
+}
+
+// itemNodes returns effective nodes of an array item.
+func itemNodes(nodes []*node, i int) []*node {
+	var res []*node
+	for _, n := range nodes {
+		if i < len(n.prefixItems) {
+			res = append(res, n.prefixItems[i].effective()...)
+		} else if n.items != nil {
+			res = append(res, n.items.effective()...)
+		}
+	}
+	return res
+}
+
+func applyDefaults(v *jsonvalue.V, nodes []*node) {
+	switch v.ValueType() {
+	case jsonvalue.Object:
+		for _, n := range nodes {
+			for _, k := range n.propertyKeys {
+				def := n.properties[k].def
+				if def == nil {
+					continue
+				}
+				if _, err := v.Get(k); err == nil {
+					continue
+				}
+				v.Set(jsonvalue.MustUnmarshal(def.MustMarshal())).At(k)
+			}
+		}
+		v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+			applyDefaults(child, propertyNodes(nodes, k))
+			return true
+		})
+
+	case jsonvalue.Array:
+		v.RangeArray(func(i int, child *jsonvalue.V) bool {
+			applyDefaults(child, itemNodes(nodes, i))
+			return true
+		})
+	}
+}
+
+func coerce(v *jsonvalue.V, nodes []*node) *jsonvalue.V {
+	if types := declaredTypes(nodes); len(types) > 0 && !matchesAnyType(v, types) {
+		for _, t := range types {
+			if res := convert(v, t); res != nil {
+				v = res
+				break
+			}
+		}
+	}
+
+	switch v.ValueType() {
+	case jsonvalue.Object:
+		coerceObject(v, nodes)
+	case jsonvalue.Array:
+		for i, child := range v.ForRangeArr() {
+			if res := coerce(child, itemNodes(nodes, i)); res != child {
+				v.Set(res).At(i)
+			}
+		}
+	}
+	return v
+}
+
+func coerceObject(v *jsonvalue.V, nodes []*node) {
+	type kv struct {
+		k string
+		v *jsonvalue.V
+	}
+	var children []kv
+	replaced := false
+
+	v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+		res := coerce(child, propertyNodes(nodes, k))
+		replaced = replaced || res != child
+		children = append(children, kv{k, res})
+		return true
+	})
+
+	// Set() moves a key to the end, thus all properties are set again to keep the sequence
+	if replaced {
+		for _, c := range children {
+			v.Set(c.v).At(c.k)
+		}
+	}
+}
+
+// declaredTypes returns the first "type" keyword among effective nodes.
+func declaredTypes(nodes []*node) []string {
+	for _, n := range nodes {
+		if len(n.types) > 0 {
+			return n.types
+		}
+	}
+	return nil
+}
+
+// convert converts v into given type, nil will be returned if not convertible.
+func convert(v *jsonvalue.V, typ string) *jsonvalue.V {
+	switch typ {
+	case "number", "integer":
+		if !v.IsString() || !jsonvalue.IsNumberText(v.String()) {
+			return nil
+		}
+		res, err := jsonvalue.UnmarshalString(v.String())
+		if err != nil || !res.IsNumber() || !matchesAnyType(res, []string{typ}) {
+			return nil
+		}
+		return res
+
+	case "boolean":
+		if !v.IsString() {
+			return nil
+		}
+		switch v.String() {
+		case "true":
+			return jsonvalue.NewBool(true)
+		case "false":
+			return jsonvalue.NewBool(false)
+		default:
+			return nil
+		}
+
+	case "string":
+		if !v.IsNumber() && !v.IsBoolean() {
+			return nil
+		}
+		return jsonvalue.NewString(v.String())
+
+	case "array":
+		if v.IsNull() {
+			return nil
+		}
+		arr := jsonvalue.NewArray()
+		arr.Append(v).InTheEnd()
+		return arr
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,424 @@
+// Package schema validates jsonvalue values against JSON Schema (draft 2020-12) directly, without reparsing.
+// Supported keywords are: type, enum, const, properties, patternProperties, additionalProperties, required,
+// minProperties, maxProperties, prefixItems, items, minItems, maxItems, uniqueItems, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, minLength, maxLength, pattern, allOf, anyOf, oneOf, not, $defs and $ref
+// within the same document. Other keywords are ignored as annotations. Besides validation, ApplyDefaults() and
+// Coerce() fill default values and convert value types by schemas.
+//
+// 本包直接基于 jsonvalue 值进行 JSON Schema (draft 2020-12) 校验，无需重新解析。支持的关键字包括：type、enum、const、
+// properties、patternProperties、additionalProperties、required、minProperties、maxProperties、prefixItems、items、
+// minItems、maxItems、uniqueItems、minimum、maximum、exclusiveMinimum、exclusiveMaximum、minLength、maxLength、pattern、
+// allOf、anyOf、oneOf、not、$defs 以及同一文档内的 $ref。其他关键字视为注解并被忽略。除校验之外，ApplyDefaults() 和 Coerce()
+// 可以根据 schema 填充默认值以及转换值的类型。
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+// Schema is a compiled JSON Schema, which could be used concurrently.
+//
+// Schema 表示一个编译好的 JSON Schema, 可以并发使用。
+type Schema struct {
+	root *node
+}
+
+// node is a compiled schema or sub-schema.
+type node struct {
+	// location of this schema in the document, in JSON Pointer format
+	ptr string
+
+	// boolean schema, nil if the schema is an object
+	boolean *bool
+
+	types    []string
+	enum     []*jsonvalue.V
+	constant *jsonvalue.V
+
+	properties           map[string]*node
+	propertyKeys         []string
+	patternProperties    []patternNode
+	additionalProperties *node
+	required             []string
+	minProperties        int
+	maxProperties        int
+
+	prefixItems []*node
+	items       *node
+	minItems    int
+	maxItems    int
+	uniqueItems bool
+
+	minimum          *jsonvalue.V
+	maximum          *jsonvalue.V
+	exclusiveMinimum *jsonvalue.V
+	exclusiveMaximum *jsonvalue.V
+
+	minLength int
+	maxLength int
+	pattern   *regexp.Regexp
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+
+	ref     string
+	refNode *node
+
+	// value of "default" keyword, used in ApplyDefaults()
+	def *jsonvalue.V
+}
+
+type patternNode struct {
+	key    string
+	regexp *regexp.Regexp
+	node   *node
+}
+
+// compiler holds states in one Compile() call.
+type compiler struct {
+	doc   *jsonvalue.V
+	nodes map[string]*node
+	refs  []*node
+}
+
+// Compile compiles a JSON Schema document. An error will be returned if any supported keyword is invalid, or a
+// $ref could not be resolved.
+//
+// Compile 编译一个 JSON Schema 文档。如果任何支持的关键字不合法，或者 $ref 无法解析，则返回错误。
+func Compile(schema *jsonvalue.V) (*Schema, error) {
+	if schema == nil || (!schema.IsObject() && !schema.IsBoolean()) {
+		return nil, fmt.Errorf("schema: a schema should be an object or a boolean")
+	}
+
+	c := &compiler{
+		doc:   schema,
+		nodes: map[string]*node{},
+	}
+	root, err := c.compile(schema, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// $ref may point to locations which are not compiled yet, thus more refs may be appended in the loop
+	for i := 0; i < len(c.refs); i++ {
+		n := c.refs[i]
+		if n.refNode, err = c.resolve(n.ref, n.ptr); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Schema{root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the schema could not be compiled.
+//
+// MustCompile 与 Compile 类似，但在无法编译时 panic。
+func MustCompile(schema *jsonvalue.V) *Schema {
+	s, err := Compile(schema)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (c *compiler) compile(v *jsonvalue.V, ptr string) (*node, error) {
+	if n, exist := c.nodes[ptr]; exist {
+		return n, nil
+	}
+
+	n := &node{
+		ptr:           ptr,
+		minProperties: -1,
+		maxProperties: -1,
+		minItems:      -1,
+		maxItems:      -1,
+		minLength:     -1,
+		maxLength:     -1,
+	}
+	c.nodes[ptr] = n
+
+	if v.IsBoolean() {
+		b := v.Bool()
+		n.boolean = &b
+		return n, nil
+	}
+	if !v.IsObject() {
+		return nil, compileErrorf(ptr, "a schema should be an object or a boolean")
+	}
+
+	var err error
+	v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+		err = c.compileKeyword(n, k, child, ptr+"/"+escapePointer(k))
+		return err == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (c *compiler) compileKeyword(n *node, keyword string, v *jsonvalue.V, ptr string) (err error) {
+	switch keyword {
+	default:
+		// annotations or unsupported keywords
+		return nil
+
+	case "type":
+		n.types, err = compileTypes(v, ptr)
+
+	case "enum":
+		if !v.IsArray() {
+			return compileErrorf(ptr, "should be an array")
+		}
+		n.enum = v.ForRangeArr()
+
+	case "const":
+		n.constant = v
+
+	case "default":
+		n.def = v
+
+	case "properties":
+		if !v.IsObject() {
+			return compileErrorf(ptr, "should be an object")
+		}
+		n.properties = map[string]*node{}
+		v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+			var sub *node
+			sub, err = c.compile(child, ptr+"/"+escapePointer(k))
+			n.properties[k] = sub
+			n.propertyKeys = append(n.propertyKeys, k)
+			return err == nil
+		})
+
+	case "patternProperties":
+		if !v.IsObject() {
+			return compileErrorf(ptr, "should be an object")
+		}
+		v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+			p := patternNode{key: k}
+			if p.regexp, err = regexp.Compile(k); err != nil {
+				err = compileErrorf(ptr, "invalid pattern %q: %v", k, err)
+				return false
+			}
+			p.node, err = c.compile(child, ptr+"/"+escapePointer(k))
+			n.patternProperties = append(n.patternProperties, p)
+			return err == nil
+		})
+
+	case "additionalProperties":
+		n.additionalProperties, err = c.compile(v, ptr)
+
+	case "required":
+		n.required, err = compileStrings(v, ptr)
+
+	case "minProperties":
+		n.minProperties, err = compileNonNegativeInt(v, ptr)
+
+	case "maxProperties":
+		n.maxProperties, err = compileNonNegativeInt(v, ptr)
+
+	case "prefixItems":
+		n.prefixItems, err = c.compileArray(v, ptr)
+
+	case "items":
+		n.items, err = c.compile(v, ptr)
+
+	case "minItems":
+		n.minItems, err = compileNonNegativeInt(v, ptr)
+
+	case "maxItems":
+		n.maxItems, err = compileNonNegativeInt(v, ptr)
+
+	case "uniqueItems":
+		if !v.IsBoolean() {
+			return compileErrorf(ptr, "should be a boolean")
+		}
+		n.uniqueItems = v.Bool()
+
+	case "minimum":
+		n.minimum, err = compileNumber(v, ptr)
+
+	case "maximum":
+		n.maximum, err = compileNumber(v, ptr)
+
+	case "exclusiveMinimum":
+		n.exclusiveMinimum, err = compileNumber(v, ptr)
+
+	case "exclusiveMaximum":
+		n.exclusiveMaximum, err = compileNumber(v, ptr)
+
+	case "minLength":
+		n.minLength, err = compileNonNegativeInt(v, ptr)
+
+	case "maxLength":
+		n.maxLength, err = compileNonNegativeInt(v, ptr)
+
+	case "pattern":
+		if !v.IsString() {
+			return compileErrorf(ptr, "should be a string")
+		}
+		if n.pattern, err = regexp.Compile(v.String()); err != nil {
+			return compileErrorf(ptr, "invalid pattern: %v", err)
+		}
+
+	case "allOf":
+		n.allOf, err = c.compileArray(v, ptr)
+
+	case "anyOf":
+		n.anyOf, err = c.compileArray(v, ptr)
+
+	case "oneOf":
+		n.oneOf, err = c.compileArray(v, ptr)
+
+	case "not":
+		n.not, err = c.compile(v, ptr)
+
+	case "$defs", "definitions":
+		if !v.IsObject() {
+			return compileErrorf(ptr, "should be an object")
+		}
+		v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+			_, err = c.compile(child, ptr+"/"+escapePointer(k))
+			return err == nil
+		})
+
+	case "$ref":
+		if !v.IsString() {
+			return compileErrorf(ptr, "should be a string")
+		}
+		n.ref = v.String()
+		c.refs = append(c.refs, n)
+	}
+
+	return err
+}
+
+func (c *compiler) compileArray(v *jsonvalue.V, ptr string) ([]*node, error) {
+	if !v.IsArray() || v.Len() == 0 {
+		return nil, compileErrorf(ptr, "should be a non-empty array")
+	}
+	res := make([]*node, 0, v.Len())
+	for i, child := range v.ForRangeArr() {
+		n, err := c.compile(child, ptr+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+// resolve finds the schema referred by $ref. Only JSON Pointers within the same document are supported.
+func (c *compiler) resolve(ref, from string) (*node, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, compileErrorf(from+"/$ref", "unsupported reference %q, only references within the document are supported", ref)
+	}
+	ptr := ref[1:]
+	if ptr != "" && !strings.HasPrefix(ptr, "/") {
+		return nil, compileErrorf(from+"/$ref", "unsupported reference %q, only JSON Pointers are supported", ref)
+	}
+
+	if n, exist := c.nodes[ptr]; exist {
+		return n, nil
+	}
+
+	// walk through the document by tokens
+	v := c.doc
+	if ptr != "" {
+		for _, token := range strings.Split(ptr[1:], "/") {
+			token = unescapePointer(token)
+			var err error
+			if v.IsArray() {
+				i, convErr := strconv.Atoi(token)
+				if convErr != nil {
+					return nil, compileErrorf(from+"/$ref", "unresolvable reference %q", ref)
+				}
+				v, err = v.Get(i)
+			} else {
+				v, err = v.Get(token)
+			}
+			if err != nil {
+				return nil, compileErrorf(from+"/$ref", "unresolvable reference %q", ref)
+			}
+		}
+	}
+	return c.compile(v, ptr)
+}
+
+func compileTypes(v *jsonvalue.V, ptr string) ([]string, error) {
+	var types []string
+	if v.IsString() {
+		types = []string{v.String()}
+	} else if v.IsArray() {
+		var err error
+		if types, err = compileStrings(v, ptr); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, compileErrorf(ptr, "should be a string or an array")
+	}
+
+	for _, t := range types {
+		switch t {
+		default:
+			return nil, compileErrorf(ptr, "unknown type %q", t)
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+			// OK
+		}
+	}
+	return types, nil
+}
+
+func compileStrings(v *jsonvalue.V, ptr string) ([]string, error) {
+	if !v.IsArray() {
+		return nil, compileErrorf(ptr, "should be an array")
+	}
+	res := make([]string, 0, v.Len())
+	for _, child := range v.ForRangeArr() {
+		if !child.IsString() {
+			return nil, compileErrorf(ptr, "should be an array of strings")
+		}
+		res = append(res, child.String())
+	}
+	return res, nil
+}
+
+func compileNonNegativeInt(v *jsonvalue.V, ptr string) (int, error) {
+	if !v.IsNumber() || v.IsNegative() || v.Float64() != float64(v.Int64()) {
+		return -1, compileErrorf(ptr, "should be a non-negative integer")
+	}
+	return v.Int(), nil
+}
+
+func compileNumber(v *jsonvalue.V, ptr string) (*jsonvalue.V, error) {
+	if !v.IsNumber() {
+		return nil, compileErrorf(ptr, "should be a number")
+	}
+	return v, nil
+}
+
+func compileErrorf(ptr, format string, a ...interface{}) error {
+	if ptr == "" {
+		ptr = "(root)"
+	}
+	return fmt.Errorf("schema: invalid schema at %s: %s", ptr, fmt.Sprintf(format, a...))
+}
+
+// escapePointer escapes a key into JSON Pointer token, as RFC 6901 describes.
+func escapePointer(s string) string {
+	s = strings.Replace(s, "~", "~0", -1)
+	return strings.Replace(s, "/", "~1", -1)
+}
+
+func unescapePointer(s string) string {
+	s = strings.Replace(s, "~1", "/", -1)
+	return strings.Replace(s, "~0", "~", -1)
+}
@@ -0,0 +1,352 @@
+package schema
+
+import (
+	"testing"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	cv = convey.Convey
+	so = convey.So
+
+	eq = convey.ShouldEqual
+
+	isNil     = convey.ShouldBeNil
+	isErr     = convey.ShouldBeError
+	isTrue    = convey.ShouldBeTrue
+	isFalse   = convey.ShouldBeFalse
+	hasSubStr = convey.ShouldContainSubstring
+)
+
+func TestSchema(t *testing.T) {
+	cv("test generic keywords", t, func() { testGenericKeywords(t) })
+	cv("test number and string keywords", t, func() { testNumberAndString(t) })
+	cv("test object keywords", t, func() { testObjectKeywords(t) })
+	cv("test array keywords", t, func() { testArrayKeywords(t) })
+	cv("test combinations", t, func() { testCombinations(t) })
+	cv("test $ref", t, func() { testRef(t) })
+	cv("test compile errors", t, func() { testCompileErrors(t) })
+	cv("test ApplyDefaults()", t, func() { testApplyDefaults(t) })
+	cv("test Coerce()", t, func() { testCoerce(t) })
+}
+
+func compile(s string) *Schema {
+	return MustCompile(jsonvalue.MustUnmarshalString(s))
+}
+
+func validate(s *Schema, instance string) []ValidationError {
+	return s.Validate(jsonvalue.MustUnmarshalString(instance))
+}
+
+func testGenericKeywords(t *testing.T) {
+	s := compile(`{"type":"integer"}`)
+	so(validate(s, `1`), isNil)
+	so(validate(s, `1.0`), isNil)
+	errs := validate(s, `1.5`)
+	so(len(errs), eq, 1)
+	so(errs[0].Error(), eq, "(root): expected integer, got number (/type)")
+
+	s = compile(`{"type":["string","null"]}`)
+	so(s.IsValid(jsonvalue.NewNull()), isTrue)
+	so(s.IsValid(jsonvalue.NewString("")), isTrue)
+	so(s.IsValid(jsonvalue.NewBool(true)), isFalse)
+
+	s = compile(`{"enum":["a",1,null]}`)
+	so(validate(s, `"a"`), isNil)
+	so(validate(s, `1.0`), isNil)
+	so(validate(s, `null`), isNil)
+	so(validate(s, `"b"`)[0].KeywordPath, eq, "/enum")
+
+	s = compile(`{"const":{"a":[1]}}`)
+	so(validate(s, `{"a":[1]}`), isNil)
+	so(validate(s, `{"a":[2]}`)[0].Message, eq, `expected {"a":[1]}, got {"a":[2]}`)
+
+	so(compile(`true`).IsValid(jsonvalue.NewNull()), isTrue)
+	so(compile(`false`).IsValid(jsonvalue.NewNull()), isFalse)
+	so(compile(`{}`).IsValid(jsonvalue.NewObject()), isTrue)
+
+	so(len(s.Validate(nil)), eq, 1)
+	so(len(s.Validate(&jsonvalue.V{})), eq, 1)
+}
+
+func testNumberAndString(t *testing.T) {
+	s := compile(`{"minimum":1,"maximum":10,"exclusiveMinimum":0,"exclusiveMaximum":10}`)
+	so(validate(s, `1`), isNil)
+	so(validate(s, `9.99`), isNil)
+	so(validate(s, `"not a number"`), isNil)
+	errs := validate(s, `10`)
+	so(len(errs), eq, 1)
+	so(errs[0].KeywordPath, eq, "/exclusiveMaximum")
+	errs = validate(s, `0`)
+	so(len(errs), eq, 2)
+	so(errs[0].KeywordPath, eq, "/minimum")
+	so(errs[1].KeywordPath, eq, "/exclusiveMinimum")
+
+	// large numbers are compared without precision lost
+	s = compile(`{"maximum":12345678901234567890}`)
+	so(validate(s, `12345678901234567890`), isNil)
+	so(len(validate(s, `12345678901234567891`)), eq, 1)
+
+	s = compile(`{"minLength":2,"maxLength":3,"pattern":"^[a-z]+"}`)
+	so(len(validate(s, `"中文"`)), eq, 1)
+	so(validate(compile(`{"maxLength":2}`), `"中文"`), isNil)
+	so(validate(s, `"ab"`), isNil)
+	so(validate(s, `"a"`)[0].KeywordPath, eq, "/minLength")
+	so(validate(s, `"abcd"`)[0].KeywordPath, eq, "/maxLength")
+	so(validate(s, `"AB"`)[0].KeywordPath, eq, "/pattern")
+}
+
+func testObjectKeywords(t *testing.T) {
+	s := compile(`{
+		"type": "object",
+		"required": ["id", "items"],
+		"properties": {
+			"id": {"type": "integer"},
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {"price": {"type": "number"}}
+				}
+			},
+			"a/b": {"type": "string"}
+		},
+		"patternProperties": {"^x-": {"type": "string"}},
+		"additionalProperties": false,
+		"minProperties": 1,
+		"maxProperties": 4
+	}`)
+
+	so(validate(s, `{"id":1,"items":[],"a/b":"","x-trace":"t"}`), isNil)
+
+	errs := validate(s, `{"id":1,"items":[{"price":1},{"price":2},{"price":3},{"price":"4"}]}`)
+	so(len(errs), eq, 1)
+	so(errs[0].KeyPath.DotString(), eq, "items[3].price")
+	so(errs[0].KeywordPath, eq, "/properties/items/items/properties/price/type")
+	so(errs[0].Error(), eq, "items[3].price: expected number, got string (/properties/items/items/properties/price/type)")
+
+	errs = validate(s, `{"items":[],"a/b":1,"x-a":1,"other":true}`)
+	so(len(errs), eq, 4)
+	so(errs[0].Message, eq, `missing required property "id"`)
+	so(errs[1].KeywordPath, eq, "/properties/a~1b/type")
+	so(errs[2].KeywordPath, eq, "/patternProperties/^x-/type")
+	so(errs[3].KeyPath.DotString(), eq, "other")
+	so(errs[3].KeywordPath, eq, "/additionalProperties")
+
+	errs = validate(s, `{}`)
+	so(errs[len(errs)-1].KeywordPath, eq, "/required")
+	so(validate(s, `{}`)[0].KeywordPath, eq, "/minProperties")
+
+	s = compile(`{"additionalProperties":{"type":"integer"}}`)
+	so(validate(s, `{"a":1}`), isNil)
+	so(validate(s, `{"a":"1"}`)[0].KeyPath.DotString(), eq, "a")
+}
+
+func testArrayKeywords(t *testing.T) {
+	s := compile(`{"prefixItems":[{"type":"string"}],"items":{"type":"integer"},"minItems":1,"maxItems":3,` +
+		`"uniqueItems":true}`)
+	so(validate(s, `["a",1,2]`), isNil)
+
+	errs := validate(s, `[1,"a"]`)
+	so(len(errs), eq, 2)
+	so(errs[0].KeyPath.DotString(), eq, "[0]")
+	so(errs[0].KeywordPath, eq, "/prefixItems/0/type")
+	so(errs[1].KeyPath.DotString(), eq, "[1]")
+	so(errs[1].KeywordPath, eq, "/items/type")
+
+	so(validate(s, `[]`)[0].KeywordPath, eq, "/minItems")
+	so(validate(s, `["a",1,2,3]`)[0].KeywordPath, eq, "/maxItems")
+	so(validate(s, `["a",1,1.0]`)[0].KeywordPath, eq, "/uniqueItems")
+}
+
+func testCombinations(t *testing.T) {
+	s := compile(`{"allOf":[{"type":"number"},{"minimum":0}]}`)
+	so(validate(s, `1`), isNil)
+	errs := validate(s, `-1`)
+	so(len(errs), eq, 1)
+	so(errs[0].KeywordPath, eq, "/allOf/1/minimum")
+
+	s = compile(`{"anyOf":[{"type":"string"},{"type":"integer"}]}`)
+	so(validate(s, `1`), isNil)
+	so(validate(s, `"1"`), isNil)
+	so(validate(s, `true`)[0].KeywordPath, eq, "/anyOf")
+
+	s = compile(`{"oneOf":[{"type":"number"},{"type":"integer"}]}`)
+	so(validate(s, `1.5`), isNil)
+	errs = validate(s, `1`)
+	so(errs[0].KeywordPath, eq, "/oneOf")
+	so(errs[0].Message, hasSubStr, "matches 2")
+
+	s = compile(`{"not":{"type":"null"}}`)
+	so(validate(s, `1`), isNil)
+	so(validate(s, `null`)[0].KeywordPath, eq, "/not")
+}
+
+func testRef(t *testing.T) {
+	s := compile(`{
+		"$defs": {
+			"price": {"type": "number", "minimum": 0},
+			"node": {
+				"type": "object",
+				"properties": {"children": {"type": "array", "items": {"$ref": "#/$defs/node"}}},
+				"required": ["name"]
+			}
+		},
+		"properties": {
+			"price": {"$ref": "#/$defs/price"},
+			"tree": {"$ref": "#/$defs/node"},
+			"same": {"$ref": "#/properties/price"},
+			"self": {"$ref": "#"}
+		}
+	}`)
+
+	so(validate(s, `{"price":1,"tree":{"name":"a","children":[{"name":"b"}]}}`), isNil)
+
+	errs := validate(s, `{"price":-1}`)
+	so(len(errs), eq, 1)
+	so(errs[0].KeywordPath, eq, "/properties/price/$ref/minimum")
+
+	errs = validate(s, `{"tree":{"name":"a","children":[{"children":[]}]}}`)
+	so(len(errs), eq, 1)
+	so(errs[0].KeyPath.DotString(), eq, "tree.children[0]")
+	so(errs[0].KeywordPath, eq, "/properties/tree/$ref/properties/children/items/$ref/required")
+
+	so(validate(s, `{"same":"x"}`)[0].KeywordPath, eq, "/properties/same/$ref/$ref/type")
+	so(validate(s, `{"self":{"price":"x"}}`)[0].KeyPath.DotString(), eq, "self.price")
+
+	// $ref cycles which never descend into the value fail instead of overflowing the stack
+	s = compile(`{
+		"$defs": {
+			"a": {"$ref": "#/$defs/b"},
+			"b": {"$ref": "#/$defs/a"}
+		},
+		"properties": {
+			"loop": {"$ref": "#/$defs/a"},
+			"any": {"anyOf": [{"$ref": "#/properties/any"}, {"type": "string"}]}
+		}
+	}`)
+	so(validate(s, `{}`), isNil)
+
+	errs = validate(s, `{"loop":1}`)
+	so(len(errs), eq, 1)
+	so(errs[0].KeyPath.DotString(), eq, "loop")
+	so(errs[0].KeywordPath, eq, "/properties/loop/$ref/$ref/$ref")
+	so(errs[0].Message, hasSubStr, "cycle")
+	so(len(validate(s, `{"any":1}`)), eq, 1)
+	so(len(validate(s, `{"any":"x"}`)), eq, 0)
+}
+
+func testCompileErrors(t *testing.T) {
+	cases := []string{
+		`1`,
+		`{"type":"int"}`,
+		`{"type":1}`,
+		`{"required":"a"}`,
+		`{"minLength":-1}`,
+		`{"minLength":1.5}`,
+		`{"maximum":"1"}`,
+		`{"pattern":"("}`,
+		`{"allOf":[]}`,
+		`{"properties":{"a":1}}`,
+		`{"$ref":"http://example.com/schema"}`,
+		`{"$ref":"#/$defs/none"}`,
+		`{"$ref":"#anchor"}`,
+	}
+	for _, c := range cases {
+		_, err := Compile(jsonvalue.MustUnmarshalString(c))
+		so(err, isErr)
+	}
+
+	_, err := Compile(nil)
+	so(err, isErr)
+
+	_, err = Compile(jsonvalue.MustUnmarshalString(`{"properties":{"a":{"type":"x"}}}`))
+	so(err.Error(), hasSubStr, "/properties/a/type")
+
+	so(func() { MustCompile(jsonvalue.NewInt(1)) }, convey.ShouldPanic)
+}
+
+func testApplyDefaults(t *testing.T) {
+	s := compile(`{
+		"$defs": {"page": {"properties": {"size": {"default": 20}}}},
+		"properties": {
+			"keyword": {"type": "string"},
+			"page": {"$ref": "#/$defs/page", "default": {}},
+			"tags": {"default": ["a"]},
+			"sort": {"default": "asc"},
+			"filters": {"items": {"properties": {"op": {"default": "eq"}}}}
+		},
+		"allOf": [{"properties": {"debug": {"default": false}}}]
+	}`)
+
+	v := jsonvalue.MustUnmarshalString(`{"keyword":"go","sort":"desc","filters":[{"field":"a"},{"op":"gt"}]}`)
+	ApplyDefaults(v, s)
+	so(v.MustMarshalString(jsonvalue.OptSetSequence()), eq,
+		`{"keyword":"go","sort":"desc","filters":[{"field":"a","op":"eq"},{"op":"gt"}],`+
+			`"page":{"size":20},"tags":["a"],"debug":false}`)
+
+	// defaults are copied
+	v.MustGet("tags").AppendString("b").InTheEnd()
+	v2 := jsonvalue.NewObject()
+	ApplyDefaults(v2, s)
+	so(v2.MustGet("tags").MustMarshalString(), eq, `["a"]`)
+
+	// nothing happens on non-objects
+	v = jsonvalue.NewString("s")
+	ApplyDefaults(v, s)
+	so(v.String(), eq, "s")
+	ApplyDefaults(nil, s)
+}
+
+func testCoerce(t *testing.T) {
+	s := compile(`{
+		"properties": {
+			"id": {"type": "integer"},
+			"price": {"type": "number"},
+			"ok": {"type": "boolean"},
+			"name": {"type": "string"},
+			"tags": {"type": "array", "items": {"type": "integer"}},
+			"ref": {"$ref": "#/$defs/num"},
+			"any": {"type": ["integer", "string"]},
+			"bad": {"type": "integer"}
+		},
+		"$defs": {"num": {"type": "number"}}
+	}`)
+
+	v := jsonvalue.MustUnmarshalString(`{"id":"42","price":"9.99","ok":"true","name":123,"tags":"7","ref":"1e3",` +
+		`"any":"x","bad":"1.5","extra":"1"}`)
+	res := Coerce(v, s)
+	so(res == v, isTrue)
+	so(v.MustMarshalString(jsonvalue.OptSetSequence()), eq,
+		`{"id":42,"price":9.99,"ok":true,"name":"123","tags":[7],"ref":1e3,"any":"x","bad":"1.5","extra":"1"}`)
+
+	errs := s.Validate(v)
+	so(len(errs), eq, 1)
+	so(errs[0].KeyPath.DotString(), eq, "bad")
+
+	// root value
+	res = Coerce(jsonvalue.NewString("12345678901234567890"), compile(`{"type":"integer"}`))
+	so(res.String(), eq, "12345678901234567890")
+	so(res.IsNumber(), isTrue)
+
+	res = Coerce(jsonvalue.NewString("1"), compile(`{"type":"array","items":{"type":"number"}}`))
+	so(res.MustMarshalString(), eq, `[1]`)
+
+	res = Coerce(jsonvalue.NewString("abc"), compile(`{"type":"number"}`))
+	so(res.String(), eq, "abc")
+
+	// only strict literals are converted, and null is not wrapped
+	s = compile(`{"properties": {"n": {"type": "number"}, "b": {"type": "boolean"}, "a": {"type": "array"}}}`)
+	for _, raw := range []string{`" 42 "`, `"01"`, `"+1"`, `"0x10"`, `"NaN"`} {
+		res = Coerce(jsonvalue.MustUnmarshalString(`{"n":`+raw+`}`), s)
+		so(res.MustGet("n").IsString(), isTrue)
+	}
+	for _, raw := range []string{`"1"`, `"0"`, `"t"`, `"F"`, `"TRUE"`, `"False"`} {
+		res = Coerce(jsonvalue.MustUnmarshalString(`{"b":`+raw+`}`), s)
+		so(res.MustGet("b").IsString(), isTrue)
+	}
+	res = Coerce(jsonvalue.MustUnmarshalString(`{"b":"false","a":null}`), s)
+	so(res.MustMarshalString(jsonvalue.OptSetSequence()), eq, `{"b":false,"a":null}`)
+}
@@ -0,0 +1,347 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	jsonvalue "github.com/Andrew-M-C/go.jsonvalue"
+)
+
+// ValidationError describes one failure in validation.
+//
+// ValidationError 描述校验中的一个失败项。
+type ValidationError struct {
+	// KeyPath is the location of the failed value in the instance.
+	//
+	// KeyPath 是校验失败的值在实例中的位置。
+	KeyPath jsonvalue.KeyPath
+
+	// KeywordPath is the evaluation path of the failed keyword in JSON Pointer format, with $ref included.
+	// E.g. "/properties/price/type".
+	//
+	// KeywordPath 是校验失败的关键字的求值路径，格式为 JSON Pointer, 包含 $ref。如 "/properties/price/type"。
+	KeywordPath string
+
+	// Message describes why the validation fails.
+	//
+	// Message 描述校验失败的原因。
+	Message string
+}
+
+// Error implements error interface, in format like "items[3].price: expected number, got string (/properties/...)".
+//
+// Error 实现 error 接口，格式如 "items[3].price: expected number, got string (/properties/...)"。
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", e.KeyPath.DotString(), e.Message, e.KeywordPath)
+}
+
+// Validate validates v against the schema, returns all failures found. Nil will be returned if v is valid. A $ref
+// cycle which never descends into v, such as "a" refers to "b" and "b" refers to "a", is reported as a failure.
+//
+// Validate 使用 schema 校验 v, 返回所有发现的失败项。如果 v 合法，则返回 nil。不深入 v 内部的 $ref 循环引用 (如 "a" 引用 "b",
+// 而 "b" 又引用 "a") 会被视为一个失败项。
+func (s *Schema) Validate(v *jsonvalue.V) []ValidationError {
+	if v == nil || v.ValueType() == jsonvalue.NotExist {
+		return []ValidationError{{
+			KeywordPath: "",
+			Message:     "value does not exist",
+		}}
+	}
+	va := &validator{refs: map[refVisit]struct{}{}}
+	va.validate(s.root, v, nil, "")
+	return va.errs
+}
+
+// IsValid tells whether v is valid against the schema.
+//
+// IsValid 判断 v 是否符合 schema。
+func (s *Schema) IsValid(v *jsonvalue.V) bool {
+	return len(s.Validate(v)) == 0
+}
+
+type validator struct {
+	errs []ValidationError
+
+	// refs holds schemas referred by $ref on the stack with the values validated against them, which is shared with
+	// sub validators. A repeated pair means a $ref cycle which never descends into the value.
+	refs map[refVisit]struct{}
+}
+
+type refVisit struct {
+	n *node
+	v *jsonvalue.V
+}
+
+func (va *validator) errorf(path jsonvalue.KeyPath, kwPath, format string, a ...interface{}) {
+	va.errs = append(va.errs, ValidationError{
+		KeyPath:     path,
+		KeywordPath: kwPath,
+		Message:     fmt.Sprintf(format, a...),
+	})
+}
+
+// isValid validates v in a sub validator, which is used in anyOf, oneOf and not.
+func (va *validator) isValid(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) bool {
+	sub := &validator{refs: va.refs}
+	sub.validate(n, v, path, kwPath)
+	return len(sub.errs) == 0
+}
+
+func (va *validator) validate(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	if n.boolean != nil {
+		if !*n.boolean {
+			va.errorf(path, kwPath, "false schema allows no value")
+		}
+		return
+	}
+
+	if n.refNode != nil {
+		va.validateRef(n.refNode, v, path, kwPath+"/$ref")
+	}
+
+	va.validateGeneric(n, v, path, kwPath)
+	va.validateCombinations(n, v, path, kwPath)
+
+	switch v.ValueType() {
+	case jsonvalue.Number:
+		va.validateNumber(n, v, path, kwPath)
+	case jsonvalue.String:
+		va.validateString(n, v, path, kwPath)
+	case jsonvalue.Object:
+		va.validateObject(n, v, path, kwPath)
+	case jsonvalue.Array:
+		va.validateArray(n, v, path, kwPath)
+	}
+}
+
+func (va *validator) validateRef(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	key := refVisit{n: n, v: v}
+	if _, exist := va.refs[key]; exist {
+		va.errorf(path, kwPath, "$ref cycle detected without descending into the value")
+		return
+	}
+	va.refs[key] = struct{}{}
+	va.validate(n, v, path, kwPath)
+	delete(va.refs, key)
+}
+
+func (va *validator) validateGeneric(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	if len(n.types) > 0 && !matchesAnyType(v, n.types) {
+		va.errorf(path, kwPath+"/type", "expected %s, got %s", strings.Join(n.types, " or "), typeOf(v))
+	}
+
+	if n.enum != nil {
+		matched := false
+		for _, e := range n.enum {
+			if v.Equal(e) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			va.errorf(path, kwPath+"/enum", "value %s is not one of the enum values", v.MustMarshalString())
+		}
+	}
+
+	if n.constant != nil && !v.Equal(n.constant) {
+		va.errorf(path, kwPath+"/const", "expected %s, got %s", n.constant.MustMarshalString(), v.MustMarshalString())
+	}
+}
+
+func (va *validator) validateCombinations(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	for i, sub := range n.allOf {
+		va.validate(sub, v, path, kwPath+"/allOf/"+strconv.Itoa(i))
+	}
+
+	if len(n.anyOf) > 0 {
+		matched := false
+		for i, sub := range n.anyOf {
+			if va.isValid(sub, v, path, kwPath+"/anyOf/"+strconv.Itoa(i)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			va.errorf(path, kwPath+"/anyOf", "value does not match any schema in anyOf")
+		}
+	}
+
+	if len(n.oneOf) > 0 {
+		matched := 0
+		for i, sub := range n.oneOf {
+			if va.isValid(sub, v, path, kwPath+"/oneOf/"+strconv.Itoa(i)) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			va.errorf(path, kwPath+"/oneOf", "value should match exactly one schema in oneOf, but matches %d", matched)
+		}
+	}
+
+	if n.not != nil && va.isValid(n.not, v, path, kwPath+"/not") {
+		va.errorf(path, kwPath+"/not", "value should not match the schema in not")
+	}
+}
+
+func (va *validator) validateNumber(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	if n.minimum != nil && jsonvalue.Compare(v, n.minimum) < 0 {
+		va.errorf(path, kwPath+"/minimum", "%v is less than minimum %v", v, n.minimum)
+	}
+	if n.maximum != nil && jsonvalue.Compare(v, n.maximum) > 0 {
+		va.errorf(path, kwPath+"/maximum", "%v is greater than maximum %v", v, n.maximum)
+	}
+	if n.exclusiveMinimum != nil && jsonvalue.Compare(v, n.exclusiveMinimum) <= 0 {
+		va.errorf(path, kwPath+"/exclusiveMinimum", "%v should be greater than %v", v, n.exclusiveMinimum)
+	}
+	if n.exclusiveMaximum != nil && jsonvalue.Compare(v, n.exclusiveMaximum) >= 0 {
+		va.errorf(path, kwPath+"/exclusiveMaximum", "%v should be less than %v", v, n.exclusiveMaximum)
+	}
+}
+
+func (va *validator) validateString(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	s := v.String()
+	if n.minLength >= 0 || n.maxLength >= 0 {
+		le := utf8.RuneCountInString(s)
+		if n.minLength >= 0 && le < n.minLength {
+			va.errorf(path, kwPath+"/minLength", "length %d is less than %d", le, n.minLength)
+		}
+		if n.maxLength >= 0 && le > n.maxLength {
+			va.errorf(path, kwPath+"/maxLength", "length %d is greater than %d", le, n.maxLength)
+		}
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		va.errorf(path, kwPath+"/pattern", "%q does not match pattern %q", s, n.pattern.String())
+	}
+}
+
+func (va *validator) validateObject(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	le := v.Len()
+	if n.minProperties >= 0 && le < n.minProperties {
+		va.errorf(path, kwPath+"/minProperties", "%d properties are less than %d", le, n.minProperties)
+	}
+	if n.maxProperties >= 0 && le > n.maxProperties {
+		va.errorf(path, kwPath+"/maxProperties", "%d properties are more than %d", le, n.maxProperties)
+	}
+
+	for _, k := range n.required {
+		if _, err := v.Get(k); err != nil {
+			va.errorf(path, kwPath+"/required", "missing required property %q", k)
+		}
+	}
+
+	for _, k := range n.propertyKeys {
+		child, err := v.Get(k)
+		if err != nil {
+			continue
+		}
+		va.validate(n.properties[k], child, appendKey(path, k), kwPath+"/properties/"+escapePointer(k))
+	}
+
+	if len(n.patternProperties) == 0 && n.additionalProperties == nil {
+		return
+	}
+
+	v.RangeObjectsBySetSequence(func(k string, child *jsonvalue.V) bool {
+		_, evaluated := n.properties[k]
+		for _, p := range n.patternProperties {
+			if p.regexp.MatchString(k) {
+				evaluated = true
+				va.validate(p.node, child, appendKey(path, k), kwPath+"/patternProperties/"+escapePointer(p.key))
+			}
+		}
+		if !evaluated && n.additionalProperties != nil {
+			if b := n.additionalProperties.boolean; b != nil && !*b {
+				va.errorf(appendKey(path, k), kwPath+"/additionalProperties", "additional property %q is not allowed", k)
+			} else {
+				va.validate(n.additionalProperties, child, appendKey(path, k), kwPath+"/additionalProperties")
+			}
+		}
+		return true
+	})
+}
+
+func (va *validator) validateArray(n *node, v *jsonvalue.V, path jsonvalue.KeyPath, kwPath string) {
+	arr := v.ForRangeArr()
+	if n.minItems >= 0 && len(arr) < n.minItems {
+		va.errorf(path, kwPath+"/minItems", "%d items are less than %d", len(arr), n.minItems)
+	}
+	if n.maxItems >= 0 && len(arr) > n.maxItems {
+		va.errorf(path, kwPath+"/maxItems", "%d items are more than %d", len(arr), n.maxItems)
+	}
+
+	if n.uniqueItems {
+	UNIQUE:
+		for i := 1; i < len(arr); i++ {
+			for j := 0; j < i; j++ {
+				if arr[i].Equal(arr[j]) {
+					va.errorf(path, kwPath+"/uniqueItems", "items at %d and %d are equal", j, i)
+					break UNIQUE
+				}
+			}
+		}
+	}
+
+	for i, child := range arr {
+		if i < len(n.prefixItems) {
+			va.validate(n.prefixItems[i], child, appendIndex(path, i), kwPath+"/prefixItems/"+strconv.Itoa(i))
+		} else if n.items != nil {
+			va.validate(n.items, child, appendIndex(path, i), kwPath+"/items")
+		}
+	}
+}
+
+func matchesAnyType(v *jsonvalue.V, types []string) bool {
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if v.IsNumber() && (v.IsInteger() || isIntegralFloat(v.Float64())) {
+				return true
+			}
+		case "number":
+			if v.IsNumber() {
+				return true
+			}
+		default:
+			if typeOf(v) == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isIntegralFloat(f float64) bool {
+	return !math.IsInf(f, 0) && f == math.Trunc(f)
+}
+
+func typeOf(v *jsonvalue.V) string {
+	switch v.ValueType() {
+	default:
+		return "unknown"
+	case jsonvalue.Null:
+		return "null"
+	case jsonvalue.Boolean:
+		return "boolean"
+	case jsonvalue.Number:
+		return "number"
+	case jsonvalue.String:
+		return "string"
+	case jsonvalue.Object:
+		return "object"
+	case jsonvalue.Array:
+		return "array"
+	}
+}
+
+func appendKey(path jsonvalue.KeyPath, k string) jsonvalue.KeyPath {
+	res := make(jsonvalue.KeyPath, 0, len(path)+1)
+	return append(append(res, path...), jsonvalue.NewStringKey(k))
+}
+
+func appendIndex(path jsonvalue.KeyPath, i int) jsonvalue.KeyPath {
+	res := make(jsonvalue.KeyPath, 0, len(path)+1)
+	return append(append(res, path...), jsonvalue.NewIntKey(i))
+}
@@ -80,6 +80,22 @@ func stringKey(s string) Key {
 	return Key{s: s}
 }
 
+// NewIntKey returns a key of array index, which is used to build a KeyPath.
+//
+// NewIntKey 返回一个数组下标的键，用于构建 KeyPath。
+func NewIntKey(i int) *Key {
+	k := intKey(i)
+	return &k
+}
+
+// NewStringKey returns a key of object, which is used to build a KeyPath.
+//
+// NewStringKey 返回一个 object 的键，用于构建 KeyPath。
+func NewStringKey(s string) *Key {
+	k := stringKey(s)
+	return &k
+}
+
 // String returns string value of a key
 //
 // String 返回当前键值对的键的描述
@@ -148,6 +164,37 @@ func (p KeyPath) String() (s string) {
 	return
 }
 
+// DotString returns key path in format like `data.items[3].price`, which is used in error messages. Keys
+// containing dots, brackets or quotes are quoted like `data["a.b"]`, and "(root)" is returned for empty path.
+//
+// DotString 返回形如 `data.items[3].price` 格式的键路径，用于错误信息中。包含点号、方括号或引号的键会以 `data["a.b"]`
+// 的形式加上引号，空路径则返回 "(root)"。
+func (p KeyPath) DotString() string {
+	if len(p) == 0 {
+		return "(root)"
+	}
+
+	buff := bytes.Buffer{}
+	for i, k := range p {
+		if k.IsInt() {
+			buff.WriteRune('[')
+			buff.WriteString(strconv.Itoa(k.Int()))
+			buff.WriteRune(']')
+		} else if s := k.String(); s == "" || strings.ContainsAny(s, ".[]\"") {
+			// keys those could be ambiguous are quoted
+			buff.WriteRune('[')
+			buff.WriteString(strconv.Quote(s))
+			buff.WriteRune(']')
+		} else {
+			if i > 0 {
+				buff.WriteRune('.')
+			}
+			buff.WriteString(s)
+		}
+	}
+	return buff.String()
+}
+
 // ParentInfo show informations of parent of a JSON value.
 //
 // ParentInfo 表示一个 JSON 值的父节点信息。
@@ -322,3 +369,30 @@ func (sssv *sortStringSliceV) Swap(i, j int) {
 	sssv.keys[i], sssv.keys[j] = sssv.keys[j], sssv.keys[i]
 	sssv.values[i], sssv.values[j] = sssv.values[j], sssv.values[i]
 }
+
+// sortedObjectChildren returns keys and values of an object in the sequence defined by marshaling options, just
+// like Marshal() does. It is shared by encoders of formats other than JSON.
+func (v *V) sortedObjectChildren(parentInfo *ParentInfo, opt *Opt) (keys []string, values []*V) {
+	switch {
+	case opt.MarshalLessFunc != nil:
+		sov := v.newSortObjectV(parentInfo, opt)
+		sort.Sort(sov)
+		return sov.keys, sov.values
+	case len(opt.MarshalKeySequence) > 0:
+		sssv := v.newSortStringSliceV(opt)
+		sort.Sort(sssv)
+		return sssv.keys, sssv.values
+	case opt.marshalBySetSequence:
+		sssv := v.newSortStringSliceVBySetSeq(opt)
+		sort.Sort(sssv)
+		return sssv.keys, sssv.values
+	default:
+		keys = make([]string, 0, len(v.children.object))
+		values = make([]*V, 0, len(v.children.object))
+		for k, child := range v.children.object {
+			keys = append(keys, k)
+			values = append(values, child.v)
+		}
+		return keys, values
+	}
+}
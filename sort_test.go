@@ -11,6 +11,12 @@ func testSort(t *testing.T) {
 	cv("sort array errors", func() { testSortArrayError(t) })
 	cv("sort marshal", func() { testSortMarshal(t) })
 	cv("sort by string slice", func() { testSortByStringSlice(t) })
+	cv("key path string", func() { testKeyPathString(t) })
+}
+
+func testKeyPathString(t *testing.T) {
+	p := KeyPath{NewStringKey("data"), NewIntKey(1), NewStringKey(`a"b`)}
+	so(p.String(), eq, `["data" 1 "a\"b"]`)
 }
 
 func testSortArray(t *testing.T) {
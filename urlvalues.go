@@ -0,0 +1,196 @@
+package jsonvalue
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FromURLValues converts url.Values, such as parsed query strings or forms, into an object *V. Keys in bracket
+// notation are converted into nested values, such as "a[b][0]=1" ==> {"a": {"b": [1]}}, where numeric segments are
+// array indexes and empty brackets like "a[]" append elements. Repeated keys are converted into arrays, such as
+// "a=1&a=2" ==> {"a": [1, 2]}. Numbers and booleans are inferred from texts, unless OptURLValuesInferType(false) is
+// given.
+//
+// As url.Values is a map, keys are handled in sorted order, which is also the set sequence of the result. Arrays
+// with sparse indexes, i.e. the max index is not less than twice of the number of elements, are converted into
+// objects instead. Values of keys conflicting with nested keys, such as "a" in "a=1&a[b]=2", are ignored. Keys not
+// in valid bracket notation are kept as they are.
+//
+// FromURLValues 将 url.Values, 比如解析后的 query string 或表单，转换为 object 类型的 *V。方括号表示法的 key 会被转换为嵌套的值，如
+// "a[b][0]=1" ==> {"a": {"b": [1]}}, 其中数字表示数组下标，空方括号如 "a[]" 表示追加数组成员。重复的 key 被转换为数组，如
+// "a=1&a=2" ==> {"a": [1, 2]}。除非指定了 OptURLValuesInferType(false), 否则会根据文本推断数字和布尔值。
+//
+// 由于 url.Values 是一个 map, 各 key 按照排序后的顺序处理，该顺序也是结果的 set 顺序。下标稀疏的数组，即最大下标不小于成员数量两倍的，
+// 会被转换为 object。与嵌套 key 冲突的 key 的值将被忽略，如 "a=1&a[b]=2" 中的 "a"。不符合方括号表示法的 key 则保持原样。
+func FromURLValues(values url.Values, opts ...Option) *V {
+	opt := combineOptions(opts)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	root := &urlNode{}
+	for _, k := range keys {
+		path := parseURLKey(k)
+		for _, s := range values[k] {
+			var v *V
+			if opt.urlValuesNoInference {
+				v = NewString(s)
+			} else {
+				v = inferTextValue(s)
+			}
+			root.add(path, v)
+		}
+	}
+
+	if len(root.keys) == 0 {
+		return newObject()
+	}
+	return root.toObject()
+}
+
+// ToURLValues converts an object *V into url.Values, which is the reverse of FromURLValues(). Nested objects and
+// arrays are represented in bracket notation, such as {"a": {"b": [1]}} ==> "a[b][0]=1". Null values are converted
+// into empty strings, while empty objects and arrays are omitted. An empty url.Values is returned if *V is not an
+// object.
+//
+// ToURLValues 将 object 类型的 *V 转换为 url.Values, 是 FromURLValues() 的逆操作。嵌套的 object 和数组以方括号表示法表示，如
+// {"a": {"b": [1]}} ==> "a[b][0]=1"。null 值转换为空字符串，空 object 和空数组则被忽略。如果 *V 不是 object, 则返回空的
+// url.Values。
+func (v *V) ToURLValues() url.Values {
+	res := url.Values{}
+	if v == nil || v.valueType != Object {
+		return res
+	}
+	for k, child := range v.children.object {
+		addURLValues(res, k, child.v)
+	}
+	return res
+}
+
+func addURLValues(res url.Values, key string, v *V) {
+	switch v.valueType {
+	default:
+		res.Add(key, scalarText(v))
+	case Object:
+		for k, child := range v.children.object {
+			addURLValues(res, key+"["+k+"]", child.v)
+		}
+	case Array:
+		for i, child := range v.children.arr {
+			addURLValues(res, key+"["+strconv.Itoa(i)+"]", child)
+		}
+	}
+}
+
+// urlAppend stands for empty brackets in path of a URL key.
+type urlAppend struct{}
+
+// parseURLKey parses a key like "a[b][0][]" into path ["a", "b", 0, urlAppend{}].
+func parseURLKey(k string) []any {
+	i := strings.IndexByte(k, '[')
+	if i <= 0 {
+		return []any{k}
+	}
+
+	path := []any{k[:i]}
+	for rest := k[i:]; rest != ""; {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return []any{k}
+		}
+		seg := rest[1:end]
+		if seg == "" {
+			path = append(path, urlAppend{})
+		} else if n, ok := flattenIndex(seg); ok {
+			path = append(path, n)
+		} else {
+			path = append(path, seg)
+		}
+		rest = rest[end+1:]
+	}
+	return path
+}
+
+type urlNode struct {
+	values    []*V
+	keys      []any
+	children  map[any]*urlNode
+	nextIndex int
+}
+
+func (n *urlNode) add(path []any, v *V) {
+	for _, k := range path {
+		if _, isAppend := k.(urlAppend); isAppend {
+			k = n.nextIndex
+		}
+
+		c, exist := n.children[k]
+		if !exist {
+			if n.children == nil {
+				n.children = map[any]*urlNode{}
+			}
+			c = &urlNode{}
+			n.children[k] = c
+			n.keys = append(n.keys, k)
+			if i, isIndex := k.(int); isIndex && i >= n.nextIndex {
+				n.nextIndex = i + 1
+			}
+		}
+		n = c
+	}
+	n.values = append(n.values, v)
+}
+
+func (n *urlNode) toV() *V {
+	if len(n.keys) == 0 {
+		if len(n.values) == 1 {
+			return n.values[0]
+		}
+		arr := newArray()
+		for _, v := range n.values {
+			arr.appendToArr(v)
+		}
+		return arr
+	}
+
+	indexes := make([]int, 0, len(n.keys))
+	for _, k := range n.keys {
+		i, isIndex := k.(int)
+		if !isIndex {
+			return n.toObject()
+		}
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	if indexes[len(indexes)-1] >= 2*len(indexes) {
+		return n.toObject()
+	}
+
+	arr := newArray()
+	for _, i := range indexes {
+		for len(arr.children.arr) < i {
+			arr.appendToArr(NewNull())
+		}
+		arr.appendToArr(n.children[i].toV())
+	}
+	return arr
+}
+
+func (n *urlNode) toObject() *V {
+	obj := newObject()
+	for _, k := range n.keys {
+		var s string
+		switch k := k.(type) {
+		case int:
+			s = strconv.Itoa(k)
+		case string:
+			s = k
+		}
+		obj.setToObjectChildren(s, n.children[k].toV())
+	}
+	return obj
+}
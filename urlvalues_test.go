@@ -0,0 +1,70 @@
+package jsonvalue
+
+import (
+	"net/url"
+	"testing"
+)
+
+func testURLValues(t *testing.T) {
+	cv("FromURLValues", func() { testURLValuesFrom(t) })
+	cv("ToURLValues", func() { testURLValuesTo(t) })
+	cv("round trip", func() { testURLValuesRoundTrip(t) })
+}
+
+func queryToJSON(q string, opts ...Option) string {
+	values, err := url.ParseQuery(q)
+	so(err, isNil)
+	return FromURLValues(values, opts...).MustMarshalString(OptSetSequence())
+}
+
+func testURLValuesFrom(t *testing.T) {
+	so(queryToJSON("name=Alice&age=30&vip=true&score=9.50&zip=01234&empty="), eq,
+		`{"age":30,"empty":"","name":"Alice","score":9.50,"vip":true,"zip":"01234"}`)
+	so(queryToJSON("age=30&vip=true", OptURLValuesInferType(false)), eq, `{"age":"30","vip":"true"}`)
+	so(queryToJSON("age=30", OptURLValuesInferType(true)), eq, `{"age":30}`)
+
+	// repeated keys and empty brackets
+	so(queryToJSON("tag=a&tag=b&id[]=1&id[]=2&one[]=x"), eq, `{"id":[1,2],"one":["x"],"tag":["a","b"]}`)
+
+	// bracket notation
+	so(queryToJSON("a[b][0]=1&a[b][1]=2&a[c]=x&user[name]=Bob&user[langs][]=go&user[langs][]=c"), eq,
+		`{"a":{"b":[1,2],"c":"x"},"user":{"langs":["go","c"],"name":"Bob"}}`)
+	so(queryToJSON("list[0][k]=a&list[1][k]=b&list[0][v]=1"), eq, `{"list":[{"k":"a","v":1},{"k":"b"}]}`)
+	so(queryToJSON("a[2]=z&a[10]=x&a[0]=y&a[1]=w&a[3]=0&a[4]=1&a[5]=2&a[6]=3&a[7]=4&a[8]=5&a[9]=6"), eq,
+		`{"a":["y","w","z",0,1,2,3,4,5,6,"x"]}`)
+	so(queryToJSON("a[0]=x&a[]=y"), eq, `{"a":["x","y"]}`)
+
+	// missing and sparse indexes
+	so(queryToJSON("a[1]=x"), eq, `{"a":[null,"x"]}`)
+	so(queryToJSON("a[5]=x&a[0]=y"), eq, `{"a":{"0":"y","5":"x"}}`)
+	so(queryToJSON("a[0]=x&a[k]=y"), eq, `{"a":{"0":"x","k":"y"}}`)
+
+	// conflicts and invalid notations
+	so(queryToJSON("a=1&a[b]=2"), eq, `{"a":{"b":2}}`)
+	so(queryToJSON("a[b=1&[c]=2&d]=3&e[f]g=4&h[01]=5"), eq, `{"[c]":2,"a[b":1,"d]":3,"e[f]g":4,"h":{"01":5}}`)
+
+	so(FromURLValues(nil).MustMarshalString(), eq, `{}`)
+	so(FromURLValues(url.Values{"a": {}}).MustMarshalString(), eq, `{}`)
+}
+
+func testURLValuesTo(t *testing.T) {
+	v := MustUnmarshalString(`{"name":"Alice","age":30,"vip":true,"nil":null,"score":9.50,` +
+		`"a":{"b":[1,{"c":"x"}]},"empty":{},"list":[]}`)
+	so(v.ToURLValues().Encode(), eq,
+		"a%5Bb%5D%5B0%5D=1&a%5Bb%5D%5B1%5D%5Bc%5D=x&age=30&name=Alice&nil=&score=9.50&vip=true")
+
+	so(len(NewArray().ToURLValues()), eq, 0)
+	so(len(NewString("s").ToURLValues()), eq, 0)
+	so(len((&V{}).ToURLValues()), eq, 0)
+	so(len((*V)(nil).ToURLValues()), eq, 0)
+}
+
+func testURLValuesRoundTrip(t *testing.T) {
+	s := `{"a":{"b":[1,{"c":"x"}],"d":true},"id":[3,1,2],"name":"Bob"}`
+	v := MustUnmarshalString(s)
+	so(FromURLValues(v.ToURLValues()).MustMarshalString(OptSetSequence()), eq, s)
+
+	values, err := url.ParseQuery(v.ToURLValues().Encode())
+	so(err, isNil)
+	so(FromURLValues(values).Equal(v), isTrue)
+}
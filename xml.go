@@ -0,0 +1,699 @@
+package jsonvalue
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// XMLConvention defines how XML elements, attributes and texts are mapped to JSON values in FromXML() and ToXML().
+//
+// XMLConvention 定义 FromXML() 和 ToXML() 中 XML 元素、属性和文本与 JSON 值之间的映射规则。
+type XMLConvention uint8
+
+const (
+	// XMLConventionAttrText is the default convention. Attributes are represented by keys like "@name", and texts of
+	// elements with attributes or child elements are represented by the "#text" key. Elements with only text are
+	// represented by strings, while empty elements are represented by null. For example:
+	// <a id="1"><b>x</b><b>y</b></a> <==> {"a": {"@id": "1", "b": ["x", "y"]}}
+	//
+	// XMLConventionAttrText 为默认规则。属性以 "@name" 形式的 key 表示，带有属性或子元素的元素的文本以 "#text" key 表示。仅包含文本的
+	// 元素表示为字符串，空元素则表示为 null。比如:
+	// <a id="1"><b>x</b><b>y</b></a> <==> {"a": {"@id": "1", "b": ["x", "y"]}}
+	XMLConventionAttrText XMLConvention = 0
+	// XMLConventionBadgerFish follows the BadgerFish convention. Every element is represented by an object, where
+	// texts are represented by the "$" key, and attributes by keys like "@name". Namespace declarations are grouped in
+	// the "@xmlns" object, where the default namespace is keyed by "$". For example:
+	// <a id="1"><b>x</b></a> <==> {"a": {"@id": "1", "b": {"$": "x"}}}
+	//
+	// XMLConventionBadgerFish 遵循 BadgerFish 规则。每一个元素均表示为一个 object, 其中文本以 "$" key 表示，属性以 "@name" 形式的
+	// key 表示。命名空间声明归入 "@xmlns" object 中，默认命名空间的 key 为 "$"。比如:
+	// <a id="1"><b>x</b></a> <==> {"a": {"@id": "1", "b": {"$": "x"}}}
+	XMLConventionBadgerFish XMLConvention = 1
+	// XMLConventionParker follows the Parker convention, which is simple but lossy. The root element is omitted,
+	// attributes are dropped, and texts are converted into numbers and booleans if possible. Elements whose child
+	// elements share the same name are represented by arrays. Texts of elements with child elements are dropped.
+	// For example: <a><b>1</b><b>2</b></a> <==> [1, 2]
+	//
+	// XMLConventionParker 遵循 Parker 规则，该规则简洁但有损。根元素被省略，属性被丢弃，文本则尽可能转换为数字和布尔值。子元素名称全部相同
+	// 的元素表示为数组。带有子元素的元素的文本被丢弃。比如: <a><b>1</b><b>2</b></a> <==> [1, 2]
+	XMLConventionParker XMLConvention = 2
+)
+
+// XMLNamespace defines how namespaces of element and attribute names are handled in FromXML() and ToXML().
+//
+// XMLNamespace 定义 FromXML() 和 ToXML() 中如何处理元素名和属性名的命名空间。
+type XMLNamespace uint8
+
+const (
+	// XMLNamespacePrefix is the default mode. Names are kept as they are written, such as "soap:Envelope", and
+	// namespace declarations are kept as attributes, such as "@xmlns:soap".
+	//
+	// XMLNamespacePrefix 为默认模式。名称按原样保留，如 "soap:Envelope", 命名空间声明作为属性保留，如 "@xmlns:soap"。
+	XMLNamespacePrefix XMLNamespace = 0
+	// XMLNamespaceStrip indicates that only local names are used, and namespace declarations are dropped.
+	//
+	// XMLNamespaceStrip 表示仅使用本地名称，并丢弃命名空间声明。
+	XMLNamespaceStrip XMLNamespace = 1
+	// XMLNamespaceURI indicates that prefixes are replaced by namespace URIs in Clark notation, such as
+	// "{http://www.w3.org/2003/05/soap-envelope}Envelope", and namespace declarations are dropped. In ToXML(),
+	// names in Clark notation are always accepted, and namespace declarations are generated, where default namespaces
+	// are reset by xmlns="" for descendants without namespaces.
+	//
+	// XMLNamespaceURI 表示使用 Clark 表示法将前缀替换为命名空间 URI, 如 "{http://www.w3.org/2003/05/soap-envelope}Envelope",
+	// 并丢弃命名空间声明。在 ToXML() 中，总是接受 Clark 表示法的名称，并自动生成命名空间声明，对于没有命名空间的后代元素，会以
+	// xmlns="" 重置默认命名空间。
+	XMLNamespaceURI XMLNamespace = 2
+)
+
+const xmlNamespaceOfXMLPrefix = "http://www.w3.org/XML/1998/namespace"
+
+// FromXML reads an XML document from r and converts it into *V following the convention specified by
+// OptXMLConvention(), XMLConventionAttrText by default. Element sequence is kept as set sequence, child elements with
+// the same name are merged into an array at the position of the first one, and whitespace around texts is trimmed.
+// Comments, processing instructions and directives are ignored.
+//
+// Use OptXMLForceArray() to specify elements which should always be arrays, and OptXMLNamespace() to specify how
+// namespaces are handled.
+//
+// FromXML 从 r 中读取 XML 文档，并按照 OptXMLConvention() 指定的规则转换为 *V, 默认使用 XMLConventionAttrText。元素顺序被保存为
+// set 顺序，同名的子元素合并为一个数组，位于第一个该名称元素的位置，文本两端的空白字符被去除。注释、处理指令和 DTD 等指令均被忽略。
+//
+// 使用 OptXMLForceArray() 指定总是解析为数组的元素，使用 OptXMLNamespace() 指定如何处理命名空间。
+func FromXML(r io.Reader, opts ...Option) (*V, error) {
+	opt := combineOptions(opts)
+	root, err := parseXMLTree(xml.NewDecoder(r), opt.xmlNamespace)
+	if err != nil {
+		return &V{}, err
+	}
+
+	c := xmlConverter{opt: opt}
+	path := "/" + root.name
+	if opt.xmlConvention == XMLConventionParker {
+		return c.parker(root, path), nil
+	}
+
+	v := newObject()
+	child := c.convert(root, path)
+	if c.forceArray(path) {
+		arr := newArray()
+		arr.appendToArr(child)
+		child = arr
+	}
+	v.setToObjectChildren(root.name, child)
+	return v, nil
+}
+
+// ToXML writes *V into w as an XML document following the convention specified by OptXMLConvention(),
+// XMLConventionAttrText by default. Arrays are written as repeated elements with the same name, while elements of
+// arrays without names, such as the root value or nested arrays, are named "item". Null values are written as
+// empty elements. The root element name is specified by OptXMLRoot(), see it for details.
+//
+// Keys are written in set sequence by default, unless OptKeySequence() or OptKeySequenceWithLessFunc() is given.
+// OptIndent() is also supported.
+//
+// ToXML 将 *V 按照 OptXMLConvention() 指定的规则以 XML 文档的形式写入 w, 默认使用 XMLConventionAttrText。数组被写为同名的重复元素，
+// 而没有名称的数组成员，比如根值或嵌套数组的成员，则命名为 "item"。null 值被写为空元素。根元素名称通过 OptXMLRoot() 指定，详见该函数。
+//
+// 默认情况下，key 按照 set 顺序写入，除非指定了 OptKeySequence() 或 OptKeySequenceWithLessFunc()。同时也支持 OptIndent()。
+func (v *V) ToXML(w io.Writer, opts ...Option) error {
+	if v == nil || v.valueType == NotExist {
+		return ErrValueUninitialized
+	}
+
+	opt := combineOptions(opts)
+	if opt.MarshalLessFunc == nil && len(opt.MarshalKeySequence) == 0 {
+		opt.marshalBySetSequence = true
+	}
+
+	e := xmlEncoder{enc: xml.NewEncoder(w), opt: opt}
+	if opt.indent.enabled {
+		e.enc.Indent(opt.indent.prefix, opt.indent.indent)
+	}
+
+	root := opt.xmlRoot
+	if root == "" && opt.xmlConvention == XMLConventionParker {
+		root = "root"
+	}
+
+	var err error
+	if root != "" {
+		err = e.writeElement(nil, root, v)
+	} else {
+		err = e.writeRoot(v)
+	}
+	if err != nil {
+		return err
+	}
+	return e.enc.Flush()
+}
+
+// ---------------- decoding ----------------
+
+type xmlNode struct {
+	name     string
+	attrs    []xmlAttr
+	children []*xmlNode
+	texts    []string
+	textPos  int // count of child elements before the first text
+}
+
+type xmlAttr struct {
+	name  string
+	value string
+	// nsPrefix is valid only for namespace declarations, where "" stands for the default namespace
+	nsPrefix string
+	isNSDecl bool
+}
+
+func xmlSyntaxError(d *xml.Decoder, format string, a ...any) error {
+	return fmt.Errorf("%w: XML at offset %d: %s", ErrRawBytesUnrecignized, d.InputOffset(), fmt.Sprintf(format, a...))
+}
+
+// parseXMLTree reads the whole document into an element tree. RawToken() is used so that prefixes could be kept,
+// hence matching of start and end elements is checked here.
+func parseXMLTree(d *xml.Decoder, mode XMLNamespace) (*xmlNode, error) {
+	var root *xmlNode
+	var stack []*xmlNode
+	var rawNames []xml.Name
+	var scopes []map[string]string
+	text := strings.Builder{}
+
+	flushText := func() {
+		s := strings.TrimSpace(text.String())
+		text.Reset()
+		if s == "" || len(stack) == 0 {
+			return
+		}
+		n := stack[len(stack)-1]
+		if len(n.texts) == 0 {
+			n.textPos = len(n.children)
+		}
+		n.texts = append(n.texts, s)
+	}
+
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if se, ok := err.(*xml.SyntaxError); ok {
+				return nil, xmlSyntaxError(d, "%s", se.Msg)
+			}
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && root != nil {
+				return nil, xmlSyntaxError(d, "multiple root elements")
+			}
+			flushText()
+
+			var scope map[string]string
+			if mode == XMLNamespaceURI {
+				scope = xmlNamespaceScope(t.Attr)
+			}
+			scopes = append(scopes, scope)
+
+			n := &xmlNode{name: resolveXMLName(t.Name, mode, true, scopes)}
+			for _, a := range t.Attr {
+				attr := xmlAttr{value: a.Value}
+				switch {
+				case a.Name.Space == "xmlns":
+					attr.isNSDecl, attr.nsPrefix = true, a.Name.Local
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					attr.isNSDecl = true
+				}
+				if attr.isNSDecl && mode != XMLNamespacePrefix {
+					continue
+				}
+				attr.name = resolveXMLName(a.Name, mode, false, scopes)
+				n.attrs = append(n.attrs, attr)
+			}
+
+			if len(stack) == 0 {
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			}
+			stack = append(stack, n)
+			rawNames = append(rawNames, t.Name)
+
+		case xml.EndElement:
+			if len(stack) == 0 || rawNames[len(rawNames)-1] != t.Name {
+				return nil, xmlSyntaxError(d, "unexpected end element </%s>", formatXMLPrefixedName(t.Name))
+			}
+			flushText()
+			stack = stack[:len(stack)-1]
+			rawNames = rawNames[:len(rawNames)-1]
+			scopes = scopes[:len(scopes)-1]
+
+		case xml.CharData:
+			if len(stack) == 0 {
+				if strings.TrimSpace(string(t)) != "" {
+					return nil, xmlSyntaxError(d, "text outside the root element")
+				}
+				continue
+			}
+			text.Write(t)
+		}
+	}
+
+	if root == nil {
+		return nil, xmlSyntaxError(d, "no root element")
+	}
+	if len(stack) > 0 {
+		return nil, xmlSyntaxError(d, "unexpected EOF, element <%s> not closed", formatXMLPrefixedName(rawNames[len(rawNames)-1]))
+	}
+	return root, nil
+}
+
+func xmlNamespaceScope(attrs []xml.Attr) map[string]string {
+	var scope map[string]string
+	for _, a := range attrs {
+		prefix := ""
+		switch {
+		case a.Name.Space == "xmlns":
+			prefix = a.Name.Local
+		case a.Name.Space == "" && a.Name.Local == "xmlns":
+			// default namespace
+		default:
+			continue
+		}
+		if scope == nil {
+			scope = map[string]string{}
+		}
+		scope[prefix] = a.Value
+	}
+	return scope
+}
+
+func formatXMLPrefixedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+func resolveXMLName(name xml.Name, mode XMLNamespace, isElement bool, scopes []map[string]string) string {
+	switch mode {
+	default:
+		return formatXMLPrefixedName(name)
+	case XMLNamespaceStrip:
+		return name.Local
+	case XMLNamespaceURI:
+		// unprefixed attributes are in no namespace
+		if name.Space == "" && !isElement {
+			return name.Local
+		}
+		if name.Space == "xml" {
+			return "{" + xmlNamespaceOfXMLPrefix + "}" + name.Local
+		}
+		for i := len(scopes) - 1; i >= 0; i-- {
+			if uri, exist := scopes[i][name.Space]; exist {
+				if uri == "" {
+					return name.Local
+				}
+				return "{" + uri + "}" + name.Local
+			}
+		}
+		// undeclared prefix
+		return formatXMLPrefixedName(name)
+	}
+}
+
+type xmlConverter struct {
+	opt *Opt
+}
+
+func (c *xmlConverter) forceArray(path string) bool {
+	for _, p := range c.opt.xmlForceArray {
+		if strings.HasPrefix(p, "/") {
+			if p == path {
+				return true
+			}
+		} else if strings.HasSuffix(path, "/"+p) {
+			return true
+		}
+	}
+	return false
+}
+
+// convert converts an element in XMLConventionAttrText or XMLConventionBadgerFish convention.
+func (c *xmlConverter) convert(n *xmlNode, path string) *V {
+	badgerFish := c.opt.xmlConvention == XMLConventionBadgerFish
+	if !badgerFish && len(n.attrs) == 0 && len(n.children) == 0 {
+		if len(n.texts) == 0 {
+			return NewNull()
+		}
+		return NewString(strings.Join(n.texts, " "))
+	}
+
+	v := newObject()
+	var xmlns *V
+	for _, a := range n.attrs {
+		if badgerFish && a.isNSDecl {
+			if xmlns == nil {
+				xmlns = newObject()
+				v.setToObjectChildren("@xmlns", xmlns)
+			}
+			k := a.nsPrefix
+			if k == "" {
+				k = "$"
+			}
+			xmlns.setToObjectChildren(k, NewString(a.value))
+			continue
+		}
+		v.setToObjectChildren("@"+a.name, NewString(a.value))
+	}
+
+	textKey := "#text"
+	if badgerFish {
+		textKey = "$"
+	}
+	setText := func() {
+		v.setToObjectChildren(textKey, NewString(strings.Join(n.texts, " ")))
+	}
+
+	counts := xmlCountNames(n.children)
+	for i, child := range n.children {
+		if len(n.texts) > 0 && i == n.textPos {
+			setText()
+		}
+		childPath := path + "/" + child.name
+		c.setChild(v, child.name, c.convert(child, childPath), counts[child.name] > 1 || c.forceArray(childPath))
+	}
+	if len(n.texts) > 0 && n.textPos >= len(n.children) {
+		setText()
+	}
+	return v
+}
+
+// parker converts an element in XMLConventionParker convention.
+func (c *xmlConverter) parker(n *xmlNode, path string) *V {
+	if len(n.children) == 0 {
+		if len(n.texts) == 0 {
+			return NewNull()
+		}
+		return inferTextValue(strings.Join(n.texts, " "))
+	}
+
+	counts := xmlCountNames(n.children)
+	if len(counts) == 1 {
+		childPath := path + "/" + n.children[0].name
+		if len(n.children) > 1 || c.forceArray(childPath) {
+			arr := newArray()
+			for _, child := range n.children {
+				arr.appendToArr(c.parker(child, childPath))
+			}
+			return arr
+		}
+	}
+
+	v := newObject()
+	for _, child := range n.children {
+		childPath := path + "/" + child.name
+		c.setChild(v, child.name, c.parker(child, childPath), counts[child.name] > 1 || c.forceArray(childPath))
+	}
+	return v
+}
+
+func (c *xmlConverter) setChild(v *V, name string, child *V, isArray bool) {
+	if !isArray {
+		v.setToObjectChildren(name, child)
+		return
+	}
+	arr, exist := v.children.object[name]
+	if !exist {
+		arr.v = newArray()
+		v.setToObjectChildren(name, arr.v)
+	}
+	arr.v.appendToArr(child)
+}
+
+func xmlCountNames(nodes []*xmlNode) map[string]int {
+	counts := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		counts[n.name]++
+	}
+	return counts
+}
+
+// ---------------- encoding ----------------
+
+type xmlEncoder struct {
+	enc *xml.Encoder
+	opt *Opt
+
+	// defaultNS is the default namespace declared by names in Clark notation, which should be reset for
+	// descendants without namespaces. nsStack saves defaultNS of ancestors.
+	defaultNS string
+	nsStack   []string
+}
+
+func (e *xmlEncoder) encodeStart(start xml.StartElement) error {
+	e.nsStack = append(e.nsStack, e.defaultNS)
+	explicit := false
+	for _, a := range start.Attr {
+		if a.Name.Space == "" && a.Name.Local == "xmlns" {
+			explicit = true // default namespace managed by the value itself
+		}
+	}
+	switch {
+	case start.Name.Space != "":
+		e.defaultNS = start.Name.Space
+	case explicit:
+		e.defaultNS = ""
+	case e.defaultNS != "":
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}})
+		e.defaultNS = ""
+	}
+	return e.enc.EncodeToken(start)
+}
+
+func (e *xmlEncoder) encodeEnd(end xml.EndElement) error {
+	e.defaultNS = e.nsStack[len(e.nsStack)-1]
+	e.nsStack = e.nsStack[:len(e.nsStack)-1]
+	return e.enc.EncodeToken(end)
+}
+
+func (e *xmlEncoder) writeRoot(v *V) error {
+	if v.valueType != Object {
+		return fmt.Errorf("%w: XML root should be an object with exactly one key, or use OptXMLRoot()", ErrNotObjectValue)
+	}
+	if len(v.children.object) != 1 {
+		return fmt.Errorf("%w: XML document should have exactly one root element, got %d", ErrTypeNotMatch, len(v.children.object))
+	}
+	keys, values := v.sortedObjectChildren(nil, e.opt)
+	if values[0].valueType == Array {
+		return fmt.Errorf("%w: XML document should have exactly one root element, got an array", ErrTypeNotMatch)
+	}
+
+	var par *ParentInfo
+	if e.opt.MarshalLessFunc != nil {
+		par = values[0].newParentInfo(nil, stringKey(keys[0]))
+	}
+	return e.writeElement(par, keys[0], values[0])
+}
+
+// writeMember writes a value keyed by name, where arrays are written as repeated elements.
+func (e *xmlEncoder) writeMember(parentInfo *ParentInfo, name string, v *V) error {
+	if v.valueType != Array {
+		return e.writeElement(parentInfo, name, v)
+	}
+	for i, child := range v.children.arr {
+		var par *ParentInfo
+		if e.opt.MarshalLessFunc != nil {
+			par = v.newParentInfo(parentInfo, intKey(i))
+		}
+		if err := e.writeElement(par, name, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *xmlEncoder) writeElement(parentInfo *ParentInfo, name string, v *V) error {
+	xmlName, err := parseXMLNameForEncoding(name)
+	if err != nil {
+		return err
+	}
+	start := xml.StartElement{Name: xmlName}
+
+	switch v.valueType {
+	default:
+		return fmt.Errorf("%w: %v", ErrTypeNotMatch, v.valueType)
+
+	case Null:
+		return e.writeSimpleElement(start, "")
+
+	case String, Number, Boolean:
+		return e.writeSimpleElement(start, scalarText(v))
+
+	case Array:
+		if err := e.encodeStart(start); err != nil {
+			return err
+		}
+		if err := e.writeMember(parentInfo, "item", v); err != nil {
+			return err
+		}
+		return e.encodeEnd(start.End())
+
+	case Object:
+		return e.writeObjectElement(parentInfo, start, v)
+	}
+}
+
+func (e *xmlEncoder) writeSimpleElement(start xml.StartElement, text string) error {
+	if err := e.encodeStart(start); err != nil {
+		return err
+	}
+	if text != "" {
+		if err := e.enc.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	return e.encodeEnd(start.End())
+}
+
+func (e *xmlEncoder) writeObjectElement(parentInfo *ParentInfo, start xml.StartElement, v *V) error {
+	convention := e.opt.xmlConvention
+	textKey := "#text"
+	if convention == XMLConventionBadgerFish {
+		textKey = "$"
+	}
+	keys, values := v.sortedObjectChildren(parentInfo, e.opt)
+
+	// attributes first
+	if convention != XMLConventionParker {
+		for i, k := range keys {
+			if !strings.HasPrefix(k, "@") {
+				continue
+			}
+			child := values[i]
+			if convention == XMLConventionBadgerFish && k == "@xmlns" && child.valueType == Object {
+				attrs, err := e.badgerFishNamespaces(parentInfo, child)
+				if err != nil {
+					return err
+				}
+				start.Attr = append(start.Attr, attrs...)
+				continue
+			}
+			if child.valueType == Null {
+				continue
+			}
+			if !isXMLScalar(child) {
+				return fmt.Errorf("%w: attribute %s should be a scalar, got %v", ErrTypeNotMatch, k, child.valueType)
+			}
+			attrName, err := parseXMLNameForEncoding(k[1:])
+			if err != nil {
+				return err
+			}
+			start.Attr = append(start.Attr, xml.Attr{Name: attrName, Value: scalarText(child)})
+		}
+	}
+	if err := e.encodeStart(start); err != nil {
+		return err
+	}
+
+	for i, k := range keys {
+		child := values[i]
+		if convention != XMLConventionParker {
+			if strings.HasPrefix(k, "@") {
+				continue
+			}
+			if k == textKey {
+				if child.valueType == Null {
+					continue
+				}
+				if !isXMLScalar(child) {
+					return fmt.Errorf("%w: %s should be a scalar, got %v", ErrTypeNotMatch, k, child.valueType)
+				}
+				if err := e.enc.EncodeToken(xml.CharData(scalarText(child))); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if e.opt.OmitNull && child.valueType == Null {
+			continue
+		}
+		var par *ParentInfo
+		if e.opt.MarshalLessFunc != nil {
+			par = child.newParentInfo(parentInfo, stringKey(k))
+		}
+		if err := e.writeMember(par, k, child); err != nil {
+			return err
+		}
+	}
+
+	return e.encodeEnd(start.End())
+}
+
+func (e *xmlEncoder) badgerFishNamespaces(parentInfo *ParentInfo, xmlns *V) ([]xml.Attr, error) {
+	keys, values := xmlns.sortedObjectChildren(parentInfo, e.opt)
+	attrs := make([]xml.Attr, 0, len(keys))
+	for i, k := range keys {
+		if values[i].valueType != String {
+			return nil, fmt.Errorf("%w: namespace URI in @xmlns should be a string, got %v", ErrTypeNotMatch, values[i].valueType)
+		}
+		name := xml.Name{Local: "xmlns"}
+		if k != "$" {
+			if !isXMLName(k) {
+				return nil, fmt.Errorf("%w: invalid namespace prefix %q", ErrIllegalString, k)
+			}
+			name.Local = "xmlns:" + k
+		}
+		attrs = append(attrs, xml.Attr{Name: name, Value: values[i].valueStr})
+	}
+	return attrs, nil
+}
+
+// parseXMLNameForEncoding parses a name, which may be in Clark notation such as "{uri}local".
+func parseXMLNameForEncoding(s string) (xml.Name, error) {
+	name := xml.Name{Local: s}
+	if strings.HasPrefix(s, "{") {
+		if i := strings.IndexByte(s, '}'); i > 0 {
+			name.Space, name.Local = s[1:i], s[i+1:]
+		}
+	}
+	if !isXMLName(name.Local) {
+		return name, fmt.Errorf("%w: invalid XML name %q", ErrIllegalString, s)
+	}
+	return name, nil
+}
+
+// isXMLName tells whether s is a valid XML name, which may be prefixed.
+func isXMLName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == utf8.RuneError:
+			return false
+		case unicode.IsLetter(r) || r == '_' || r == ':':
+			// OK
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.' || unicode.In(r, unicode.Mn, unicode.Mc)):
+			// OK
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isXMLScalar(v *V) bool {
+	switch v.valueType {
+	case String, Number, Boolean, Null:
+		return true
+	default:
+		return false
+	}
+}
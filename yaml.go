@@ -0,0 +1,1548 @@
+package jsonvalue
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// UnmarshalYAML parses a YAML document into *V. The JSON-compatible subset of YAML 1.2 is supported, including
+// block and flow mappings and sequences, plain, quoted, literal (|) and folded (>) scalars, comments, anchors and
+// aliases, as well as merge keys (<<). Aliases are expanded into copies of anchored values. Plain scalars are
+// resolved by YAML 1.2 core schema, which means that null, true, 123, 0x1F and 1.5e3 are resolved into
+// corresponding JSON types, while tags like !!str and !!int could be used to specify types explicitly. Keys are
+// kept as they are written, and key sequence is kept as set sequence.
+//
+// Only one document is allowed. As JSON does not support infinity and NaN, floats like .inf, -.inf, .nan and
+// those out of range of float64 result in errors. Quote them if they are meant to be strings. Tabs are not allowed
+// in indentation, as YAML specifies. Integers out of range of int64 and uint64 are kept exact as number texts,
+// rather than being rounded into float64.
+//
+// UnmarshalYAML 将 YAML 文档解析为 *V。支持 YAML 1.2 中与 JSON 兼容的子集，包括块 (block) 和流 (flow) 形式的映射和序列，普通、
+// 引号、字面 (|) 和折叠 (>) 标量，注释，锚点 (anchor) 和别名 (alias)，以及合并键 (<<)。别名会被展开为锚点值的副本。普通标量按照
+// YAML 1.2 core schema 解析，即 null, true, 123, 0x1F 和 1.5e3 等会被解析为对应的 JSON 类型，也可以使用 !!str 和 !!int
+// 等标签 (tag) 显式指定类型。键按照原文保留，键的顺序被保存为 set 顺序。
+//
+// 仅允许一个文档。由于 JSON 不支持无穷大和 NaN, .inf, -.inf, .nan 以及超出 float64 范围的浮点数会导致错误，如需作为字符串，请加上
+// 引号。按照 YAML 规范，缩进中不允许使用 tab。超出 int64 和 uint64 范围的整数会以数字文本的形式精确保留，而不会被舍入为 float64。
+func UnmarshalYAML(b []byte) (*V, error) {
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	b = bytes.Replace(b, []byte("\r\n"), []byte("\n"), -1)
+	if !utf8.Valid(b) {
+		return &V{}, fmt.Errorf("%w: YAML document is not valid UTF-8", ErrRawBytesUnrecignized)
+	}
+
+	p := yamlParser{src: b, anchors: map[string]*V{}}
+	v, err := p.parseDocument()
+	if err != nil {
+		return &V{}, err
+	}
+	return v, nil
+}
+
+// MarshalYAML encodes *V into a YAML document in block style, with two spaces as indentation. Strings are
+// quoted when needed, and multi-line strings are written as literal block scalars. Object keys are written in set
+// sequence by default, unless OptKeySequence() or OptKeySequenceWithLessFunc() is given. OptOmitNull() is also
+// supported.
+//
+// MarshalYAML 将 *V 编码为块 (block) 风格的 YAML 文档，以两个空格缩进。字符串仅在必要时添加引号，多行字符串以字面块标量 (|) 的
+// 形式输出。默认情况下，object 的键按照 set 顺序输出，除非指定了 OptKeySequence() 或 OptKeySequenceWithLessFunc()。同时支持
+// OptOmitNull()。
+func (v *V) MarshalYAML(opts ...Option) ([]byte, error) {
+	if v == nil || v.valueType == NotExist {
+		return nil, ErrValueUninitialized
+	}
+
+	opt := combineOptions(opts)
+	if opt.MarshalLessFunc == nil && len(opt.MarshalKeySequence) == 0 {
+		opt.marshalBySetSequence = true
+	}
+
+	buf := bytes.Buffer{}
+	if err := v.marshalYAMLRoot(&buf, opt); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ---------------- decoding ----------------
+
+// yamlMaxAliasNodes limits total count of nodes copied in alias expanding, in order to prevent "billion laughs".
+const yamlMaxAliasNodes = 1 << 20
+
+type yamlParser struct {
+	src        []byte
+	pos        int
+	anchors    map[string]*V
+	aliasNodes int
+
+	// indentErr is the first error of indentation, which is found when skipping blank lines
+	indentErr error
+}
+
+func (p *yamlParser) errorf(format string, a ...any) error {
+	line := bytes.Count(p.src[:p.pos], []byte{'\n'}) + 1
+	return fmt.Errorf(
+		"%w: YAML at line %d, column %d: %s",
+		ErrRawBytesUnrecignized, line, p.column()+1, fmt.Sprintf(format, a...),
+	)
+}
+
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *yamlParser) peek() byte {
+	return p.peekAt(0)
+}
+
+func (p *yamlParser) peekAt(offset int) byte {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *yamlParser) column() int {
+	return p.pos - (bytes.LastIndexByte(p.src[:p.pos], '\n') + 1)
+}
+
+func isYAMLSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// isYAMLBlank tells whether c is space, line break or end of data.
+func isYAMLBlank(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == 0
+}
+
+func isYAMLFlowIndicator(c byte) bool {
+	return c == ',' || c == '[' || c == ']' || c == '{' || c == '}'
+}
+
+func (p *yamlParser) skipSpaces() {
+	for isYAMLSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// atLineEnd tells whether there is nothing but comment in the rest of current line.
+func (p *yamlParser) atLineEnd() bool {
+	switch p.peek() {
+	case 0, '\n':
+		return true
+	case '#':
+		return p.pos == 0 || isYAMLBlank(p.src[p.pos-1])
+	}
+	return false
+}
+
+func (p *yamlParser) skipLine() {
+	if i := bytes.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+		p.pos += i + 1
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+// skipBlankLines skips spaces, comments and empty lines, and stops at the next content. It returns false when
+// reaching end of data.
+func (p *yamlParser) skipBlankLines() bool {
+	for {
+		p.skipSpaces()
+		if p.eof() {
+			return false
+		}
+		if !p.atLineEnd() {
+			p.checkIndent()
+			return true
+		}
+		p.skipLine()
+	}
+}
+
+// checkIndent records an error if the content at current position is indented by tabs.
+func (p *yamlParser) checkIndent() {
+	lineStart := bytes.LastIndexByte(p.src[:p.pos], '\n') + 1
+	indent := p.src[lineStart:p.pos]
+	if p.indentErr == nil && bytes.IndexByte(indent, '\t') >= 0 && len(bytes.Trim(indent, " \t")) == 0 {
+		p.indentErr = p.errorf("tabs are not allowed in indentation")
+	}
+}
+
+func (p *yamlParser) expectLineEnd() error {
+	p.skipSpaces()
+	if !p.atLineEnd() {
+		return p.errorf("unexpected character %q", p.peek())
+	}
+	return nil
+}
+
+func (p *yamlParser) isDocMarker() bool {
+	if p.column() != 0 || len(p.src)-p.pos < 3 {
+		return false
+	}
+	s := string(p.src[p.pos : p.pos+3])
+	return (s == "---" || s == "...") && isYAMLBlank(p.peekAt(3))
+}
+
+func (p *yamlParser) isSeqEntry() bool {
+	return p.peek() == '-' && isYAMLBlank(p.peekAt(1))
+}
+
+func (p *yamlParser) parseDocument() (*V, error) {
+	// directives
+	for p.skipBlankLines() && p.column() == 0 && p.peek() == '%' {
+		p.skipLine()
+	}
+	if p.eof() {
+		return NewNull(), nil
+	}
+	if p.isDocMarker() && p.peek() == '-' {
+		p.pos += 3
+	}
+
+	v, err := p.parseBlockValue(-1, false)
+	if p.indentErr != nil {
+		return nil, p.indentErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.skipBlankLines() && p.isDocMarker() && p.peek() == '.' {
+		p.pos += 3
+		p.skipBlankLines()
+	}
+	if p.indentErr != nil {
+		return nil, p.indentErr
+	}
+	if !p.eof() {
+		if p.isDocMarker() {
+			return nil, p.errorf("multiple documents are not supported")
+		}
+		return nil, p.errorf("unexpected content")
+	}
+	return v, nil
+}
+
+// parseProperties parses anchor and tag of a node.
+func (p *yamlParser) parseProperties() (anchor, tag string, err error) {
+	for i := 0; i < 2; i++ {
+		p.skipSpaces()
+		c := p.peek()
+		if c != '&' && c != '!' {
+			break
+		}
+		start := p.pos
+		for !isYAMLBlank(p.peek()) && !isYAMLFlowIndicator(p.peek()) {
+			p.pos++
+		}
+		s := string(p.src[start:p.pos])
+		if c == '&' {
+			if anchor != "" || len(s) == 1 {
+				p.pos = start
+				return "", "", p.errorf("invalid anchor")
+			}
+			anchor = s[1:]
+		} else {
+			if tag != "" {
+				p.pos = start
+				return "", "", p.errorf("duplicated tag")
+			}
+			tag = normalizeYAMLTag(s)
+		}
+	}
+	return anchor, tag, nil
+}
+
+func normalizeYAMLTag(tag string) string {
+	const prefix = "!<tag:yaml.org,2002:"
+	if strings.HasPrefix(tag, prefix) && strings.HasSuffix(tag, ">") {
+		return "!!" + tag[len(prefix):len(tag)-1]
+	}
+	return tag
+}
+
+// parseBlockValue parses the node after "key:", "- " or "---". The node should be more indented than its parent,
+// except that a block sequence could be as indented as its parent mapping.
+func (p *yamlParser) parseBlockValue(indent int, isMapValue bool) (*V, error) {
+	anchor, tag, err := p.parseProperties()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+
+	var v *V
+	if !p.atLineEnd() {
+		v, err = p.parseInlineNode(indent, !isMapValue, tag)
+	} else if lineEnd := p.pos; !p.skipBlankLines() || p.isDocMarker() {
+		v, err = p.resolveScalar("", true, tag)
+	} else if col := p.column(); col > indent || (col == indent && isMapValue && p.isSeqEntry()) {
+		v, err = p.parseInlineNode(indent, true, tag)
+	} else {
+		p.pos = lineEnd
+		v, err = p.resolveScalar("", true, tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.registerAnchor(anchor, v)
+	return v, nil
+}
+
+// parseInlineNode parses a node starting at current position. If compact is true, block collections starting in the
+// middle of a line, such as "- - a" and "- a: b", are allowed.
+func (p *yamlParser) parseInlineNode(indent int, compact bool, tag string) (*V, error) {
+	col := p.column()
+	switch {
+	case compact && p.isSeqEntry():
+		return p.parseBlockSequence(col)
+	case compact && p.lineHasMappingKey():
+		return p.parseBlockMapping(col)
+	}
+
+	switch p.peek() {
+	case '|', '>':
+		return p.parseBlockScalar(indent, tag)
+
+	case '[', '{', '*', '"', '\'':
+		v, err := p.parseFlowNode(tag)
+		if err != nil {
+			return nil, err
+		}
+		return v, p.expectLineEnd()
+
+	case '&', '!':
+		// properties in the next line, e.g. "key:\n  !!str 123"
+		anchor, innerTag, err := p.parseProperties()
+		if err != nil {
+			return nil, err
+		}
+		if tag == "" {
+			tag = innerTag
+		}
+		p.skipSpaces()
+		v, err := p.parseInlineNode(indent, false, tag)
+		if err != nil {
+			return nil, err
+		}
+		p.registerAnchor(anchor, v)
+		return v, nil
+	}
+
+	s, err := p.parsePlainBlockScalar(indent)
+	if err != nil {
+		return nil, err
+	}
+	return p.resolveScalar(s, true, tag)
+}
+
+func (p *yamlParser) registerAnchor(anchor string, v *V) {
+	if anchor != "" {
+		p.anchors[anchor] = v
+	}
+}
+
+// resolveScalar is resolveYAMLScalar with position information in error.
+func (p *yamlParser) resolveScalar(s string, plain bool, tag string) (*V, error) {
+	v, err := resolveYAMLScalar(s, plain, tag)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return v, nil
+}
+
+// lineHasMappingKey tells whether current line starts with a mapping key.
+func (p *yamlParser) lineHasMappingKey() bool {
+	start := p.pos
+	_, _, err := p.parseKey()
+	p.pos = start
+	return err == nil
+}
+
+// parseKey parses an implicit key in block mapping, as well as the following colon.
+func (p *yamlParser) parseKey() (key string, plain bool, err error) {
+	c := p.peek()
+	switch {
+	case c == '"' || c == '\'':
+		start := p.pos
+		if key, err = p.parseQuotedScalar(); err != nil {
+			return "", false, err
+		}
+		if bytes.IndexByte(p.src[start:p.pos], '\n') >= 0 {
+			return "", false, p.errorf("multi-line implicit key")
+		}
+		p.skipSpaces()
+
+	case isYAMLFlowIndicator(c) || isYAMLBlank(c) || strings.IndexByte("#&*!|>%@`?", c) >= 0 || p.isSeqEntry():
+		return "", false, p.errorf("invalid mapping key")
+
+	default:
+		start := p.pos
+		for {
+			c := p.peek()
+			if c == 0 || c == '\n' || (c == '#' && isYAMLSpace(p.src[p.pos-1])) {
+				return "", false, p.errorf("mapping key expected")
+			}
+			if c == ':' && isYAMLBlank(p.peekAt(1)) {
+				break
+			}
+			p.pos++
+		}
+		key = strings.TrimRight(string(p.src[start:p.pos]), " \t")
+		plain = true
+	}
+
+	if p.peek() != ':' || !isYAMLBlank(p.peekAt(1)) {
+		return "", false, p.errorf("colon expected after mapping key")
+	}
+	p.pos++
+	return key, plain, nil
+}
+
+func (p *yamlParser) parseBlockMapping(col int) (*V, error) {
+	obj := newObject()
+	m := yamlMapping{obj: obj, explicit: map[string]bool{}}
+
+	for {
+		keyPos := p.pos
+		k, plain, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		v, err := p.parseBlockValue(col, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.set(k, plain, v); err != nil {
+			p.pos = keyPos
+			return nil, p.errorf("%v", err)
+		}
+
+		if !p.skipBlankLines() || p.isDocMarker() {
+			break
+		}
+		if c := p.column(); c < col {
+			break
+		} else if c > col {
+			return nil, p.errorf("bad indentation of a mapping entry")
+		}
+		if p.isSeqEntry() {
+			return nil, p.errorf("unexpected sequence entry in a mapping")
+		}
+	}
+	return obj, nil
+}
+
+func (p *yamlParser) parseBlockSequence(col int) (*V, error) {
+	arr := newArray()
+	for {
+		p.pos++ // '-'
+		v, err := p.parseBlockValue(col, false)
+		if err != nil {
+			return nil, err
+		}
+		arr.appendToArr(v)
+
+		if !p.skipBlankLines() || p.isDocMarker() {
+			break
+		}
+		if c := p.column(); c < col || (c == col && !p.isSeqEntry()) {
+			break
+		} else if c > col {
+			return nil, p.errorf("bad indentation of a sequence entry")
+		}
+	}
+	return arr, nil
+}
+
+// yamlMapping handles duplicated keys and merge keys.
+type yamlMapping struct {
+	obj      *V
+	explicit map[string]bool
+}
+
+func (m *yamlMapping) set(k string, plain bool, v *V) error {
+	if plain && k == "<<" {
+		return m.merge(v)
+	}
+	if m.explicit[k] {
+		return fmt.Errorf("duplicated mapping key %q", k)
+	}
+	m.explicit[k] = true
+	m.obj.setToObjectChildren(k, v)
+	return nil
+}
+
+func (m *yamlMapping) merge(v *V) error {
+	switch v.valueType {
+	default:
+		return fmt.Errorf("merge key requires a mapping or a sequence of mappings")
+	case Object:
+		v.RangeObjectsBySetSequence(func(k string, child *V) bool {
+			if _, exist := m.obj.children.object[k]; !exist {
+				m.obj.setToObjectChildren(k, child)
+			}
+			return true
+		})
+	case Array:
+		for _, child := range v.children.arr {
+			if child.valueType != Object {
+				return fmt.Errorf("merge key requires a mapping or a sequence of mappings")
+			}
+			if err := m.merge(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parsePlainBlockScalar parses a plain scalar in block context, which may continue in following lines which are more
+// indented than its parent.
+func (p *yamlParser) parsePlainBlockScalar(indent int) (string, error) {
+	buf := strings.Builder{}
+	buf.WriteString(p.readPlainLine())
+
+	for {
+		lineEnd := p.pos
+		emptyLines := 0
+		p.skipLine()
+		for {
+			p.skipSpaces()
+			if p.peek() != '\n' {
+				break
+			}
+			emptyLines++
+			p.pos++
+		}
+		if p.eof() || p.column() <= indent || p.atLineEnd() || p.isDocMarker() {
+			p.pos = lineEnd
+			break
+		}
+		if p.lineHasMappingKey() {
+			return "", p.errorf("mapping values are not allowed in multi-line plain scalar")
+		}
+		if emptyLines == 0 {
+			buf.WriteByte(' ')
+		} else {
+			buf.WriteString(strings.Repeat("\n", emptyLines))
+		}
+		buf.WriteString(p.readPlainLine())
+	}
+
+	return buf.String(), nil
+}
+
+// readPlainLine reads a line of plain scalar until comment or line break.
+func (p *yamlParser) readPlainLine() string {
+	start := p.pos
+	for {
+		c := p.peek()
+		if c == 0 || c == '\n' || (c == '#' && isYAMLSpace(p.src[p.pos-1])) {
+			break
+		}
+		p.pos++
+	}
+	return strings.TrimRight(string(p.src[start:p.pos]), " \t")
+}
+
+func (p *yamlParser) parseBlockScalar(indent int, tag string) (*V, error) {
+	folded := p.peek() == '>'
+	p.pos++
+
+	chomping := byte(0) // clip
+	contentIndent := -1
+	for i := 0; i < 2; i++ {
+		c := p.peek()
+		switch {
+		case (c == '+' || c == '-') && chomping == 0:
+			chomping = c
+			p.pos++
+		case c >= '1' && c <= '9' && contentIndent < 0:
+			contentIndent = indent + int(c-'0')
+			if contentIndent < 0 {
+				contentIndent = 0
+			}
+			p.pos++
+		}
+	}
+	if err := p.expectLineEnd(); err != nil {
+		return nil, err
+	}
+	p.skipLine()
+
+	var lines []string
+	trailingEmpty := 0
+	for !p.eof() {
+		lineStart := p.pos
+		sp := 0
+		for p.peekAt(sp) == ' ' {
+			sp++
+		}
+		c := p.peekAt(sp)
+		if c == '\n' || c == 0 {
+			// empty line
+			if contentIndent >= 0 && sp > contentIndent {
+				lines = append(lines, string(p.src[lineStart+contentIndent:lineStart+sp]))
+				trailingEmpty = 0
+			} else {
+				lines = append(lines, "")
+				trailingEmpty++
+			}
+			p.skipLine()
+			continue
+		}
+		if contentIndent < 0 {
+			if sp <= indent {
+				break
+			}
+			contentIndent = sp
+		}
+		if sp < contentIndent || (sp == 0 && p.isDocMarker()) {
+			break
+		}
+		p.skipLine()
+		lines = append(lines, strings.TrimSuffix(string(p.src[lineStart+contentIndent:p.pos]), "\n"))
+		trailingEmpty = 0
+	}
+
+	// put back the line break before next content, which is used to identify line end of this node
+	if p.pos > 0 && p.src[p.pos-1] == '\n' && !p.eof() {
+		p.pos--
+	}
+
+	content := lines[:len(lines)-trailingEmpty]
+	var s string
+	if folded {
+		s = foldYAMLLines(content)
+	} else {
+		s = strings.Join(content, "\n")
+	}
+
+	switch {
+	case chomping == '-' || (len(content) == 0 && chomping != '+'):
+		// strip
+	case chomping == '+':
+		if len(content) > 0 {
+			s += "\n"
+		}
+		s += strings.Repeat("\n", trailingEmpty)
+	default:
+		s += "\n"
+	}
+
+	return p.resolveScalar(s, false, tag)
+}
+
+// foldYAMLLines joins lines of a folded block scalar. Lines are folded into spaces, while empty lines and
+// more-indented lines are kept as line breaks.
+func foldYAMLLines(lines []string) string {
+	buf := strings.Builder{}
+	empty := 0
+	prevMoreIndented := false
+	for i, line := range lines {
+		if line == "" {
+			empty++
+			continue
+		}
+		moreIndented := isYAMLSpace(line[0])
+		switch {
+		case i == empty:
+			// leading empty lines
+			buf.WriteString(strings.Repeat("\n", empty))
+		case moreIndented || prevMoreIndented:
+			buf.WriteString(strings.Repeat("\n", empty+1))
+		case empty > 0:
+			buf.WriteString(strings.Repeat("\n", empty))
+		default:
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(line)
+		empty = 0
+		prevMoreIndented = moreIndented
+	}
+	return buf.String()
+}
+
+// parseQuotedScalar parses a single or double quoted scalar, which may span multiple lines.
+func (p *yamlParser) parseQuotedScalar() (string, error) {
+	quote := p.peek()
+	start := p.pos
+	p.pos++
+
+	buf := strings.Builder{}
+	for {
+		if p.eof() {
+			p.pos = start
+			return "", p.errorf("unterminated quoted scalar")
+		}
+		c := p.src[p.pos]
+		switch {
+		case c == quote && quote == '\'' && p.peekAt(1) == '\'':
+			buf.WriteByte('\'')
+			p.pos += 2
+
+		case c == quote:
+			p.pos++
+			return buf.String(), nil
+
+		case c == '\\' && quote == '"':
+			if p.peekAt(1) == '\n' {
+				// escaped line break, leading spaces of next line are skipped
+				p.pos += 2
+				p.skipSpaces()
+				continue
+			}
+			if err := p.readEscape(&buf); err != nil {
+				return "", err
+			}
+
+		case c == '\n':
+			s := strings.TrimRight(buf.String(), " \t")
+			buf.Reset()
+			buf.WriteString(s)
+			empty := 0
+			for {
+				p.pos++
+				p.skipSpaces()
+				if p.peek() != '\n' {
+					break
+				}
+				empty++
+			}
+			if p.isDocMarker() {
+				return "", p.errorf("document marker in quoted scalar")
+			}
+			if empty == 0 {
+				buf.WriteByte(' ')
+			} else {
+				buf.WriteString(strings.Repeat("\n", empty))
+			}
+
+		default:
+			buf.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *yamlParser) readEscape(buf *strings.Builder) error {
+	c := p.peekAt(1)
+	simple := map[byte]string{
+		'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v", 'f': "\f", 'r': "\r",
+		'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028",
+		'P': "\u2029",
+	}
+	if s, exist := simple[c]; exist {
+		buf.WriteString(s)
+		p.pos += 2
+		return nil
+	}
+
+	size := 0
+	switch c {
+	case 'x':
+		size = 2
+	case 'u':
+		size = 4
+	case 'U':
+		size = 8
+	default:
+		return p.errorf("invalid escape character %q", c)
+	}
+	if len(p.src)-p.pos < 2+size {
+		return p.errorf("invalid escape sequence")
+	}
+	u, err := strconv.ParseUint(string(p.src[p.pos+2:p.pos+2+size]), 16, 32)
+	if err != nil || !utf8.ValidRune(rune(u)) {
+		return p.errorf("invalid escape sequence")
+	}
+	buf.WriteRune(rune(u))
+	p.pos += 2 + size
+	return nil
+}
+
+// skipFlowSpaces skips spaces, line breaks and comments in flow collections.
+func (p *yamlParser) skipFlowSpaces() {
+	for {
+		p.skipSpaces()
+		switch {
+		case p.peek() == '\n':
+			p.pos++
+		case p.peek() == '#' && p.atLineEnd():
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// parseFlowNode parses a node in flow style. Tag is given if it has been parsed in block context.
+func (p *yamlParser) parseFlowNode(tag string) (*V, error) {
+	p.skipFlowSpaces()
+	anchor, innerTag, err := p.parseProperties()
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		tag = innerTag
+	}
+	p.skipFlowSpaces()
+
+	var v *V
+	switch c := p.peek(); c {
+	case '[':
+		v, err = p.parseFlowSequence()
+	case '{':
+		v, err = p.parseFlowMapping()
+	case '*':
+		v, err = p.parseAlias()
+	case '"', '\'':
+		var s string
+		if s, err = p.parseQuotedScalar(); err == nil {
+			v, err = p.resolveScalar(s, false, tag)
+		}
+	case ',', ']', '}', ':':
+		v, err = p.resolveScalar("", true, tag)
+	default:
+		var s string
+		if s, err = p.parsePlainFlowScalar(); err == nil {
+			v, err = p.resolveScalar(s, true, tag)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.registerAnchor(anchor, v)
+	return v, nil
+}
+
+func (p *yamlParser) parseAlias() (*V, error) {
+	start := p.pos
+	p.pos++
+	for !isYAMLBlank(p.peek()) && !isYAMLFlowIndicator(p.peek()) {
+		p.pos++
+	}
+	name := string(p.src[start+1 : p.pos])
+	v, exist := p.anchors[name]
+	if !exist {
+		p.pos = start
+		return nil, p.errorf("undefined alias %q", name)
+	}
+	res, err := p.copyNode(v)
+	if err != nil {
+		p.pos = start
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *yamlParser) copyNode(v *V) (*V, error) {
+	p.aliasNodes++
+	if p.aliasNodes > yamlMaxAliasNodes {
+		return nil, p.errorf("too many nodes expanded by aliases")
+	}
+
+	switch v.valueType {
+	default:
+		res := *v
+		return &res, nil
+
+	case Array:
+		res := newArray()
+		for _, child := range v.children.arr {
+			c, err := p.copyNode(child)
+			if err != nil {
+				return nil, err
+			}
+			res.appendToArr(c)
+		}
+		return res, nil
+
+	case Object:
+		res := newObject()
+		var err error
+		v.RangeObjectsBySetSequence(func(k string, child *V) bool {
+			var c *V
+			if c, err = p.copyNode(child); err != nil {
+				return false
+			}
+			res.setToObjectChildren(k, c)
+			return true
+		})
+		return res, err
+	}
+}
+
+// parsePlainFlowScalar parses a plain scalar in flow context, which ends at flow indicators or ": ".
+func (p *yamlParser) parsePlainFlowScalar() (string, error) {
+	var words []string
+	for {
+		start := p.pos
+		for {
+			c := p.peek()
+			if c == 0 || c == '\n' || isYAMLFlowIndicator(c) || (c == '#' && isYAMLSpace(p.src[p.pos-1])) {
+				break
+			}
+			if c == ':' && (isYAMLBlank(p.peekAt(1)) || isYAMLFlowIndicator(p.peekAt(1))) {
+				break
+			}
+			p.pos++
+		}
+		if s := strings.TrimRight(string(p.src[start:p.pos]), " \t"); s != "" {
+			words = append(words, s)
+		}
+		if p.peek() != '\n' {
+			break
+		}
+		// multi-line plain scalar in flow context
+		lineEnd := p.pos
+		p.skipFlowSpaces()
+		if c := p.peek(); c == 0 || isYAMLFlowIndicator(c) || c == ':' || c == '#' {
+			p.pos = lineEnd
+			break
+		}
+	}
+	if len(words) == 0 {
+		return "", p.errorf("unexpected character %q", p.peek())
+	}
+	return strings.Join(words, " "), nil
+}
+
+func (p *yamlParser) parseFlowSequence() (*V, error) {
+	p.pos++ // '['
+	arr := newArray()
+	for {
+		p.skipFlowSpaces()
+		if p.peek() == ']' {
+			p.pos++
+			return arr, nil
+		}
+
+		keyPos := p.pos
+		v, err := p.parseFlowNode("")
+		if err != nil {
+			return nil, err
+		}
+		p.skipFlowSpaces()
+		if p.peek() == ':' {
+			// single pair mapping, e.g. [a: b]
+			k, err := yamlKeyString(v)
+			if err != nil {
+				p.pos = keyPos
+				return nil, p.errorf("%v", err)
+			}
+			value, err := p.parseFlowValue()
+			if err != nil {
+				return nil, err
+			}
+			v = newObject()
+			v.setToObjectChildren(k, value)
+		}
+		arr.appendToArr(v)
+
+		if err := p.flowEntryEnd(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *yamlParser) parseFlowMapping() (*V, error) {
+	p.pos++ // '{'
+	obj := newObject()
+	m := yamlMapping{obj: obj, explicit: map[string]bool{}}
+	for {
+		p.skipFlowSpaces()
+		if p.peek() == '}' {
+			p.pos++
+			return obj, nil
+		}
+
+		keyPos := p.pos
+		k, plain, err := p.parseFlowKey()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipFlowSpaces()
+		var value *V
+		if p.peek() == ':' {
+			if value, err = p.parseFlowValue(); err != nil {
+				return nil, err
+			}
+		} else {
+			value = NewNull()
+		}
+		if err := m.set(k, plain, value); err != nil {
+			p.pos = keyPos
+			return nil, p.errorf("%v", err)
+		}
+
+		if err := p.flowEntryEnd('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseFlowKey parses a key in flow mapping. Plain keys are kept as they are written, just like those in block
+// mappings.
+func (p *yamlParser) parseFlowKey() (key string, plain bool, err error) {
+	switch p.peek() {
+	case '"', '\'':
+		key, err = p.parseQuotedScalar()
+		return key, false, err
+	case '[', '{', '&', '!', '?':
+		return "", false, p.errorf("unsupported mapping key")
+	case '*':
+		v, err := p.parseAlias()
+		if err != nil {
+			return "", false, err
+		}
+		key, err = yamlKeyString(v)
+		if err != nil {
+			return "", false, p.errorf("%v", err)
+		}
+		return key, false, nil
+	case ':':
+		return "", true, nil
+	default:
+		key, err = p.parsePlainFlowScalar()
+		return key, true, err
+	}
+}
+
+// parseFlowValue parses the value after colon in flow collections.
+func (p *yamlParser) parseFlowValue() (*V, error) {
+	p.pos++ // ':'
+	p.skipFlowSpaces()
+	if c := p.peek(); c == ',' || c == ']' || c == '}' {
+		return NewNull(), nil
+	}
+	return p.parseFlowNode("")
+}
+
+func (p *yamlParser) flowEntryEnd(closing byte) error {
+	p.skipFlowSpaces()
+	switch p.peek() {
+	case ',':
+		p.pos++
+		return nil
+	case closing:
+		return nil
+	case 0:
+		return p.errorf("unterminated flow collection")
+	default:
+		return p.errorf("unexpected character %q in flow collection", p.peek())
+	}
+}
+
+// yamlKeyString converts a scalar key into string. Keys in flow collections have been resolved, thus numbers are
+// converted back into their source texts.
+func yamlKeyString(v *V) (string, error) {
+	switch v.valueType {
+	case String:
+		return v.valueStr, nil
+	case Number:
+		if len(v.srcByte) > 0 {
+			return string(v.srcByte), nil
+		}
+		return formatYAMLFloat(v.num.f64), nil
+	case Boolean, Null:
+		return v.MustMarshalString(), nil
+	default:
+		return "", fmt.Errorf("unsupported mapping key type %v", v.valueType)
+	}
+}
+
+// resolveYAMLScalar converts a scalar into *V by tag. Plain scalars without tags are resolved by YAML 1.2 core schema.
+func resolveYAMLScalar(s string, plain bool, tag string) (*V, error) {
+	switch tag {
+	case "!!str":
+		return NewString(s), nil
+	case "!!null":
+		if !isYAMLNull(s) {
+			return nil, fmt.Errorf("%w: invalid null value %q", ErrTypeNotMatch, s)
+		}
+		return NewNull(), nil
+	case "!!bool":
+		if b, ok := parseYAMLBool(s); ok {
+			return NewBool(b), nil
+		}
+		return nil, fmt.Errorf("%w: invalid bool value %q", ErrTypeNotMatch, s)
+	case "!!int":
+		if v, ok := parseYAMLInt(s); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("%w: invalid int value %q", ErrTypeNotMatch, s)
+	case "!!float":
+		if v, ok := parseYAMLInt(s); ok {
+			return v, nil
+		}
+		if v, ok := parseYAMLFloat(s); ok {
+			return checkYAMLFloat(s, v)
+		}
+		return nil, fmt.Errorf("%w: invalid float value %q", ErrTypeNotMatch, s)
+	case "!!binary":
+		return NewString(strings.Join(strings.Fields(s), "")), nil
+	}
+
+	if !plain || tag == "!" {
+		return NewString(s), nil
+	}
+	if isYAMLNull(s) {
+		return NewNull(), nil
+	}
+	if b, ok := parseYAMLBool(s); ok {
+		return NewBool(b), nil
+	}
+	if v, ok := parseYAMLInt(s); ok {
+		return v, nil
+	}
+	if v, ok := parseYAMLFloat(s); ok {
+		return checkYAMLFloat(s, v)
+	}
+	return NewString(s), nil
+}
+
+// checkYAMLFloat rejects infinity and NaN, which could not be represented in JSON.
+func checkYAMLFloat(s string, v *V) (*V, error) {
+	if f := v.num.f64; math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, fmt.Errorf("%w: float %q is not supported by JSON", ErrOutOfRange, s)
+	}
+	return v, nil
+}
+
+func isYAMLNull(s string) bool {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+func parseYAMLBool(s string) (b, ok bool) {
+	switch s {
+	case "true", "True", "TRUE":
+		return true, true
+	case "false", "False", "FALSE":
+		return false, true
+	}
+	return false, false
+}
+
+func parseYAMLInt(s string) (*V, bool) {
+	switch {
+	case strings.HasPrefix(s, "0x"):
+		if u, err := strconv.ParseUint(s[2:], 16, 64); err == nil && !strings.HasPrefix(s[2:], "+") {
+			return NewUint64(u), true
+		}
+		return nil, false
+	case strings.HasPrefix(s, "0o"):
+		if u, err := strconv.ParseUint(s[2:], 8, 64); err == nil && !strings.HasPrefix(s[2:], "+") {
+			return NewUint64(u), true
+		}
+		return nil, false
+	}
+
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if len(s)-len(digits) > 1 || !isDecimalDigits(digits) {
+		return nil, false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return NewInt64(i), true
+	}
+	if u, err := strconv.ParseUint(strings.TrimPrefix(s, "+"), 10, 64); err == nil {
+		return NewUint64(u), true
+	}
+	// Out of range of integers. The digits are kept as number text instead of being rounded into float64, while
+	// integers even beyond float64 are not integers here, and will be rejected as floats.
+	lit := strings.TrimLeft(digits, "0")
+	if strings.HasPrefix(s, "-") {
+		lit = "-" + lit
+	}
+	if v, err := iter(lit).parseFloatResult(0, len(lit)); err == nil {
+		return v, true
+	}
+	return nil, false
+}
+
+func isDecimalDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+func parseYAMLFloat(s string) (*V, bool) {
+	switch s {
+	case ".inf", ".Inf", ".INF", "+.inf", "+.Inf", "+.INF":
+		return NewFloat64(math.Inf(1)), true
+	case "-.inf", "-.Inf", "-.INF":
+		return NewFloat64(math.Inf(-1)), true
+	case ".nan", ".NaN", ".NAN":
+		return NewFloat64(math.NaN()), true
+	}
+
+	// [-+]? (\.[0-9]+ | [0-9]+(\.[0-9]*)?) ([eE][-+]?[0-9]+)?
+	t := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if len(s)-len(t) > 1 {
+		return nil, false
+	}
+	mant, exp := t, ""
+	if i := strings.IndexAny(t, "eE"); i >= 0 {
+		mant, exp = t[:i], t[i+1:]
+		if e := strings.TrimPrefix(strings.TrimPrefix(exp, "-"), "+"); len(exp)-len(e) > 1 || !isDecimalDigits(e) {
+			return nil, false
+		}
+	}
+	intPart, fracPart := mant, ""
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		intPart, fracPart = mant[:i], mant[i+1:]
+	}
+	if !isDecimalDigits(intPart + fracPart) {
+		return nil, false
+	}
+
+	// build a JSON number literal, so that source text is kept as far as possible
+	if intPart == "" {
+		intPart = "0"
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	lit := intPart
+	if fracPart != "" {
+		lit += "." + fracPart
+	}
+	if exp != "" {
+		lit += "e" + exp
+	}
+	if strings.HasPrefix(s, "-") {
+		lit = "-" + lit
+	}
+
+	v, end, reachEnd, err := iter(lit).parseNumber(0)
+	if err == nil && reachEnd && end == len(lit) {
+		v.num.floated = true
+		return v, true
+	}
+	f, err := strconv.ParseFloat(lit, 64)
+	if err != nil && !math.IsInf(f, 0) {
+		return nil, false
+	}
+	return newDecodedFloat(NewFloat64(f)), true
+}
+
+// ---------------- encoding ----------------
+
+func (v *V) marshalYAMLRoot(buf *bytes.Buffer, opt *Opt) error {
+	switch v.valueType {
+	case Object:
+		keys, values := v.yamlObjectChildren(nil, opt)
+		if len(keys) == 0 {
+			buf.WriteString("{}\n")
+			return nil
+		}
+		return v.marshalYAMLMapping(nil, buf, opt, keys, values, 0, false)
+
+	case Array:
+		if len(v.children.arr) == 0 {
+			buf.WriteString("[]\n")
+			return nil
+		}
+		return v.marshalYAMLSequence(nil, buf, opt, 0, false)
+
+	case String:
+		if isYAMLLiteralCandidate(v.valueStr) {
+			writeYAMLLiteral(buf, v.valueStr, 2)
+			return nil
+		}
+	}
+
+	s, err := yamlScalarString(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(s)
+	buf.WriteByte('\n')
+	return nil
+}
+
+func (v *V) yamlObjectChildren(parentInfo *ParentInfo, opt *Opt) (keys []string, values []*V) {
+	keys, values = v.sortedObjectChildren(parentInfo, opt)
+	if !opt.OmitNull {
+		return keys, values
+	}
+	k, val := keys[:0], values[:0]
+	for i, child := range values {
+		if child.valueType != Null {
+			k = append(k, keys[i])
+			val = append(val, child)
+		}
+	}
+	return k, val
+}
+
+// marshalYAMLMapping writes mapping entries in given indent. If firstInline is true, the first key is written
+// without indent, which is used in compact form like "- key: value".
+func (v *V) marshalYAMLMapping(
+	parentInfo *ParentInfo, buf *bytes.Buffer, opt *Opt, keys []string, values []*V, indent int, firstInline bool,
+) error {
+	for i, k := range keys {
+		if i > 0 || !firstInline {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		if yamlNeedsQuote(k) {
+			writeYAMLDoubleQuoted(buf, k)
+		} else {
+			buf.WriteString(k)
+		}
+		buf.WriteByte(':')
+
+		child := values[i]
+		var par *ParentInfo
+		if opt.MarshalLessFunc != nil {
+			par = child.newParentInfo(parentInfo, stringKey(k))
+		}
+		if err := child.marshalYAMLMappingValue(par, buf, opt, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalYAMLMappingValue writes value after "key:".
+func (v *V) marshalYAMLMappingValue(parentInfo *ParentInfo, buf *bytes.Buffer, opt *Opt, indent int) error {
+	switch v.valueType {
+	case Object:
+		keys, values := v.yamlObjectChildren(parentInfo, opt)
+		if len(keys) == 0 {
+			buf.WriteString(" {}\n")
+			return nil
+		}
+		buf.WriteByte('\n')
+		return v.marshalYAMLMapping(parentInfo, buf, opt, keys, values, indent+2, false)
+
+	case Array:
+		if len(v.children.arr) == 0 {
+			buf.WriteString(" []\n")
+			return nil
+		}
+		buf.WriteByte('\n')
+		return v.marshalYAMLSequence(parentInfo, buf, opt, indent+2, false)
+
+	case String:
+		if isYAMLLiteralCandidate(v.valueStr) {
+			buf.WriteByte(' ')
+			writeYAMLLiteral(buf, v.valueStr, indent+2)
+			return nil
+		}
+	}
+
+	s, err := yamlScalarString(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(s)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// marshalYAMLSequence writes sequence entries in given indent. If firstInline is true, the first entry is written
+// without indent, which is used in compact form like "- - a".
+func (v *V) marshalYAMLSequence(parentInfo *ParentInfo, buf *bytes.Buffer, opt *Opt, indent int, firstInline bool) error {
+	for i, child := range v.children.arr {
+		if i > 0 || !firstInline {
+			buf.WriteString(strings.Repeat(" ", indent))
+		}
+		buf.WriteString("- ")
+
+		var par *ParentInfo
+		if opt.MarshalLessFunc != nil {
+			par = v.newParentInfo(parentInfo, intKey(i))
+		}
+		if err := child.marshalYAMLSequenceEntry(par, buf, opt, indent+2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalYAMLSequenceEntry writes value after "- ".
+func (v *V) marshalYAMLSequenceEntry(parentInfo *ParentInfo, buf *bytes.Buffer, opt *Opt, indent int) error {
+	switch v.valueType {
+	case Object:
+		keys, values := v.yamlObjectChildren(parentInfo, opt)
+		if len(keys) > 0 {
+			return v.marshalYAMLMapping(parentInfo, buf, opt, keys, values, indent, true)
+		}
+		buf.WriteString("{}\n")
+		return nil
+
+	case Array:
+		if len(v.children.arr) > 0 {
+			return v.marshalYAMLSequence(parentInfo, buf, opt, indent, true)
+		}
+		buf.WriteString("[]\n")
+		return nil
+
+	case String:
+		if isYAMLLiteralCandidate(v.valueStr) {
+			writeYAMLLiteral(buf, v.valueStr, indent)
+			return nil
+		}
+	}
+
+	s, err := yamlScalarString(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(s)
+	buf.WriteByte('\n')
+	return nil
+}
+
+func yamlScalarString(v *V) (string, error) {
+	switch v.valueType {
+	default:
+		return "", fmt.Errorf("%w: %v", ErrTypeNotMatch, v.valueType)
+	case Null:
+		return "null", nil
+	case Boolean:
+		if v.valueBool {
+			return "true", nil
+		}
+		return "false", nil
+	case Number:
+		if len(v.srcByte) > 0 {
+			return string(v.srcByte), nil
+		}
+		return formatYAMLFloat(v.num.f64), nil
+	case String:
+		if !yamlNeedsQuote(v.valueStr) {
+			return v.valueStr, nil
+		}
+		buf := bytes.Buffer{}
+		writeYAMLDoubleQuoted(&buf, v.valueStr)
+		return buf.String(), nil
+	}
+}
+
+func formatYAMLFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return ".nan"
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	default:
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+}
+
+// yamlNeedsQuote tells whether a string could not be written as a plain scalar.
+func yamlNeedsQuote(s string) bool {
+	if s == "" || s == "<<" || strings.IndexByte("-?:,[]{}#&*!|>'\"%@` \t", s[0]) >= 0 {
+		return true
+	}
+	if last := s[len(s)-1]; last == ' ' || last == '\t' || last == ':' {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasPrefix(s, "...") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f || r == 0x85 || r == 0xfeff || r == 0x2028 || r == 0x2029 {
+			return true
+		}
+	}
+
+	v, _ := resolveYAMLScalar(s, true, "")
+	if v.valueType != String {
+		return true
+	}
+	// booleans in YAML 1.1, which are still treated as booleans by many parsers
+	switch strings.ToLower(s) {
+	case "y", "yes", "n", "no", "on", "off":
+		return true
+	}
+	return false
+}
+
+func writeYAMLDoubleQuoted(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case 0x85:
+			buf.WriteString(`\N`)
+		case 0x2028:
+			buf.WriteString(`\L`)
+		case 0x2029:
+			buf.WriteString(`\P`)
+		case 0xfeff:
+			buf.WriteString(`\ufeff`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(buf, `\x%02x`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// isYAMLLiteralCandidate tells whether a string could be written as a literal block scalar.
+func isYAMLLiteralCandidate(s string) bool {
+	if !strings.Contains(s, "\n") || s[0] == '\n' || isYAMLSpace(s[0]) {
+		return false
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" && strings.TrimLeft(line, " \t") == "" {
+			// lines with only spaces could not be distinguished from empty lines
+			return false
+		}
+		for _, r := range line {
+			if (r < 0x20 && r != '\t') || r == 0x7f || r == 0x85 || r == 0xfeff || r == 0x2028 || r == 0x2029 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func writeYAMLLiteral(buf *bytes.Buffer, s string, indent int) {
+	body := strings.TrimSuffix(s, "\n")
+	switch {
+	case !strings.HasSuffix(s, "\n"):
+		buf.WriteString("|-\n")
+	case strings.HasSuffix(body, "\n"):
+		buf.WriteString("|+\n")
+	default:
+		buf.WriteString("|\n")
+	}
+
+	prefix := strings.Repeat(" ", indent)
+	for _, line := range strings.Split(body, "\n") {
+		if line != "" {
+			buf.WriteString(prefix)
+			buf.WriteString(line)
+		}
+		buf.WriteByte('\n')
+	}
+}
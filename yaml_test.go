@@ -0,0 +1,322 @@
+package jsonvalue
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func testYAML(t *testing.T) {
+	cv("block collections", func() { testYAMLBlockCollections(t) })
+	cv("flow collections", func() { testYAMLFlowCollections(t) })
+	cv("scalars", func() { testYAMLScalars(t) })
+	cv("multi-line strings", func() { testYAMLMultiLineStrings(t) })
+	cv("anchors and aliases", func() { testYAMLAnchors(t) })
+	cv("marshaling", func() { testYAMLMarshal(t) })
+	cv("errors", func() { testYAMLErrors(t) })
+}
+
+func yamlToJSON(s string) string {
+	v, err := UnmarshalYAML([]byte(s))
+	so(err, isNil)
+	return v.MustMarshalString(OptSetSequence(), OptEscapeSlash(false), OptUTF8())
+}
+
+func testYAMLBlockCollections(t *testing.T) {
+	src := strings.Join([]string{
+		"%YAML 1.2",
+		"---",
+		"# comment",
+		"name: jsonvalue   # trailing comment",
+		"version: 1",
+		"url: http://example.com/a#b",
+		"empty:",
+		"tags:",
+		"- json",
+		"- yaml",
+		"nested:",
+		"  list:",
+		"    - a: 1",
+		"      b: 2",
+		"    - - x",
+		"      - y",
+		"    -",
+		"      c: 3",
+		"  \"quoted key\": 'v'",
+		"  key with spaces: v2",
+		"last: end",
+		"...",
+		"",
+	}, "\n")
+	so(yamlToJSON(src), eq, `{"name":"jsonvalue","version":1,"url":"http://example.com/a#b","empty":null,`+
+		`"tags":["json","yaml"],"nested":{"list":[{"a":1,"b":2},["x","y"],{"c":3}],"quoted key":"v",`+
+		`"key with spaces":"v2"},"last":"end"}`)
+
+	so(yamlToJSON("- 1\n- two\n-\n- - 3\n"), eq, `[1,"two",null,[3]]`)
+	so(yamlToJSON("a:\n  - 1\nb:\n  c:\n"), eq, `{"a":[1],"b":{"c":null}}`)
+	so(yamlToJSON("\r\na: 1\r\nb: 2\r\n"), eq, `{"a":1,"b":2}`)
+	so(yamlToJSON(""), eq, `null`)
+	so(yamlToJSON("# only comment\n"), eq, `null`)
+	so(yamlToJSON("--- text\n"), eq, `"text"`)
+}
+
+func testYAMLFlowCollections(t *testing.T) {
+	so(yamlToJSON(`[1, "two", three, {a: b}, [], {}]`), eq, `[1,"two","three",{"a":"b"},[],{}]`)
+	so(yamlToJSON(`{"json": "style", "n": [1, 2.5, true, null]}`), eq, `{"json":"style","n":[1,2.5,true,null]}`)
+	so(yamlToJSON("key: [a,\n  b, # comment\n  c,]\n"), eq, `{"key":["a","b","c"]}`)
+	so(yamlToJSON("{a, b: , c: d e}"), eq, `{"a":null,"b":null,"c":"d e"}`)
+	so(yamlToJSON("[a: 1, b]"), eq, `[{"a":1},"b"]`)
+	so(yamlToJSON("{url: http://x.com, 0x10: hex}"), eq, `{"url":"http://x.com","0x10":"hex"}`)
+}
+
+func testYAMLScalars(t *testing.T) {
+	v, err := UnmarshalYAML([]byte(strings.Join([]string{
+		"nulls: [~, null, Null, NULL, ]",
+		"bools: [true, True, FALSE, yes, no]",
+		"ints: [0, -12, +7, 0x1F, 0o17, 18446744073709551615]",
+		"floats: [1.5, -0.25, .5, 1e3, 6.02E+23, 1.]",
+		"specials: ['.inf', \"-.Inf\", !!str .NaN]",
+		"strings: [1.2.3, 12abc, '123', \"true\", 0x, -]",
+		"tagged: [!!str 123, !!int \"42\", !!float 1, !!bool 'true', !!null '', !!str , !custom 1]",
+		"big: 123456789012345678901234567890",
+	}, "\n")))
+	so(err, isNil)
+
+	so(v.MustGet("nulls").MustMarshalString(), eq, `[null,null,null,null]`)
+	so(v.MustGet("bools").MustMarshalString(), eq, `[true,true,false,"yes","no"]`)
+	so(v.MustGet("ints").MustMarshalString(), eq, `[0,-12,7,31,15,18446744073709551615]`)
+	so(v.MustGet("floats").MustMarshalString(), eq, `[1.5,-0.25,0.5,1e3,6.02e+23,1]`)
+	for _, f := range v.MustGet("floats").ForRangeArr() {
+		so(f.IsFloat(), isTrue)
+	}
+	so(v.MustGet("specials").MustMarshalString(), eq, `[".inf","-.Inf",".NaN"]`)
+	so(v.MustGet("strings").MustMarshalString(), eq, `["1.2.3","12abc","123","true","0x","-"]`)
+	so(v.MustGet("tagged").MustMarshalString(), eq, `["123",42,1,true,null,"",1]`)
+	so(v.MustGet("big").IsFloat(), isTrue)
+	so(v.MustGet("big").MustMarshalString(), eq, `123456789012345678901234567890`)
+
+	// integers out of range of int64 and uint64 are kept exact
+	so(yamlToJSON("big: 123456789012345678901"), eq, `{"big":123456789012345678901}`)
+	so(yamlToJSON("big: [-0009223372036854775809, +18446744073709551616]"), eq,
+		`{"big":[-9223372036854775809,18446744073709551616]}`)
+	so(yamlToJSON("big: !!float 123456789012345678901"), eq, `{"big":123456789012345678901}`)
+	so(yamlToJSON("big: !!int 123456789012345678901"), eq, `{"big":123456789012345678901}`)
+
+	// while those out of range of float64 are not rounded to infinity
+	for _, s := range []string{"a: 1", "a: !!int 1", "a: !!float 1"} {
+		_, err := UnmarshalYAML([]byte(s + strings.Repeat("0", 400)))
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+	}
+
+	so(yamlToJSON(`"esc: \t\"\\\/\x41\u4e2d\U0001F600\N"`), eq, "\"esc: \\t\\\"\\\\/A中😀\u0085\"")
+	so(yamlToJSON(`'it''s'`), eq, `"it's"`)
+	so(yamlToJSON("key: !!str\n"), eq, `{"key":""}`)
+	so(yamlToJSON("key:\n  !!int '5'\n"), eq, `{"key":5}`)
+}
+
+func testYAMLMultiLineStrings(t *testing.T) {
+	src := strings.Join([]string{
+		"literal: |",
+		"  line 1",
+		"    indented",
+		"",
+		"  line 3",
+		"strip: |-",
+		"  text",
+		"",
+		"keep: |+",
+		"  text",
+		"",
+		"folded: >",
+		"  folded",
+		"  text",
+		"",
+		"  new paragraph",
+		"    more indented",
+		"  end",
+		"indicator: |2",
+		"   leading space",
+		"plain: multi",
+		"  line",
+		"",
+		"  plain",
+		"double: \"a",
+		"  b\\",
+		"  c\"",
+		"single: 'x",
+		"",
+		"  y'",
+		"seq:",
+		"- |",
+		"  in seq",
+		"- last",
+	}, "\n")
+	v, err := UnmarshalYAML([]byte(src))
+	so(err, isNil)
+	so(v.MustGet("literal").String(), eq, "line 1\n  indented\n\nline 3\n")
+	so(v.MustGet("strip").String(), eq, "text")
+	so(v.MustGet("keep").String(), eq, "text\n\n")
+	so(v.MustGet("folded").String(), eq, "folded text\nnew paragraph\n  more indented\nend\n")
+	so(v.MustGet("indicator").String(), eq, " leading space\n")
+	so(v.MustGet("plain").String(), eq, "multi line\nplain")
+	so(v.MustGet("double").String(), eq, "a bc")
+	so(v.MustGet("single").String(), eq, "x\ny")
+	so(v.MustGet("seq").MustMarshalString(), eq, `["in seq\n","last"]`)
+}
+
+func testYAMLAnchors(t *testing.T) {
+	src := strings.Join([]string{
+		"base: &base",
+		"  host: localhost",
+		"  port: 80",
+		"list: &list [1, 2]",
+		"dev:",
+		"  <<: *base",
+		"  port: 8080",
+		"  list: *list",
+		"prod:",
+		"  name: prod",
+		"  <<: [*base, {extra: true}]",
+		"scalar: &s hello",
+		"copy: *s",
+	}, "\n")
+	v, err := UnmarshalYAML([]byte(src))
+	so(err, isNil)
+	so(v.MustGet("dev").MustMarshalString(OptSetSequence()), eq, `{"host":"localhost","port":8080,"list":[1,2]}`)
+	so(v.MustGet("prod").MustMarshalString(OptSetSequence()), eq, `{"name":"prod","host":"localhost","port":80,"extra":true}`)
+	so(v.MustGet("copy").String(), eq, "hello")
+
+	// aliases are copies
+	v.MustGet("dev", "list").AppendInt(3).InTheEnd()
+	so(v.MustGet("list").MustMarshalString(), eq, `[1,2]`)
+
+	// billion laughs
+	laughs := []string{`a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]`}
+	for c := 'b'; c <= 'j'; c++ {
+		prev := string(c - 1)
+		laughs = append(laughs, string(c)+": &"+string(c)+" [*"+prev+",*"+prev+",*"+prev+",*"+prev+",*"+prev+
+			",*"+prev+",*"+prev+",*"+prev+",*"+prev+"]")
+	}
+	_, err = UnmarshalYAML([]byte(strings.Join(laughs, "\n")))
+	so(err, isErr)
+	so(err.Error(), hasSubStr, "too many nodes")
+}
+
+func testYAMLMarshal(t *testing.T) {
+	v := MustUnmarshalString(`{"name":"jsonvalue","version":1.50,"empty_obj":{},"empty_arr":[],"null":null,` +
+		`"list":[1,"two",{"a":true,"b":[]},[3,4]],"nested":{"deep":{"key":"value"}},` +
+		`"quoted":["","true","123","- x","a: b","yes","<<"," lead","trail ","x #y"],` +
+		`"multi":"line 1\nline 2\n","strip":"a\nb","keep":"a\n\n","esc":"tab\there\r\n",` +
+		`"key: colon":1}`)
+	b, err := v.MarshalYAML()
+	so(err, isNil)
+	so(string(b), eq, strings.Join([]string{
+		`name: jsonvalue`,
+		`version: 1.50`,
+		`empty_obj: {}`,
+		`empty_arr: []`,
+		`"null": null`,
+		`list:`,
+		`  - 1`,
+		`  - two`,
+		`  - a: true`,
+		`    b: []`,
+		`  - - 3`,
+		`    - 4`,
+		`nested:`,
+		`  deep:`,
+		`    key: value`,
+		`quoted:`,
+		`  - ""`,
+		`  - "true"`,
+		`  - "123"`,
+		`  - "- x"`,
+		`  - "a: b"`,
+		`  - "yes"`,
+		`  - "<<"`,
+		`  - " lead"`,
+		`  - "trail "`,
+		`  - "x #y"`,
+		`multi: |`,
+		`  line 1`,
+		`  line 2`,
+		`strip: |-`,
+		`  a`,
+		`  b`,
+		`keep: |+`,
+		`  a`,
+		``,
+		`esc: "tab\there\r\n"`,
+		`"key: colon": 1`,
+		``,
+	}, "\n"))
+
+	// round trip
+	got, err := UnmarshalYAML(b)
+	so(err, isNil)
+	so(got.Equal(v), isTrue)
+	so(got.MustMarshalString(OptSetSequence()), eq, v.MustMarshalString(OptSetSequence()))
+
+	// options
+	b, err = v.MustGet("nested").MarshalYAML(OptKeySequence([]string{"x"}))
+	so(err, isNil)
+	so(string(b), eq, "deep:\n  key: value\n")
+	b, err = MustUnmarshalString(`{"b":null,"a":1}`).MarshalYAML(OptOmitNull(true), OptDefaultStringSequence())
+	so(err, isNil)
+	so(string(b), eq, "a: 1\n")
+
+	// root scalars
+	for _, s := range []string{`"text"`, `12`, `null`, `true`, `"multi\nline"`, `[]`, `{}`} {
+		b, err := MustUnmarshalString(s).MarshalYAML()
+		so(err, isNil)
+		got, err := UnmarshalYAML(b)
+		so(err, isNil)
+		so(got.MustMarshalString(), eq, s)
+	}
+	b, err = NewFloat64(math.Inf(-1)).MarshalYAML()
+	so(err, isNil)
+	so(string(b), eq, "-.inf\n")
+
+	_, err = (&V{}).MarshalYAML()
+	so(errors.Is(err, ErrValueUninitialized), isTrue)
+}
+
+func testYAMLErrors(t *testing.T) {
+	for _, s := range []string{
+		"a: 1\n  b: 2",        // bad indentation
+		"a: 1\na: 2",          // duplicated key
+		"a: *unknown",         // undefined alias
+		"[1, 2",               // unterminated flow sequence
+		"{a: 1, b}]",          // mismatched bracket
+		"'unterminated",       // unterminated quote
+		"\"bad \\q escape\"",  // invalid escape
+		"a: 1\n---\nb: 2",     // multiple documents
+		"key: [1] extra",      // extra content
+		"a: !!int abc",        // invalid tagged value
+		"<<: 1",               // invalid merge
+		"- a\nb: 1",           // mapping after sequence
+		"a:\n  - 1\n  b: 2",   // mapping in sequence
+		"\xff",                // invalid UTF-8
+		"key: |x\n  text",     // invalid block scalar header
+		".inf",                // infinity not supported by JSON
+		"a: [-.Inf]",          // negative infinity
+		"a: .nan",             // NaN
+		"a: !!float .inf",     // tagged infinity
+		"a: 1e400",            // out of range of float64
+		"k:\n\t- 1",           // tab as indentation
+		"k:\n  a: 1\n \tb: 2", // tab in indentation
+	} {
+		v, err := UnmarshalYAML([]byte(s))
+		so(err, isErr)
+		so(errors.Is(err, ErrRawBytesUnrecignized), isTrue)
+		so(v.ValueType(), eq, NotExist)
+	}
+
+	_, err := UnmarshalYAML([]byte("a: 1\nb: [1,\n  2\nc: 3"))
+	so(err, isErr)
+	so(err.Error(), hasSubStr, "line 4")
+
+	// tabs are allowed as separation and in blank lines
+	so(yamlToJSON("k:\t1\n\t\nl: [\t2]\t# comment"), eq, `{"k":1,"l":[2]}`)
+}